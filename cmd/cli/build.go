@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+const (
+	defaultPresetDir  = "./../../sources/presets/package4"
+	defaultOutputPath = "./output/entities.json"
+	defaultWorkers    = 200
+)
+
+func runBuild(args []string) int {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputPath := fs.String("out", defaultOutputPath, "output entities.json path")
+	bundlePath := fs.String("bundle", "", "also write bundle (.tar.gz) to this path")
+	signPath := fs.String("sign", "", "private key file to sign the bundle, overrides build config")
+	i18n := fs.String("i18n", preset.I18nAnnotations,
+		"localized schemas: annotations (title + x-i18n) or split (schema per locale)")
+	fs.Parse(args)
+
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", outputPath, pf.build.Outputs.Entities)
+	pf.override("bundle", bundlePath, pf.build.Outputs.Bundle)
+	pf.override("i18n", i18n, pf.build.Outputs.I18n)
+
+	var key *preset.SigningKey
+	if *bundlePath != "" {
+		var ok bool
+		if key, ok = pf.signingKey("sign", signPath); !ok {
+			return 1
+		}
+	}
+
+	if *i18n != preset.I18nAnnotations && *i18n != preset.I18nSplit {
+		fmt.Printf("invalid -i18n mode: %s\n", *i18n)
+		return 2
+	}
+
+	pkg, processed, loaded := pf.loadLinted()
+	if !loaded {
+		return 1
+	}
+
+	if *i18n == preset.I18nSplit {
+		if err := preset.SplitSchemasByLocale(pkg, processed); err != nil {
+			fmt.Printf("Failed to split schemas by locale: %v\n", err)
+			return 1
+		}
+	}
+
+	if len(processed) > 0 {
+		if err := preset.SaveEntitiesToJSON(processed, *outputPath); err != nil {
+			fmt.Printf("Failed to save JSON: %v\n", err)
+			return 1
+		}
+	}
+
+	if *bundlePath != "" {
+		manifest, err := preset.SaveBundle(pkg, processed, *bundlePath, key)
+		if err != nil {
+			fmt.Printf("Failed to save bundle: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Saved bundle of %d entities to %s\n", len(manifest.Entities), *bundlePath)
+		if key != nil {
+			fmt.Printf("Signed with key %s\n", key.ID)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"yieldaa/runtime/internal/preset"
+)
+
+// cache stats|prune|clear
+func runCache(args []string) int {
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "prune" && args[0] != "clear") {
+		fmt.Fprintln(os.Stderr, "usage: cli cache <stats|prune|clear> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("cache "+action, flag.ExitOnError)
+	dir := fs.String("cache", preset.DefaultCacheDir(), "build cache directory")
+	maxAge := fs.Duration("max-age", 30*24*time.Hour, "prune: remove entries not used for this long, 0 - keep")
+	fs.Parse(args[1:])
+
+	// existing cache only: nothing is created, untagged directories are never touched
+	cache, err := preset.ExistingBuildCache(*dir)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No cache in %s\n", *dir)
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("Failed to open cache: %v\n", err)
+		return 1
+	}
+
+	switch action {
+	case "stats":
+		usage, err := cache.Usage()
+		if err != nil {
+			fmt.Printf("Failed to read cache: %v\n", err)
+			return 1
+		}
+		fmt.Printf("cache %s (build %s)\n", cache.Dir(), preset.CacheVersion())
+		for _, u := range usage {
+			current := ""
+			if u.Version == preset.CacheVersion() {
+				current = " *"
+			}
+			fmt.Printf("  %-12s entries:%d size:%.1fKB%s\n", u.Version, u.Entries, float64(u.Size)/1024, current)
+		}
+
+	case "prune":
+		removed, err := cache.Prune(*maxAge)
+		if err != nil {
+			fmt.Printf("Failed to prune cache: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed %d cache entries from %s\n", removed, cache.Dir())
+
+	case "clear":
+		if err := cache.Clear(); err != nil {
+			fmt.Printf("Failed to clear cache: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Cleared cache %s\n", cache.Dir())
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"strings"
+	"yieldaa/runtime/internal/preset"
+)
+
+func runFake(args []string) int {
+	fs := flag.NewFlagSet("fake", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputDir := fs.String("out", "./output/fake", "output directory")
+	entityKey := fs.String("entity", "", "entity key module.object.property.code (default all)")
+	count := fs.Int("n", 10, "records per entity")
+	seed := fs.Int64("seed", 1, "random seed, same seed gives same records")
+	format := fs.String("format", "jsonl", "output format: jsonl or csv")
+	invalid := fs.Bool("invalid", false, "emit records breaking one rule each")
+	fs.Parse(args)
+
+	if *format != "jsonl" && *format != "csv" {
+		fmt.Printf("Unknown format %q, expected jsonl or csv\n", *format)
+		return 2
+	}
+
+	pkg, processed, ok := pf.load()
+	if !ok {
+		return 1
+	}
+
+	files := make(map[string][]byte)
+	for _, pe := range processed {
+		key := preset.EntityKey(pe.ParsedData)
+		if pe.Schema == nil || (*entityKey != "" && key != *entityKey) {
+			continue
+		}
+
+		// per-entity seed: adding entities does not change records of others
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		records, err := preset.FakeRecords(pe.ParsedData, preset.FakeOptions{
+			Count:   *count,
+			Seed:    *seed ^ int64(h.Sum64()),
+			Region:  pkg.Region,
+			Invalid: *invalid,
+			Format:  *format,
+		})
+		if err != nil {
+			fmt.Printf("Failed to generate records of %s: %v\n", key, err)
+			return 1
+		}
+
+		var buf bytes.Buffer
+		if *format == "csv" {
+			err = preset.WriteRecordsCSV(&buf, pe.ParsedData, records)
+		} else {
+			err = preset.WriteRecordsJSONL(&buf, records)
+		}
+		if err != nil {
+			fmt.Printf("Failed to encode records of %s: %v\n", key, err)
+			return 1
+		}
+
+		parts := strings.SplitN(key, ".", 2)
+		files[path.Join(parts[0], strings.ReplaceAll(parts[1], ".", "_")+"."+*format)] = buf.Bytes()
+	}
+
+	if *entityKey != "" && len(files) == 0 {
+		fmt.Printf("Entity %s not found or invalid\n", *entityKey)
+		return 1
+	}
+
+	if err := preset.SaveFiles(files, *outputDir); err != nil {
+		fmt.Printf("Failed to save records: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Generated %d records for %d entities in %s\n", *count*len(files), len(files), *outputDir)
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+// fmt - rewrite package.yml and entity files in canonical layout
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	dir := fs.String("dir", defaultPresetDir, "preset directory")
+	check := fs.Bool("check", false, "only report files that need formatting, exit 1 if any")
+	fs.Parse(args)
+
+	results, err := preset.FormatPreset(*dir, !*check)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+
+	changed, failed := 0, 0
+	for _, res := range results {
+		switch {
+		case res.Err != nil:
+			failed++
+			fmt.Printf("✗ %s: %v\n", res.Path, res.Err)
+		case res.Changed:
+			changed++
+			fmt.Printf("%s\n", res.Path)
+		}
+	}
+
+	if *check {
+		fmt.Printf("\n%d of %d files need formatting\n", changed, len(results))
+	} else {
+		fmt.Printf("\nformatted %d of %d files\n", changed, len(results))
+	}
+	if failed > 0 || (*check && changed > 0) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"yieldaa/runtime/internal/preset"
+)
+
+// code generators: gen <target> [flags]
+var generators = map[string]func(pkg *preset.Package, processed []preset.ProcessedEntity) (map[string][]byte, error){
+	"go":   preset.GenerateGo,
+	"html": preset.GenerateHTMLDocs,
+	"md":   preset.GenerateMarkdownDocs,
+	"sql":  preset.GenerateSQL,
+	"ts":   preset.GenerateTypeScript,
+}
+
+func runGen(args []string) int {
+	if len(args) == 0 || generators[args[0]] == nil {
+		targets := make([]string, 0, len(generators))
+		for target := range generators {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		fmt.Fprintf(os.Stderr, "usage: cli gen <target> [flags]\n\ntargets: %v\n", targets)
+		return 2
+	}
+	target, generate := args[0], generators[args[0]]
+
+	fs := flag.NewFlagSet("gen "+target, flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputDir := fs.String("out", "./output/"+target, "output directory")
+	fs.Parse(args[1:])
+
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", outputDir, pf.build.Outputs.Gen[target])
+
+	pkg, processed, ok := pf.load()
+	if !ok {
+		return 1
+	}
+
+	files, err := generate(pkg, processed)
+	if err != nil {
+		fmt.Printf("Failed to generate %s: %v\n", target, err)
+		return 1
+	}
+
+	if err := preset.SaveFiles(files, *outputDir); err != nil {
+		fmt.Printf("Failed to save %s: %v\n", target, err)
+		return 1
+	}
+
+	fmt.Printf("Generated %d %s files in %s\n", len(files), target, *outputDir)
+	return 0
+}
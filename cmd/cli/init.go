@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"yieldaa/runtime/internal/preset"
+)
+
+// init - skeleton of new preset: package.yml, entities directory and build config
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", ".", "preset directory, created if missing")
+	name := fs.String("name", "", "package name (default: name of the directory)")
+	version := fs.String("version", "0.1.0", "package version X.Y.Z")
+	region := fs.String("region", "", "region code, e.g. ru")
+	description := fs.String("description", "", "package description")
+	locales := fs.String("locales", "", "comma-separated locales, e.g. ru,en")
+	defaultLocale := fs.String("default-locale", "", "default locale (default: first of -locales)")
+	fs.Parse(args)
+
+	opts := preset.InitOptions{
+		Name:          *name,
+		Version:       *version,
+		Region:        *region,
+		Description:   *description,
+		DefaultLocale: *defaultLocale,
+	}
+	for _, locale := range strings.Split(*locales, ",") {
+		if locale = strings.TrimSpace(locale); locale != "" {
+			opts.Locales = append(opts.Locales, locale)
+		}
+	}
+
+	created, err := preset.InitPreset(*dir, opts)
+	for _, path := range created {
+		fmt.Printf("created %s\n", path)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+	return 0
+}
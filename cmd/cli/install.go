@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+// install - dependencies of package.yml from registry into vendor directory
+func runInstall(args []string) int {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	registry := fs.String("registry", "", "registry directory or URL, overrides build config")
+	vendor := fs.String("vendor", "", "vendor directory (default <dir>/vendor)")
+	fs.Parse(args)
+
+	if preset.IsBundle(*pf.dir) || preset.IsArchive(*pf.dir) {
+		fmt.Printf("install needs the source directory of the package, not a bundle or archive\n")
+		return 2
+	}
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("registry", registry, pf.build.Registry)
+	if *registry == "" {
+		fmt.Printf("no registry: use -registry or registry in %s\n", preset.ConfigFileName)
+		return 2
+	}
+	trust, ok := pf.trustStore()
+	if !ok {
+		return 1
+	}
+
+	pkg, err := preset.LoadPreset(*pf.dir)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+
+	reg := preset.OpenRegistry(*registry)
+	lock, err := preset.InstallDependencies(*pf.dir, pkg, reg, preset.InstallOptions{Vendor: *vendor, Trust: trust})
+	if err != nil {
+		fmt.Printf("install failed: %v\n", err)
+		return 1
+	}
+
+	for _, p := range lock.Packages {
+		signed := ""
+		if p.KeyID != "" {
+			signed = ", signed by " + p.KeyID
+		}
+		fmt.Printf("✓ %s %s (required by %v%s)\n", p.ID, p.Version, p.Required, signed)
+	}
+	fmt.Printf("installed %d packages from %s\n", len(lock.Packages), reg)
+	return 0
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+// keygen - ed25519 key pair for bundle signatures
+func runKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	prefix := fs.String("out", "./signing", "key files prefix: <out>.key and <out>.pub")
+	fs.Parse(args)
+
+	key, err := preset.GenerateKeyPair(*prefix)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+	fmt.Printf("key %s\nprivate: %s%s (keep secret)\npublic:  %s%s (add to trust stores)\n",
+		key.ID, *prefix, preset.PrivateKeyExt, *prefix, preset.PublicKeyExt)
+	return 0
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+// lint - style checks beyond validation, configured in the lint section of yieldaa.yml
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := fs.String("dir", defaultPresetDir, "preset directory or source archive (.zip, .tar)")
+	list := fs.Bool("list", false, "list rules with default severities")
+	fs.Parse(args)
+
+	if *list {
+		for _, r := range preset.LintRules() {
+			fmt.Printf("%-18s %-8s %s\n", r.Name, r.Severity, r.Description)
+		}
+		return 0
+	}
+
+	issues, err := lintDir(*dir)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+
+	errorsCount := 0
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Severity == preset.LintError {
+			errorsCount++
+		}
+	}
+	fmt.Printf("\n%d issues, %d errors\n", len(issues), errorsCount)
+
+	if errorsCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// lintDir - lint of preset directory or source archive
+func lintDir(dir string) ([]preset.LintIssue, error) {
+	if !preset.IsArchive(dir) {
+		return preset.LintPreset(dir)
+	}
+	sources, err := preset.OpenArchive(dir)
+	if err != nil {
+		return nil, err
+	}
+	return preset.LintPresetFS(sources)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"yieldaa/runtime/internal/lsp"
+)
+
+// lsp - language server on stdin/stdout for editors
+func runLSP(args []string) int {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	dir := fs.String("dir", "", "preset directory, empty - root of the editor workspace")
+	fs.Parse(args)
+
+	// stdout is the protocol stream, logs go to stderr
+	server := lsp.NewServer(os.Stdin, os.Stdout, os.Stderr, *dir)
+	if err := server.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "lsp: %v\n", err)
+		return 1
+	}
+	return 0
+}
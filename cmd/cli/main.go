@@ -4,27 +4,58 @@ package main
 import (
 	"fmt"
 	"os"
-	"yieldaa/runtime/internal/preset"
+	"sort"
+	"strings"
 )
 
-func main() {
-	pkg, processed, fatalErrs := preset.LoadAndProcessPreset(
-		"./../../sources/presets/package4", 200)
+var commands = map[string]func(args []string) int{
+	"build":    runBuild,
+	"cache":    runCache,
+	"fake":     runFake,
+	"fmt":      runFmt,
+	"gen":      runGen,
+	"init":     runInit,
+	"install":  runInstall,
+	"keygen":   runKeygen,
+	"lint":     runLint,
+	"lsp":      runLSP,
+	"new":      runNew,
+	"openapi":  runOpenAPI,
+	"proto":    runProto,
+	"publish":  runPublish,
+	"registry": runRegistry,
+	"release":  runRelease,
+	"validate": runValidate,
+	"verify":   runVerify,
+	"watch":    runWatch,
+}
 
-	preset.PrintResults(pkg, processed, fatalErrs)
+func main() {
+	// build is the default command: `cli -dir ...` == `cli build -dir ...`
+	name, args := "build", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	if len(fatalErrs) > 0 || preset.HasValidationErrors(processed) {
-		os.Exit(1)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", name)
+		usage()
+		os.Exit(2)
 	}
 
-	if len(processed) > 0 {
-		outputPath := "./output/entities.json"
-		if err := preset.SaveEntitiesToJSON(processed, outputPath); err != nil {
-			fmt.Printf("Failed to save JSON: %v\n", err)
-		}
+	os.Exit(cmd(args))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	if len(fatalErrs) > 0 || preset.HasValidationErrors(processed) {
-		os.Exit(1)
+	fmt.Fprintf(os.Stderr, "usage: cli <command> [flags]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
 	}
 }
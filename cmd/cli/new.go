@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"yieldaa/runtime/internal/preset"
+)
+
+// new entity [flags] <key> - entity file from template or with fields of another entity
+func runNew(args []string) int {
+	usage := func() int {
+		fmt.Fprintf(os.Stderr, "usage: cli new entity [flags] <module.object.property.code>\n")
+		return 2
+	}
+	if len(args) < 1 || args[0] != "entity" {
+		return usage()
+	}
+
+	fs := flag.NewFlagSet("new entity", flag.ExitOnError)
+	dir := fs.String("dir", defaultPresetDir, "preset directory")
+	from := fs.String("from", "", "entity key of the package or entity file to clone fields from")
+	name := fs.String("name", "", "entity name in every locale (default: words of the code)")
+	out := fs.String("out", "", "entity file path (default: <dir>/entities/<module>/<code>.yml)")
+	fs.Parse(args[1:])
+
+	// flags may follow the key too
+	key := fs.Arg(0)
+	fs.Parse(fs.Args()[min(1, fs.NArg()):])
+	if key == "" || fs.NArg() > 0 {
+		return usage()
+	}
+
+	path, err := preset.NewEntity(*dir, key, preset.NewEntityOptions{From: *from, Name: *name, Path: *out})
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return 1
+	}
+	fmt.Printf("created %s\n", path)
+	return 0
+}
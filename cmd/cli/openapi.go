@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"yieldaa/runtime/internal/preset"
+)
+
+func runOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputPath := fs.String("out", "./output/openapi.json", "output path (.json, .yml or .yaml)")
+	fs.Parse(args)
+
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", outputPath, pf.build.Outputs.OpenAPI)
+
+	pkg, processed, ok := pf.load()
+	if !ok {
+		return 1
+	}
+
+	doc, err := preset.GenerateOpenAPI(pkg, processed)
+	if err != nil {
+		fmt.Printf("Failed to generate OpenAPI: %v\n", err)
+		return 1
+	}
+
+	if err := preset.SaveDocument(doc, *outputPath); err != nil {
+		fmt.Printf("Failed to save OpenAPI: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Saved OpenAPI %s with %d schemas to %s\n",
+		preset.OpenAPIVersion, len(doc["components"].(map[string]any)["schemas"].(map[string]any)), *outputPath)
+	return 0
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"yieldaa/runtime/internal/preset"
+)
+
+// presetFlags - flags of commands that load and process a preset;
+// flags given on the command line override the build config, the config overrides flag defaults
+type presetFlags struct {
+	fs       *flag.FlagSet
+	dir      *string
+	workers  *int
+	cacheDir *string
+	noCache  *bool
+	strict   *bool
+	trust    *string
+
+	build   *preset.BuildConfig // nil until configure
+	sources fs.FS               // sources of archive -dir, nil - directory
+}
+
+func addPresetFlags(fs *flag.FlagSet) *presetFlags {
+	return &presetFlags{
+		fs:       fs,
+		dir:      fs.String("dir", defaultPresetDir, "preset directory, source archive (.zip, .tar) or bundle (.tar.gz)"),
+		workers:  fs.Int("workers", defaultWorkers, "number of workers, overrides build config"),
+		cacheDir: fs.String("cache", preset.DefaultCacheDir(), "build cache directory"),
+		noCache:  fs.Bool("no-cache", false, "process every file, do not read or write build cache"),
+		strict:   fs.Bool("strict", false, "lint warnings fail build and release, overrides build config"),
+		trust:    fs.String("trust", "", "trust store of bundles: public key or directory of *.pub, refuses unsigned bundles"),
+	}
+}
+
+// isSet - flag is given on the command line
+func (f *presetFlags) isSet(name string) bool {
+	set := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		set = set || fl.Name == name
+	})
+	return set
+}
+
+// configure - read build config of the preset; flags not given on the command line take its values.
+// Bundles are built already, they have no build config
+func (f *presetFlags) configure() bool {
+	if f.build != nil {
+		return true
+	}
+	if preset.IsBundle(*f.dir) {
+		f.build = &preset.BuildConfig{}
+		return true
+	}
+	var cfg preset.BuildConfig
+	var err error
+	if preset.IsArchive(*f.dir) {
+		if f.sources, err = preset.OpenArchive(*f.dir); err == nil {
+			cfg, err = preset.LoadBuildConfigFS(f.sources)
+		}
+	} else {
+		cfg, err = preset.LoadBuildConfig(*f.dir)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return false
+	}
+	f.build = &cfg
+
+	if !f.isSet("workers") && cfg.Workers > 0 {
+		*f.workers = cfg.Workers
+	}
+	if !f.isSet("strict") {
+		*f.strict = cfg.Strict
+	}
+	return true
+}
+
+// override - value of build config for flag not given on the command line
+func (f *presetFlags) override(name string, value *string, configured string) {
+	if configured != "" && !f.isSet(name) {
+		*value = configured
+	}
+}
+
+// signingKey - key of the flag, of build config otherwise; nil - bundles are not signed
+func (f *presetFlags) signingKey(name string, path *string) (*preset.SigningKey, bool) {
+	f.override(name, path, f.build.SigningKey)
+	if *path == "" {
+		return nil, true
+	}
+	key, err := preset.LoadSigningKey(*path)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil, false
+	}
+	return key, true
+}
+
+// processOptions - workers and build cache of flags
+func (f *presetFlags) processOptions() preset.ProcessOptions {
+	opts := preset.ProcessOptions{Workers: *f.workers}
+	if !*f.noCache {
+		cache, err := preset.OpenBuildCache(*f.cacheDir)
+		if err != nil {
+			// build still works without cache
+			fmt.Printf("cache disabled: %v\n", err)
+		}
+		opts.Cache = cache
+	}
+	return opts
+}
+
+// trustStore - trust store of the flag; nil - signatures are not required
+func (f *presetFlags) trustStore() (*preset.TrustStore, bool) {
+	if *f.trust == "" {
+		return nil, true
+	}
+	trust, err := preset.LoadTrustStore(*f.trust)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil, false
+	}
+	return trust, true
+}
+
+// load - load, process and print report; ok is false on fatal or validation errors
+func (f *presetFlags) load() (*preset.Package, []preset.ProcessedEntity, bool) {
+	if !f.configure() {
+		return nil, nil, false
+	}
+	if preset.IsBundle(*f.dir) {
+		trust, ok := f.trustStore()
+		if !ok {
+			return nil, nil, false
+		}
+		pkg, processed, err := preset.LoadBundleWith(*f.dir, preset.BundleOptions{Trust: trust})
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return nil, nil, false
+		}
+		preset.PrintResults(pkg, processed, nil)
+		return pkg, processed, true
+	}
+	opts := f.processOptions()
+
+	var pkg *preset.Package
+	var processed []preset.ProcessedEntity
+	var fatalErrs []error
+	if f.sources != nil {
+		pkg, processed, fatalErrs = preset.LoadAndProcessPresetFS(f.sources, opts)
+	} else {
+		pkg, processed, fatalErrs = preset.LoadAndProcessPresetWith(*f.dir, opts)
+	}
+	if pkg == nil {
+		for _, err := range fatalErrs {
+			fmt.Printf("%v\n", err)
+		}
+		return nil, nil, false
+	}
+
+	preset.PrintResults(pkg, processed, fatalErrs)
+	if opts.Cache != nil {
+		s := opts.Cache.Stats()
+		fmt.Printf("\ncache: hits:%d misses:%d writes:%d failures:%d (%s)\n",
+			s.Hits, s.Misses, s.Writes, s.Failures, opts.Cache.Dir())
+	}
+
+	if len(fatalErrs) > 0 || preset.HasValidationErrors(processed) {
+		return pkg, processed, false
+	}
+	return pkg, processed, true
+}
+
+// loadLinted - load, then lint of the sources; only build and release are gated by lint,
+// it reads every entity file once more
+func (f *presetFlags) loadLinted() (*preset.Package, []preset.ProcessedEntity, bool) {
+	pkg, processed, ok := f.load()
+	if pkg == nil || preset.IsBundle(*f.dir) {
+		return pkg, processed, ok
+	}
+	linted := f.lint()
+	return pkg, processed, ok && linted
+}
+
+// lint - print lint errors, and warnings in strict mode; false if any of them is printed
+func (f *presetFlags) lint() bool {
+	var issues []preset.LintIssue
+	var err error
+	if f.sources != nil {
+		issues, err = preset.LintPresetFS(f.sources)
+	} else {
+		issues, err = preset.LintPreset(*f.dir)
+	}
+	if err != nil {
+		fmt.Printf("\nlint: %v\n", err)
+		return false
+	}
+
+	errorsCount, warnings := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == preset.LintError {
+			errorsCount++
+		} else {
+			warnings++
+		}
+	}
+	if errorsCount == 0 && warnings == 0 {
+		return true
+	}
+
+	if errorsCount > 0 || *f.strict {
+		fmt.Printf("\nLINT:\n")
+		for _, issue := range issues {
+			if issue.Severity == preset.LintError || *f.strict {
+				fmt.Printf("  %s\n", issue)
+			}
+		}
+	}
+	fmt.Printf("\nlint: %d errors, %d warnings", errorsCount, warnings)
+	if !*f.strict && warnings > 0 {
+		fmt.Printf(" (warnings are listed by the lint command, -strict fails on them)")
+	}
+	fmt.Println()
+
+	return errorsCount == 0 && (!*f.strict || warnings == 0)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"yieldaa/runtime/internal/preset"
+)
+
+// proto numbers sidecar, kept in preset directory under version control
+const protoNumbersFile = "proto_numbers.json"
+
+func runProto(args []string) int {
+	fs := flag.NewFlagSet("proto", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputDir := fs.String("out", "./output/proto", "output directory")
+	numbersPath := fs.String("numbers", "", "field numbers mapping (default <dir>/"+protoNumbersFile+")")
+	checkPath := fs.String("check", "", "numbers mapping of previous version: only check compatibility")
+	fs.Parse(args)
+
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", outputDir, pf.build.Outputs.Proto)
+
+	if *numbersPath == "" {
+		// archives and bundles are read-only, the sidecar has no place next to package.yml
+		if info, err := os.Stat(*pf.dir); err != nil || !info.IsDir() {
+			fmt.Printf("-numbers is required when -dir is not a preset directory: %s\n", *pf.dir)
+			return 2
+		}
+		*numbersPath = filepath.Join(*pf.dir, protoNumbersFile)
+	}
+
+	pkg, processed, ok := pf.load()
+	if !ok {
+		return 1
+	}
+
+	numbers, err := preset.LoadProtoNumbers(*numbersPath)
+	if err != nil {
+		fmt.Printf("Failed to load proto numbers: %v\n", err)
+		return 1
+	}
+	preset.AssignProtoNumbers(numbers, processed)
+
+	if *checkPath != "" {
+		prev, err := preset.LoadProtoNumbers(*checkPath)
+		if err != nil {
+			fmt.Printf("Failed to load previous proto numbers: %v\n", err)
+			return 1
+		}
+		if errs := preset.CheckProtoCompat(prev, numbers); len(errs) > 0 {
+			fmt.Println("INCOMPATIBLE FIELD NUMBERS:")
+			for _, e := range errs {
+				fmt.Printf("  • %s\n", e)
+			}
+			return 1
+		}
+		fmt.Printf("Field numbers are compatible with %s\n", *checkPath)
+		return 0
+	}
+
+	files, err := preset.GenerateProto(pkg, processed, numbers)
+	if err != nil {
+		fmt.Printf("Failed to generate proto: %v\n", err)
+		return 1
+	}
+	if err := preset.SaveFiles(files, *outputDir); err != nil {
+		fmt.Printf("Failed to save proto: %v\n", err)
+		return 1
+	}
+	if err := preset.SaveProtoNumbers(numbers, *numbersPath); err != nil {
+		fmt.Printf("Failed to save proto numbers: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Generated %d proto files in %s, field numbers in %s\n", len(files), *outputDir, *numbersPath)
+	return 0
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"yieldaa/runtime/internal/preset"
+)
+
+// publish - bundles into local registry directory
+func runPublish(args []string) int {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	registry := fs.String("registry", "", "registry directory")
+	trustPath := fs.String("trust", "", "trust store: public key or directory of *.pub; unsigned bundles are refused")
+	fs.Parse(args)
+
+	if *registry == "" || fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "usage: cli publish -registry <dir> [-trust path] <bundle.tar.gz>...\n")
+		return 2
+	}
+	if preset.IsRegistryURL(*registry) {
+		fmt.Printf("publish needs a registry directory, serve it over HTTP with `cli registry serve`\n")
+		return 2
+	}
+
+	var opts preset.BundleOptions
+	if *trustPath != "" {
+		trust, err := preset.LoadTrustStore(*trustPath)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		opts.Trust = trust
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var release *preset.RegistryRelease
+			if release, err = preset.PublishBundle(*registry, data, opts, time.Now()); err == nil {
+				fmt.Printf("✓ %s: published %s %s\n", path, release.File, release.SHA256[:12])
+				continue
+			}
+		}
+		failed++
+		fmt.Printf("✗ %s: %v\n", path, err)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
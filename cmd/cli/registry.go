@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"yieldaa/runtime/internal/preset"
+)
+
+// registry list|search|serve
+func runRegistry(args []string) int {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "search" && args[0] != "serve") {
+		fmt.Fprintln(os.Stderr, "usage: cli registry <list|search <query>|serve> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("registry "+action, flag.ExitOnError)
+	location := fs.String("registry", ".", "registry directory or URL")
+	addr := fs.String("addr", ":8080", "serve: listen address")
+	fs.Parse(args[1:])
+
+	if action == "serve" {
+		if preset.IsRegistryURL(*location) {
+			fmt.Printf("serve needs a registry directory\n")
+			return 2
+		}
+		fmt.Printf("serving registry %s on %s\n", *location, *addr)
+		if err := http.ListenAndServe(*addr, preset.RegistryHandler(*location)); err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	query := ""
+	if action == "search" {
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "usage: cli registry search [-registry location] <query>")
+			return 2
+		}
+		query = strings.Join(fs.Args(), " ")
+	}
+
+	reg := preset.OpenRegistry(*location)
+	idx, err := reg.Index()
+	if err != nil {
+		fmt.Printf("registry %s: %v\n", reg, err)
+		return 1
+	}
+
+	ids := idx.Search(query)
+	for _, id := range ids {
+		p := idx.Packages[id]
+		latest := p.Latest()
+		versions := make([]string, len(p.Releases))
+		for i, r := range p.Releases {
+			versions[i] = r.Version
+		}
+		fmt.Printf("%s %s  %s\n", id, latest.Version, latest.Description)
+		fmt.Printf("  versions: %s\n", strings.Join(versions, ", "))
+		if len(latest.Tags) > 0 {
+			fmt.Printf("  tags: %s\n", strings.Join(latest.Tags, ", "))
+		}
+	}
+	fmt.Printf("%d packages in %s\n", len(ids), reg)
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"time"
+	"yieldaa/runtime/internal/preset"
+)
+
+// release - bump version by changes since the last release, write CHANGELOG.md entry and bundle
+func runRelease(args []string) int {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	releasesDir := fs.String("out", "", "release bundles directory (default <dir>/releases)")
+	bump := fs.String("bump", "", "patch, minor or major; default - the level required by changes")
+	dryRun := fs.Bool("dry-run", false, "print version and changelog entry, change nothing")
+	signPath := fs.String("sign", "", "private key file to sign the bundle, overrides build config")
+	fs.Parse(args)
+
+	if preset.IsBundle(*pf.dir) || preset.IsArchive(*pf.dir) {
+		fmt.Printf("release needs the source directory of the package, not a bundle or archive\n")
+		return 2
+	}
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", releasesDir, pf.build.Outputs.Releases)
+	if *releasesDir == "" {
+		*releasesDir = filepath.Join(*pf.dir, "releases")
+	}
+	key, ok := pf.signingKey("sign", signPath)
+	if !ok {
+		return 1
+	}
+
+	pkg, processed, loaded := pf.loadLinted()
+	if !loaded {
+		fmt.Printf("\nrelease refused: fix the errors above first\n")
+		return 1
+	}
+
+	plan, err := preset.PlanRelease(pkg, processed, *releasesDir, *bump, time.Now())
+	if err != nil {
+		fmt.Printf("\nrelease refused: %v\n", err)
+		return 1
+	}
+
+	previous := plan.Previous
+	if previous == "" {
+		previous = "first release"
+	}
+	fmt.Printf("\nrelease %s %s (%s, changes require %s)\n\n%s",
+		plan.Name, plan.Version, previous, plan.Required, plan.Changelog)
+	if *dryRun {
+		return 0
+	}
+
+	path, err := preset.WriteRelease(*pf.dir, pkg, processed, plan, *releasesDir, key)
+	if err != nil {
+		fmt.Printf("\nrelease failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nreleased %s\n", path)
+	if key != nil {
+		fmt.Printf("signed with key %s\n", key.ID)
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"yieldaa/runtime/internal/preset"
+)
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	entityKey := fs.String("entity", "", "entity key module.object.property.code")
+	input := fs.String("in", "", "records file (.jsonl or .csv)")
+	format := fs.String("format", "", "records format: jsonl or csv (default by extension)")
+	reportPath := fs.String("report", "-", "per-row violations as JSONL, - for stdout")
+	columns := fs.String("map", "", "CSV header mapping: 'Header=code,Header2=code2'")
+	fs.Parse(args)
+
+	if *entityKey == "" || *input == "" {
+		fmt.Fprintln(os.Stderr, "usage: cli validate -entity module.object.property.code -in records.csv [flags]")
+		return 2
+	}
+
+	mapping := make(map[string]string)
+	if *columns != "" {
+		for _, pair := range strings.Split(*columns, ",") {
+			header, code, ok := strings.Cut(pair, "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, "invalid -map entry %q, expected Header=code\n", pair)
+				return 2
+			}
+			mapping[strings.TrimSpace(header)] = strings.TrimSpace(code)
+		}
+	}
+
+	pkg, processed, ok := pf.load()
+	if !ok {
+		return 1
+	}
+
+	var entity map[string]any
+	for _, pe := range processed {
+		if pe.Schema != nil && preset.EntityKey(pe.ParsedData) == *entityKey {
+			entity = pe.ParsedData
+		}
+	}
+	if entity == nil {
+		fmt.Printf("Entity %s not found or invalid\n", *entityKey)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *reportPath != "-" {
+		f, err := os.Create(*reportPath)
+		if err != nil {
+			fmt.Printf("Failed to create report: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	summary, err := preset.ValidateRecordsFile(entity, *input, preset.BatchOptions{
+		Format:  *format,
+		Region:  pkg.Region,
+		Workers: pf.processOptions().Workers,
+		Columns: mapping,
+	}, func(row preset.RowResult) error {
+		return encoder.Encode(row)
+	})
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		fmt.Printf("Failed to validate %s: %v\n", *input, err)
+		return 1
+	}
+
+	printBatchSummary(*input, summary)
+	if summary.Invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printBatchSummary(input string, s preset.BatchSummary) {
+	fmt.Fprintf(os.Stderr, "\n%s: %d rows, %d valid, %d invalid (%v)\n",
+		input, s.Rows, s.Valid, s.Invalid, s.Duration.Round(1e6))
+
+	if len(s.Unmapped) > 0 {
+		fmt.Fprintf(os.Stderr, "unmapped columns (ignored): %s\n", strings.Join(s.Unmapped, ", "))
+	}
+
+	fields := make([]string, 0, len(s.ByField))
+	for field := range s.ByField {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if s.ByField[fields[i]] != s.ByField[fields[j]] {
+			return s.ByField[fields[i]] > s.ByField[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	for _, field := range fields {
+		name := field
+		if name == "" {
+			name = "(record)"
+		}
+		fmt.Fprintf(os.Stderr, "  %-30s %d\n", name, s.ByField[field])
+	}
+}
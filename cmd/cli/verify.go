@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"yieldaa/runtime/internal/preset"
+)
+
+// verify - checksums and signatures of bundles, against trust store if given
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	trustPath := fs.String("trust", "", "trust store: public key or directory of *.pub; unsigned bundles fail")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "usage: cli verify [-trust path] <bundle.tar.gz>...\n")
+		return 2
+	}
+
+	var opts preset.BundleOptions
+	if *trustPath != "" {
+		trust, err := preset.LoadTrustStore(*trustPath)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return 1
+		}
+		opts.Trust = trust
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		b, err := preset.OpenBundleWith(path, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("✗ %s: %v\n", path, err)
+			continue
+		}
+
+		m := b.Manifest
+		status := "unsigned"
+		if b.Signature != nil {
+			status = "signed by " + b.Signature.KeyID
+			if opts.Trust != nil {
+				status += " (trusted)"
+			}
+		}
+		fmt.Printf("✓ %s: %s %s, %d entities, %s\n", path, m.Name, m.Version, len(m.Entities), status)
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+	"yieldaa/runtime/internal/preset"
+)
+
+// watch - rebuild on changes of package.yml and entities until interrupted
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	pf := addPresetFlags(fs)
+	outputPath := fs.String("out", defaultOutputPath, "output entities.json path, empty - do not write")
+	gen := fs.String("gen", "", "comma-separated gen targets to rewrite on every build, e.g. go,ts")
+	genDir := fs.String("gen-out", "./output", "output directory of gen targets, files go to <gen-out>/<target>")
+	interval := fs.Duration("interval", 500*time.Millisecond, "polling interval")
+	fs.Parse(args)
+
+	if !pf.configure() {
+		return 1
+	}
+	pf.override("out", outputPath, pf.build.Outputs.Entities)
+
+	// target -> output directory: -gen-out, then build config, then default
+	targets := make(map[string]string)
+	if *gen != "" {
+		for _, target := range strings.Split(*gen, ",") {
+			target = strings.TrimSpace(target)
+			if generators[target] == nil {
+				fmt.Printf("unknown gen target: %s\n", target)
+				return 2
+			}
+			targets[target] = filepath.Join(*genDir, target)
+			if dir := pf.build.Outputs.Gen[target]; dir != "" && !pf.isSet("gen-out") {
+				targets[target] = dir
+			}
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	watcher := preset.NewWatcher(*pf.dir, pf.processOptions())
+	fmt.Printf("Watching %s every %s, Ctrl+C to stop\n", *pf.dir, *interval)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return 0
+			case <-ticker.C:
+			}
+		}
+
+		result, err := watcher.Poll()
+		if err != nil {
+			fmt.Printf("[%s] %v\n", time.Now().Format(time.TimeOnly), err)
+			continue
+		}
+		if result == nil {
+			continue
+		}
+
+		if first {
+			preset.PrintResults(result.Package, result.Processed, result.Fatal)
+		} else {
+			printWatchDiff(result)
+		}
+
+		if len(result.Fatal) > 0 || preset.HasValidationErrors(result.Processed) {
+			fmt.Printf("artifacts are not updated until errors are fixed\n")
+			continue
+		}
+		writeWatchArtifacts(result, *outputPath, targets)
+	}
+}
+
+// printWatchDiff - compact report: what was rebuilt, new (+) and resolved (-) errors
+func printWatchDiff(result *preset.WatchResult) {
+	what := fmt.Sprintf("%d changed", len(result.Changed))
+	if result.Reloaded {
+		what = "package config changed, all files reprocessed"
+	}
+	if len(result.Removed) > 0 {
+		what += fmt.Sprintf(", %d removed", len(result.Removed))
+	}
+	fmt.Printf("\n[%s] %s: errors +%d -%d\n",
+		time.Now().Format(time.TimeOnly), what, len(result.NewErrors), len(result.Resolved))
+
+	for _, e := range result.NewErrors {
+		fmt.Printf("  + %s\n", e)
+	}
+	for _, e := range result.Resolved {
+		fmt.Printf("  - %s\n", e)
+	}
+}
+
+func writeWatchArtifacts(result *preset.WatchResult, outputPath string, targets map[string]string) {
+	if outputPath != "" && len(result.Processed) > 0 {
+		if err := preset.SaveEntitiesToJSON(result.Processed, outputPath); err != nil {
+			fmt.Printf("Failed to save JSON: %v\n", err)
+		}
+	}
+
+	for target, dir := range targets {
+		files, err := generators[target](result.Package, result.Processed)
+		if err != nil {
+			fmt.Printf("Failed to generate %s: %v\n", target, err)
+			continue
+		}
+		if err := preset.SaveFiles(files, dir); err != nil {
+			fmt.Printf("Failed to save %s: %v\n", target, err)
+			continue
+		}
+		fmt.Printf("Generated %d %s files in %s\n", len(files), target, dir)
+	}
+}
@@ -2,7 +2,10 @@ module yieldaa/runtime
 
 go 1.22.2
 
-require github.com/ghodss/yaml v1.0.0
+require (
+	github.com/ghodss/yaml v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
@@ -0,0 +1,443 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"yieldaa/runtime/internal/preset"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entityDocs - top-level keys of entity file
+var entityDocs = map[string]string{
+	"module":      "Module of the entity, first part of the key `module.object.property.code`.",
+	"object":      "Object of the entity, second part of the key.",
+	"property":    "Property of the object, third part of the key.",
+	"code":        "Code of the entity, last part of the key.",
+	"name":        "Display name, string or map of locale -> text.",
+	"description": "Description, string or map of locale -> text.",
+	"fields":      "Fields of the entity record.",
+}
+
+// fieldDocs - keys of field definition
+var fieldDocs = map[string]string{
+	"code":           "Field code, key of the value in the record. Unique in the entity.",
+	"name":           "Display name, string or map of locale -> text.",
+	"description":    "Description, string or map of locale -> text.",
+	"type":           "Field type: built-in or registered type plugin.",
+	"required":       "Record must have the field.",
+	"default":        "Default value, must pass the field checks.",
+	"examples":       "Example values; used in documentation and by the fake generator.",
+	"pattern":        "Regular expression for strings, or `YYYY-MM-DD` for dates.",
+	"min":            "Minimum value for numbers, minimum length for strings.",
+	"max":            "Maximum value for numbers, maximum length for strings.",
+	"multiple_of":    "Number must be a multiple of this value.",
+	"values":         "Allowed values of `enum` field.",
+	"format":         "Named string format, e.g. email or phone.",
+	"phone_region":   "Region of phone numbers for `phone` format.",
+	"validator":      "Named value validator of the package region, e.g. inn or snils.",
+	"bik_field":      "Code of the field with BIK used by account validators.",
+	"precision":      "Decimal: total number of digits.",
+	"scale":          "Decimal: number of digits after the point.",
+	"encoding":       "Decimal: JSON encoding of values, `string` or `number`.",
+	"currency":       "Money: ISO 4217 currency code.",
+	"currency_field": "Money: code of the field with currency.",
+	"ref":            "Reference: key `module.object.property.code` of the target entity.",
+}
+
+// typeDocs - built-in types; registered plugins show their kind
+var typeDocs = map[string]string{
+	"string":    "Text value; supports pattern, min/max length, format and validator.",
+	"number":    "Floating point number; supports min, max and multiple_of.",
+	"integer":   "Whole number; supports min, max and multiple_of.",
+	"boolean":   "true or false.",
+	"enum":      "One of `values`.",
+	"decimal":   "Exact decimal number; supports precision, scale, min, max and encoding.",
+	"money":     "Decimal amount with currency or currency_field.",
+	"percent":   "Decimal percent.",
+	"reference": "Id of a record of the entity in `ref`.",
+}
+
+var (
+	keyPrefixRegex   = regexp.MustCompile(`^(\s*)(- )?([A-Za-z_]*)$`)
+	valuePrefixRegex = regexp.MustCompile(`^\s*(?:- )?([A-Za-z_]+):\s*(?:\[[^\]]*,\s*)?["']?([^"',\]]*)$`)
+	itemPrefixRegex  = regexp.MustCompile(`^(\s*)- ["']?([^"']*)$`)
+)
+
+// completion - keys, type names, enum values and other known values at position
+func (ws *workspace) completion(doc *document, pos Position) []CompletionItem {
+	line := lineAt(doc.lines, pos.Line)
+	prefix := string([]rune(line)[:min(runeColumn(line, pos.Character), len([]rune(line)))])
+	root := doc.good
+
+	if m := valuePrefixRegex.FindStringSubmatch(prefix); m != nil {
+		return ws.valueCompletion(m[1], fieldAtLine(root, pos.Line), doc)
+	}
+
+	if m := itemPrefixRegex.FindStringSubmatch(prefix); m != nil {
+		// list item of examples: enum values
+		if key := parentKey(doc.lines, pos.Line, len(m[1])); key == "examples" || key == "default" {
+			return ws.valueCompletion(key, fieldAtLine(root, pos.Line), doc)
+		}
+	}
+
+	if m := keyPrefixRegex.FindStringSubmatch(prefix); m != nil {
+		indent := len(m[1])
+		if indent == 0 && m[2] == "" {
+			return keyItems(entityDocs, existingKeys(root))
+		}
+		var existing map[string]bool
+		if m[2] == "" {
+			existing = existingKeys(fieldAtLine(root, pos.Line))
+		}
+		return keyItems(fieldDocs, existing)
+	}
+	return nil
+}
+
+// valueCompletion - values of key
+func (ws *workspace) valueCompletion(key string, field *yaml.Node, doc *document) []CompletionItem {
+	var items []CompletionItem
+	switch key {
+	case "type":
+		for _, name := range preset.FieldTypes() {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionValue, Detail: typeDetail(name), Documentation: typeDocs[name]})
+		}
+	case "default", "examples":
+		if _, values := mappingValue(field, "values"); values != nil {
+			for _, v := range values.Content {
+				items = append(items, CompletionItem{Label: v.Value, Kind: CompletionEnumMember, Detail: "enum value"})
+			}
+		}
+	case "required":
+		items = append(items, CompletionItem{Label: "true", Kind: CompletionValue}, CompletionItem{Label: "false", Kind: CompletionValue})
+	case "encoding":
+		items = append(items, CompletionItem{Label: preset.DecimalAsString, Kind: CompletionValue}, CompletionItem{Label: preset.DecimalAsNumber, Kind: CompletionValue})
+	case "format":
+		for _, name := range preset.Formats() {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionValue, Detail: "format"})
+		}
+	case "validator":
+		region := ""
+		if ws.pkg != nil {
+			region = ws.pkg.Region
+		}
+		for _, name := range preset.RegionValidators(region) {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionValue, Detail: "validator"})
+		}
+	case "ref":
+		for _, key := range ws.keys() {
+			if paths := ws.entitiesByKey(key); len(paths) > 0 && paths[0] != doc.path {
+				items = append(items, CompletionItem{Label: key, Kind: CompletionReference, Detail: ws.rel(paths[0])})
+			}
+		}
+	case "currency_field", "bik_field":
+		for _, f := range fieldNodes(doc.good) {
+			if _, code := mappingValue(f, "code"); code != nil && f != field {
+				items = append(items, CompletionItem{Label: code.Value, Kind: CompletionReference, Detail: "field"})
+			}
+		}
+	}
+	return items
+}
+
+// parentKey - key of the block a list item at indent belongs to
+func parentKey(lines []string, line, indent int) string {
+	for i := line - 1; i >= 0; i-- {
+		l := lines[i]
+		trimmed := strings.TrimLeft(l, " ")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(l) - len(trimmed); n < indent || (n == indent && !strings.HasPrefix(trimmed, "- ")) {
+			key, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "- "), ":")
+			return key
+		}
+	}
+	return ""
+}
+
+func existingKeys(m *yaml.Node) map[string]bool {
+	keys := make(map[string]bool)
+	if m != nil && m.Kind == yaml.MappingNode {
+		for i := 0; i < len(m.Content); i += 2 {
+			keys[m.Content[i].Value] = true
+		}
+	}
+	return keys
+}
+
+func keyItems(docs map[string]string, existing map[string]bool) []CompletionItem {
+	items := make([]CompletionItem, 0, len(docs))
+	for key, text := range docs {
+		if existing[key] {
+			continue
+		}
+		items = append(items, CompletionItem{Label: key, Kind: CompletionProperty, Documentation: text, InsertText: key + ": "})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func typeDetail(name string) string {
+	if kind := preset.FieldKind(name); string(kind) != name {
+		return fmt.Sprintf("%s (%s)", name, kind)
+	}
+	return name
+}
+
+// hover - docs of keys, types, validators and references under cursor
+func (ws *workspace) hover(doc *document, pos Position) *Hover {
+	h := ws.hitAt(doc, pos)
+	if h == nil {
+		return nil
+	}
+
+	var text string
+	switch {
+	case h.isKey && h.field != nil && h.mapping == h.field:
+		text = fieldDocs[h.key]
+	case h.isKey && h.mapping == doc.root:
+		text = entityDocs[h.key]
+	case !h.isKey && h.key == "type":
+		if _, ok := preset.LookupType(h.node.Value); !ok {
+			return nil
+		}
+		text = fmt.Sprintf("**%s**\n\n%s", typeDetail(h.node.Value), typeDocs[h.node.Value])
+	case !h.isKey && h.key == "validator":
+		region := ""
+		if ws.pkg != nil {
+			region = ws.pkg.Region
+		}
+		if _, ok := preset.LookupValidator(region, h.node.Value); ok {
+			text = fmt.Sprintf("**%s** validator of region `%s`", h.node.Value, region)
+		} else {
+			text = fmt.Sprintf("unknown validator for region `%s`", region)
+		}
+	case !h.isKey && h.key == "ref":
+		paths := ws.entitiesByKey(h.node.Value)
+		if len(paths) == 0 {
+			text = "entity not found in package"
+		} else {
+			text = fmt.Sprintf("**%s**\n\ndefined in `%s`", h.node.Value, ws.rel(paths[0]))
+		}
+	}
+	if text == "" {
+		return nil
+	}
+
+	r := nodeRange(doc.lines, h.node)
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: &r}
+}
+
+// definition - target entity of ref, field of *_field keys, other files of conflicting key
+func (ws *workspace) definition(doc *document, pos Position) []Location {
+	h := ws.hitAt(doc, pos)
+	if h == nil {
+		return nil
+	}
+
+	var locations []Location
+	switch {
+	case !h.isKey && h.key == "ref":
+		for _, path := range ws.entitiesByKey(h.node.Value) {
+			locations = append(locations, ws.location(path))
+		}
+	case !h.isKey && (h.key == "currency_field" || h.key == "bik_field"):
+		if field := fieldByCode(doc.root, h.node.Value); field != nil {
+			_, code := mappingValue(field, "code")
+			locations = append(locations, Location{URI: pathToURI(doc.path), Range: nodeRange(doc.lines, code)})
+		}
+	case h.mapping == doc.root && (h.key == "module" || h.key == "object" || h.key == "property" || h.key == "code"):
+		info := ws.index[doc.path]
+		if info == nil {
+			return nil
+		}
+		for _, path := range ws.entitiesByKey(info.key) {
+			if path != doc.path {
+				locations = append(locations, ws.location(path))
+			}
+		}
+	}
+	return locations
+}
+
+func (ws *workspace) hitAt(doc *document, pos Position) *hit {
+	if doc.root == nil {
+		return nil
+	}
+	line := lineAt(doc.lines, pos.Line)
+	return nodeAt(doc.root, pos.Line, runeColumn(line, pos.Character))
+}
+
+var (
+	invalidTypeRegex = regexp.MustCompile(`^field (\S+): invalid type '([^']*)'$`)
+	refNotFoundRegex = regexp.MustCompile(`^field (\S+): ref '([^']*)' not found in package$`)
+	enumValuesRegex  = regexp.MustCompile(`^field (\S+): enum requires values array$`)
+	missingKeyRegex  = regexp.MustCompile(`^missing: (\w+)$`)
+)
+
+// codeActions - quick fixes of diagnostics
+func (ws *workspace) codeActions(doc *document, diags []Diagnostic) []CodeAction {
+	actions := []CodeAction{}
+	if doc.root == nil {
+		return actions
+	}
+	uri := pathToURI(doc.path)
+	fix := func(title string, d Diagnostic, preferred bool, edits ...TextEdit) {
+		actions = append(actions, CodeAction{
+			Title:       title,
+			Kind:        "quickfix",
+			Diagnostics: []Diagnostic{d},
+			IsPreferred: preferred,
+			Edit:        &WorkspaceEdit{Changes: map[string][]TextEdit{uri: edits}},
+		})
+	}
+
+	for _, d := range diags {
+		switch {
+		case enumValuesRegex.MatchString(d.Message):
+			code := enumValuesRegex.FindStringSubmatch(d.Message)[1]
+			if edit, ok := addFieldKey(doc, fieldByCode(doc.root, code), "values", []string{"value"}); ok {
+				fix("Add enum values", d, true, edit)
+			}
+
+		case invalidTypeRegex.MatchString(d.Message):
+			m := invalidTypeRegex.FindStringSubmatch(d.Message)
+			_, typeNode := mappingValue(fieldByCode(doc.root, m[1]), "type")
+			if typeNode == nil {
+				continue
+			}
+			for i, name := range closest(m[2], preset.FieldTypes(), 3) {
+				fix(fmt.Sprintf("Change type to '%s'", name), d, i == 0,
+					TextEdit{Range: nodeRange(doc.lines, typeNode), NewText: name})
+			}
+
+		case refNotFoundRegex.MatchString(d.Message):
+			m := refNotFoundRegex.FindStringSubmatch(d.Message)
+			_, refNode := mappingValue(fieldByCode(doc.root, m[1]), "ref")
+			if refNode == nil {
+				continue
+			}
+			for i, key := range closest(m[2], ws.keys(), 3) {
+				fix(fmt.Sprintf("Change ref to '%s'", key), d, i == 0,
+					TextEdit{Range: nodeRange(doc.lines, refNode), NewText: key})
+			}
+
+		case missingKeyRegex.MatchString(d.Message):
+			key := missingKeyRegex.FindStringSubmatch(d.Message)[1]
+			fix(fmt.Sprintf("Add '%s'", key), d, true, addEntityKey(doc, key))
+		}
+	}
+	return actions
+}
+
+// addFieldKey - insert key with list of values into field, block or flow style
+func addFieldKey(doc *document, field *yaml.Node, key string, values []string) (TextEdit, bool) {
+	if field == nil || len(field.Content) == 0 {
+		return TextEdit{}, false
+	}
+
+	if field.Style&yaml.FlowStyle != 0 {
+		// {code: x, type: enum} -> {code: x, type: enum, values: [value]}
+		line := field.Line - 1
+		runes := []rune(lineAt(doc.lines, line))
+		for i := len(runes) - 1; i >= 0; i-- {
+			if runes[i] == '}' {
+				at := Position{Line: line, Character: utf16Offset(string(runes), i)}
+				return TextEdit{
+					Range:   Range{Start: at, End: at},
+					NewText: fmt.Sprintf(", %s: [%s]", key, strings.Join(values, ", ")),
+				}, true
+			}
+		}
+		return TextEdit{}, false
+	}
+
+	// after the last line of the field, with indent of its keys
+	last := lastLine(field)
+	indent := strings.Repeat(" ", field.Content[0].Column-1)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s:\n", indent, key)
+	for _, v := range values {
+		fmt.Fprintf(&b, "%s  - %s\n", indent, v)
+	}
+	at := Position{Line: last + 1}
+	return TextEdit{Range: Range{Start: at, End: at}, NewText: b.String()}, true
+}
+
+// addEntityKey - insert missing top-level key before fields, or at the end
+func addEntityKey(doc *document, key string) TextEdit {
+	text := key + ": \n"
+	if key == "fields" {
+		text = "fields:\n  - code: \n    name: \n    type: string\n"
+	}
+
+	at := Position{Line: len(doc.lines)}
+	if k, _ := mappingValue(doc.root, "fields"); k != nil && key != "fields" {
+		at = Position{Line: k.Line - 1}
+	} else if n := len(doc.lines); n > 0 && doc.lines[n-1] != "" {
+		text = "\n" + text
+		at = Position{Line: n - 1, Character: utf16Offset(doc.lines[n-1], len([]rune(doc.lines[n-1])))}
+	}
+	return TextEdit{Range: Range{Start: at, End: at}, NewText: text}
+}
+
+// lastLine - zero-based last line of node content
+func lastLine(n *yaml.Node) int {
+	last := n.Line - 1
+	for _, c := range n.Content {
+		if l := lastLine(c); l > last {
+			last = l
+		}
+	}
+	if n.Kind == yaml.ScalarNode && n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		last += strings.Count(strings.TrimRight(n.Value, "\n"), "\n") + 1
+	}
+	return last
+}
+
+// closest - up to n candidates by edit distance, only reasonably close ones
+func closest(s string, candidates []string, n int) []string {
+	type scored struct {
+		value    string
+		distance int
+	}
+	var list []scored
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(s), strings.ToLower(c))
+		if d <= max(2, len([]rune(s))/3) {
+			list = append(list, scored{c, d})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].distance < list[j].distance })
+
+	var result []string
+	for i := 0; i < len(list) && i < n; i++ {
+		result = append(result, list[i].value)
+	}
+	return result
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,257 @@
+// Package lsp - Language Server Protocol server for preset YAML over stdio
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+)
+
+// Server - single-threaded LSP server: messages are handled in order of arrival
+type Server struct {
+	conn *conn
+	dir  string // preset directory, empty - root of the client workspace
+	ws   *workspace
+	log  *log.Logger
+
+	shutdown bool
+}
+
+// NewServer - server reading r and writing w; logs go to logw (never stdout, it is the protocol stream)
+func NewServer(r io.Reader, w io.Writer, logw io.Writer, dir string) *Server {
+	return &Server{
+		conn: newConn(r, w),
+		dir:  dir,
+		log:  log.New(logw, "lsp: ", log.LstdFlags),
+	}
+}
+
+// Run - serve until exit notification or end of input
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, rpcErr := s.handle(msg)
+		if msg.ID == nil {
+			if rpcErr != nil {
+				s.log.Printf("%s: %s", msg.Method, rpcErr.Message)
+			}
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, rpcErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (any, *rpcError) {
+	if s.ws == nil && msg.Method != "initialize" {
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is not initialized"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.initialize(params), nil
+
+	case "initialized":
+		s.publishPackage()
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.changed(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+
+	case "textDocument/didChange":
+		var params DidChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			s.changed(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+
+	case "textDocument/didSave", "workspace/didChangeWatchedFiles":
+		// files on disk changed: package.yml, new or removed entities
+		s.ws.reload()
+		s.publishPackage()
+		s.publishAll()
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params DidCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		path := uriToPath(params.TextDocument.URI)
+		if _, ok := s.ws.docs[path]; ok {
+			s.ws.close(path)
+			s.publish(params.TextDocument.URI, []Diagnostic{})
+			s.publishAll()
+		}
+		return nil, nil
+
+	case "textDocument/completion":
+		return withDocument(s, msg, func(doc *document, p TextDocumentPositionParams) any {
+			return s.ws.completion(doc, p.Position)
+		})
+
+	case "textDocument/hover":
+		return withDocument(s, msg, func(doc *document, p TextDocumentPositionParams) any {
+			if h := s.ws.hover(doc, p.Position); h != nil {
+				return h
+			}
+			return nil
+		})
+
+	case "textDocument/definition":
+		return withDocument(s, msg, func(doc *document, p TextDocumentPositionParams) any {
+			return s.ws.definition(doc, p.Position)
+		})
+
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		doc := s.ws.docs[uriToPath(params.TextDocument.URI)]
+		if doc == nil {
+			return []CodeAction{}, nil
+		}
+		return s.ws.codeActions(doc, params.Context.Diagnostics), nil
+	}
+
+	if msg.ID == nil {
+		// unknown notifications are ignored
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+}
+
+func (s *Server) initialize(params InitializeParams) any {
+	dir := s.dir
+	if dir == "" {
+		dir = uriToPath(params.RootURI)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	s.ws = newWorkspace(dir)
+	s.log.Printf("preset %s", dir)
+
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    1, // full
+				"save":      true,
+			},
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{":", " ", "-"},
+			},
+			"hoverProvider":      true,
+			"definitionProvider": true,
+			"codeActionProvider": map[string]any{
+				"codeActionKinds": []string{"quickfix"},
+			},
+		},
+		"serverInfo": map[string]any{"name": "yieldaa-lsp"},
+	}
+}
+
+// changed - new text of document: diagnostics of it and of open documents affected by the index
+func (s *Server) changed(uri, text string) {
+	path := uriToPath(uri)
+	if s.ws.isPackage(path) {
+		s.publishPackage()
+		return
+	}
+	if !s.ws.isEntity(path) {
+		return
+	}
+
+	prevKey := ""
+	if info := s.ws.index[path]; info != nil {
+		prevKey = info.key
+	}
+	doc := s.ws.update(path, text)
+	s.publish(uri, s.ws.diagnostics(doc))
+
+	// key of the entity changed: conflicts and references of other files change too
+	if info := s.ws.index[path]; info == nil || info.key != prevKey {
+		for otherPath, other := range s.ws.docs {
+			if otherPath != path {
+				s.publish(pathToURI(otherPath), s.ws.diagnostics(other))
+			}
+		}
+	}
+}
+
+func (s *Server) publishAll() {
+	for path, doc := range s.ws.docs {
+		s.publish(pathToURI(path), s.ws.diagnostics(doc))
+	}
+}
+
+// publishPackage - load error of package.yml
+func (s *Server) publishPackage() {
+	diags := []Diagnostic{}
+	if s.ws.pkgErr != nil {
+		diags = append(diags, Diagnostic{Severity: SeverityError, Source: "yieldaa", Message: s.ws.pkgErr.Error()})
+	}
+	s.publish(pathToURI(filepath.Join(s.ws.dir, "package.yml")), diags)
+}
+
+func (s *Server) publish(uri string, diags []Diagnostic) {
+	if err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: diags}); err != nil {
+		s.log.Printf("publish diagnostics: %v", err)
+	}
+}
+
+// withDocument - decode position params and run f for open document; closed documents give null
+func withDocument(s *Server, msg *message, f func(doc *document, p TextDocumentPositionParams) any) (any, *rpcError) {
+	var params TextDocumentPositionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, invalidParams(err)
+	}
+	doc := s.ws.docs[uriToPath(params.TextDocument.URI)]
+	if doc == nil {
+		return nil, nil
+	}
+	return f(doc, params), nil
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+}
@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testTarget = `module: crm
+object: client
+property: requisite
+code: company
+name: Company
+fields:
+  - code: inn
+    name: INN
+    type: string
+`
+	testSource = `module: crm
+object: client
+property: contract
+code: main
+name: Contract
+fields:
+  - code: company
+    name: Company
+    type: reference
+    ref: crm.client.requisite.company
+  - code: kind
+    name: Kind
+    type: enum
+  - code: title
+    name: Title
+    type: strng
+`
+)
+
+// testClient - LSP client over in-memory pipes; messages of the server are read
+// in background, so the server never blocks on writing a notification
+type testClient struct {
+	t        *testing.T
+	conn     *conn
+	messages chan *message
+	nextID   int
+	// diagnostics published by the server, by URI
+	diagnostics map[string][]Diagnostic
+}
+
+func startServer(t *testing.T, dir string) *testClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	srv := NewServer(inR, outW, io.Discard, dir)
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run()
+		outW.Close()
+	}()
+
+	c := &testClient{
+		t:           t,
+		conn:        newConn(outR, inW),
+		messages:    make(chan *message, 100),
+		diagnostics: make(map[string][]Diagnostic),
+	}
+	go func() {
+		defer close(c.messages)
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		c.request("shutdown", nil, nil)
+		c.notify("exit", nil)
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+		inW.Close()
+	})
+	return c
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// request - send request and wait for its response; notifications received meanwhile
+// are recorded
+func (c *testClient) request(method string, params, result any) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strings.TrimSpace(string(mustJSON(c.t, c.nextID))))
+	msg := &message{ID: &id, Method: method}
+	if params != nil {
+		msg.Params = mustJSON(c.t, params)
+	}
+	if err := c.conn.write(msg); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		msg := c.receive()
+		if msg.ID == nil || string(*msg.ID) != string(id) {
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatalf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil {
+			if err := json.Unmarshal(mustJSON(c.t, msg.Result), result); err != nil {
+				c.t.Fatalf("%s result: %v", method, err)
+			}
+		}
+		return
+	}
+}
+
+func (c *testClient) receive() *message {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed the connection")
+		}
+		if msg.Method == "textDocument/publishDiagnostics" {
+			var p PublishDiagnosticsParams
+			json.Unmarshal(msg.Params, &p)
+			c.diagnostics[p.URI] = p.Diagnostics
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("no message from server")
+	}
+	return nil
+}
+
+// open - didOpen of file text; a request after it waits until its diagnostics are published
+func (c *testClient) open(path string) string {
+	c.t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	uri := pathToURI(path)
+	c.notify("textDocument/didOpen", DidOpenParams{TextDocument: TextDocumentItem{URI: uri, Version: 1, Text: string(data)}})
+	c.request("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}}, nil)
+	return uri
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func testPreset(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.yml":           "name: test\nversion: 1.0.0\nregion: ru\n",
+		"entities/company.yml":  testTarget,
+		"entities/contract.yml": testSource,
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestServerSession(t *testing.T) {
+	dir := testPreset(t)
+	c := startServer(t, dir)
+
+	var init struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	c.request("initialize", InitializeParams{RootURI: pathToURI(dir)}, &init)
+	if init.Capabilities["definitionProvider"] != true || init.Capabilities["completionProvider"] == nil {
+		t.Fatalf("capabilities = %v", init.Capabilities)
+	}
+	c.notify("initialized", struct{}{})
+
+	uri := c.open(filepath.Join(dir, "entities", "contract.yml"))
+	diags := c.diagnostics[uri]
+	messages := make([]string, len(diags))
+	for i, d := range diags {
+		messages[i] = d.Message
+	}
+	// code actions match these processor messages
+	for _, want := range []string{"field kind: enum requires values array", "field title: invalid type 'strng'"} {
+		found := false
+		for _, m := range messages {
+			found = found || m == want
+		}
+		if !found {
+			t.Errorf("diagnostics %q lack %q", messages, want)
+		}
+	}
+
+	t.Run("completion of type", func(t *testing.T) {
+		var items []CompletionItem
+		// "    type: strng" of title, cursor after "type: "
+		c.request("textDocument/completion", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: 15, Character: 10},
+		}, &items)
+		labels := map[string]bool{}
+		for _, item := range items {
+			labels[item.Label] = true
+		}
+		for _, want := range []string{"string", "enum", "decimal", "reference"} {
+			if !labels[want] {
+				t.Errorf("completion of type lacks %s: %v", want, labels)
+			}
+		}
+	})
+
+	t.Run("definition of ref", func(t *testing.T) {
+		var locations []Location
+		// "    ref: crm.client.requisite.company"
+		c.request("textDocument/definition", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: 9, Character: 15},
+		}, &locations)
+		want := pathToURI(filepath.Join(dir, "entities", "company.yml"))
+		if len(locations) != 1 || locations[0].URI != want || locations[0].Range.Start.Line != 3 {
+			t.Errorf("definition = %+v, want %s line 3", locations, want)
+		}
+	})
+
+	t.Run("code actions", func(t *testing.T) {
+		var actions []CodeAction
+		params := CodeActionParams{TextDocument: TextDocumentIdentifier{URI: uri}}
+		params.Context.Diagnostics = diags
+		c.request("textDocument/codeAction", params, &actions)
+
+		edits := map[string]TextEdit{}
+		for _, a := range actions {
+			if a.Edit != nil && len(a.Edit.Changes[uri]) == 1 {
+				edits[a.Title] = a.Edit.Changes[uri][0]
+			}
+		}
+		values, ok := edits["Add enum values"]
+		if !ok || values.NewText != "    values:\n      - value\n" || values.Range.Start.Line != 13 {
+			t.Errorf("enum values action = %+v (%v)", values, ok)
+		}
+		typeFix, ok := edits["Change type to 'string'"]
+		if !ok || typeFix.NewText != "string" || typeFix.Range.Start != (Position{Line: 15, Character: 10}) {
+			t.Errorf("type action = %+v (%v)", typeFix, ok)
+		}
+	})
+
+	t.Run("fixed document", func(t *testing.T) {
+		text := strings.Replace(strings.Replace(testSource, "strng", "string", 1),
+			"    type: enum\n", "    type: enum\n    values: [a, b]\n", 1)
+		c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []map[string]any{{"text": text}},
+		})
+		c.request("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}}, nil)
+		if d := c.diagnostics[uri]; len(d) != 0 {
+			t.Errorf("diagnostics of fixed document = %+v", d)
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"yieldaa/runtime/internal/preset"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entityInfo - index entry of entity file
+type entityInfo struct {
+	key  string
+	line int               // zero-based line of entity code
+	refs map[string]string // field code -> ref
+}
+
+// document - open entity file; text of editor overrides disk
+type document struct {
+	path  string
+	text  string
+	lines []string
+	root  *yaml.Node // nil while text does not parse
+	good  *yaml.Node // last root that parsed, used for completion of broken text
+	entry preset.ProcessedEntity
+}
+
+// workspace - preset directory: package, open documents and index of all entities
+type workspace struct {
+	dir    string
+	pkg    *preset.Package
+	pkgErr error
+	docs   map[string]*document
+	index  map[string]*entityInfo // by path
+}
+
+func newWorkspace(dir string) *workspace {
+	ws := &workspace{
+		dir:   dir,
+		docs:  make(map[string]*document),
+		index: make(map[string]*entityInfo),
+	}
+	ws.reload()
+	return ws
+}
+
+// reload - package.yml and index of entity files from disk, open documents keep editor text
+func (ws *workspace) reload() {
+	ws.pkg, ws.pkgErr = preset.LoadPreset(ws.dir)
+
+	files, err := preset.ScanEntities(filepath.Join(ws.dir, "entities"))
+	if err != nil {
+		files = nil
+	}
+	ws.index = make(map[string]*entityInfo, len(files))
+	for _, f := range files {
+		if doc, ok := ws.docs[f.Path]; ok {
+			ws.index[f.Path] = indexEntity([]byte(doc.text))
+			continue
+		}
+		if data, err := os.ReadFile(f.Path); err == nil {
+			ws.index[f.Path] = indexEntity(data)
+		}
+	}
+	for path, doc := range ws.docs {
+		ws.index[path] = indexEntity([]byte(doc.text))
+		doc.entry = preset.ProcessEntity(doc.file(), []byte(doc.text), ws.pkg)
+	}
+}
+
+// isEntity - YAML file under entities directory
+func (ws *workspace) isEntity(path string) bool {
+	rel, err := filepath.Rel(filepath.Join(ws.dir, "entities"), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+func (ws *workspace) isPackage(path string) bool {
+	return path == filepath.Join(ws.dir, "package.yml")
+}
+
+// update - new text of open document
+func (ws *workspace) update(path, text string) *document {
+	doc := ws.docs[path]
+	if doc == nil {
+		doc = &document{path: path}
+		ws.docs[path] = doc
+	}
+	doc.text = text
+	doc.lines = splitLines(text)
+	doc.root = parseYAML(text)
+	if doc.root != nil {
+		doc.good = doc.root
+	}
+	doc.entry = preset.ProcessEntity(doc.file(), []byte(text), ws.pkg)
+	ws.index[path] = indexEntity([]byte(text))
+	return doc
+}
+
+// close - document is closed, index falls back to disk
+func (ws *workspace) close(path string) {
+	delete(ws.docs, path)
+	if data, err := os.ReadFile(path); err == nil {
+		ws.index[path] = indexEntity(data)
+	} else {
+		delete(ws.index, path)
+	}
+}
+
+func (d *document) file() preset.EntityFile {
+	return preset.EntityFile{Path: d.path, Size: int64(len(d.text)), ModTime: time.Now()}
+}
+
+// indexEntity - key and references of entity text; zero info for broken YAML
+func indexEntity(data []byte) *entityInfo {
+	info := &entityInfo{refs: make(map[string]string)}
+
+	var doc yaml.Node
+	var parsed map[string]any
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 || doc.Content[0].Decode(&parsed) != nil || parsed == nil {
+		return info
+	}
+	info.key = preset.EntityKey(parsed)
+	if k, _ := mappingValue(doc.Content[0], "code"); k != nil {
+		info.line = k.Line - 1
+	}
+
+	fields, _ := parsed["fields"].([]any)
+	for _, f := range fields {
+		field, _ := f.(map[string]any)
+		code, _ := field["code"].(string)
+		ref, _ := field["ref"].(string)
+		if field["type"] == string(preset.TypeReference) && code != "" && ref != "" {
+			info.refs[code] = ref
+		}
+	}
+	return info
+}
+
+// location - start of entity code in file
+func (ws *workspace) location(path string) Location {
+	at := Position{}
+	if info := ws.index[path]; info != nil {
+		at.Line = info.line
+	}
+	return Location{URI: pathToURI(path), Range: Range{Start: at, End: at}}
+}
+
+// entitiesByKey - paths of indexed entities with key, sorted
+func (ws *workspace) entitiesByKey(key string) []string {
+	var paths []string
+	for path, info := range ws.index {
+		if info.key == key && key != "" {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// keys - sorted entity keys of the package
+func (ws *workspace) keys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, info := range ws.index {
+		if info.key != "" && !seen[info.key] {
+			seen[info.key] = true
+			keys = append(keys, info.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (ws *workspace) rel(path string) string {
+	if rel, err := filepath.Rel(ws.dir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+var yamlLineRegex = regexp.MustCompile(`line (\d+)`)
+
+// diagnostics - processor errors of document and cross-file checks against the index
+func (ws *workspace) diagnostics(doc *document) []Diagnostic {
+	diags := []Diagnostic{}
+	add := func(r Range, severity int, msg string) {
+		diags = append(diags, Diagnostic{Range: r, Severity: severity, Source: "yieldaa", Message: msg})
+	}
+
+	if ws.pkgErr != nil {
+		add(lineRange(doc.lines, 0), SeverityWarning, ws.pkgErr.Error())
+	}
+
+	if err := doc.entry.FatalError; err != nil {
+		line := 0
+		if m := yamlLineRegex.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			line--
+		}
+		add(lineRange(doc.lines, line), SeverityError, err.Error())
+		return diags
+	}
+
+	for _, msg := range doc.entry.Errors {
+		add(errorRange(doc, msg), SeverityError, msg)
+	}
+
+	// cross-file: key conflicts
+	info := ws.index[doc.path]
+	if info != nil && info.key != "" {
+		for _, other := range ws.entitiesByKey(info.key) {
+			if other != doc.path {
+				add(keyRange(doc), SeverityError,
+					fmt.Sprintf("entity key conflict: '%s' also defined in '%s'", info.key, ws.rel(other)))
+			}
+		}
+	}
+
+	// cross-file: references
+	if info != nil && doc.root != nil {
+		keys := make(map[string]bool)
+		for _, key := range ws.keys() {
+			keys[key] = true
+		}
+		for code, ref := range info.refs {
+			if keys[ref] {
+				continue
+			}
+			r := lineRange(doc.lines, 0)
+			if _, v := mappingValue(fieldByCode(doc.root, code), "ref"); v != nil {
+				r = nodeRange(doc.lines, v)
+			}
+			add(r, SeverityError, fmt.Sprintf("field %s: ref '%s' not found in package", code, ref))
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Range.Start.Line < diags[j].Range.Start.Line })
+	return diags
+}
+
+// keyRange - code of the entity, the last part of the key
+func keyRange(doc *document) Range {
+	if _, v := mappingValue(doc.root, "code"); v != nil {
+		return nodeRange(doc.lines, v)
+	}
+	return lineRange(doc.lines, 0)
+}
+
+var fieldIndexRegex = regexp.MustCompile(`^field\[(\d+)\]: `)
+
+// errorRange - node the processor error message is about
+func errorRange(doc *document, msg string) Range {
+	root := doc.root
+	first := lineRange(doc.lines, 0)
+	if root == nil {
+		return first
+	}
+
+	if m := fieldIndexRegex.FindStringSubmatch(msg); m != nil {
+		i, _ := strconv.Atoi(m[1])
+		_, fields := mappingValue(root, "fields")
+		if fields != nil && i < len(fields.Content) {
+			return nodeRange(doc.lines, fields.Content[i])
+		}
+		return first
+	}
+
+	if code, ok := strings.CutPrefix(msg, "duplicate field code: "); ok {
+		// the last field with the code is the duplicate
+		var last *yaml.Node
+		for _, field := range fieldNodes(root) {
+			if _, v := mappingValue(field, "code"); v != nil && v.Value == code {
+				last = v
+			}
+		}
+		if last != nil {
+			return nodeRange(doc.lines, last)
+		}
+		return first
+	}
+
+	if rest, ok := strings.CutPrefix(msg, "field "); ok {
+		code, detail, _ := strings.Cut(rest, ": ")
+		field := fieldByCode(root, code)
+		if field == nil {
+			return first
+		}
+		return nodeRange(doc.lines, fieldPropertyNode(field, detail))
+	}
+
+	// top-level: "missing: x", "fields ...", "name: ..."
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k := root.Content[i]
+		if strings.HasPrefix(msg, k.Value+":") || strings.HasPrefix(msg, k.Value+" ") {
+			return nodeRange(doc.lines, k)
+		}
+	}
+	return first
+}
+
+// fieldPropertyNode - value of field property mentioned in error detail, code of the field otherwise
+func fieldPropertyNode(field *yaml.Node, detail string) *yaml.Node {
+	codeKey, codeValue := mappingValue(field, "code")
+	_, typeValue := mappingValue(field, "type")
+
+	// translation errors: "name: ..."
+	for i := 0; i+1 < len(field.Content); i += 2 {
+		if strings.HasPrefix(detail, field.Content[i].Value+": ") {
+			return field.Content[i+1]
+		}
+	}
+	for i := 0; i+1 < len(field.Content); i += 2 {
+		k := field.Content[i].Value
+		if k == "code" || k == "name" || k == "type" {
+			continue
+		}
+		if regexp.MustCompile(`\b` + regexp.QuoteMeta(k) + `\b`).MatchString(detail) {
+			return field.Content[i+1]
+		}
+	}
+	if typeValue != nil && (strings.Contains(detail, "type") ||
+		regexp.MustCompile(`\b`+regexp.QuoteMeta(typeValue.Value)+`\b`).MatchString(detail)) {
+		return typeValue
+	}
+	if codeValue != nil {
+		return codeValue
+	}
+	return codeKey
+}
+
+// uriToPath - file URI to local path
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
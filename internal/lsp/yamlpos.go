@@ -0,0 +1,197 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML - root mapping of entity document, nil on syntax errors
+func parseYAML(text string) *yaml.Node {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return nil
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// mappingValue - key and value nodes of mapping entry
+func mappingValue(m *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// fieldNodes - mappings of fields sequence, nil items for non-mappings
+func fieldNodes(root *yaml.Node) []*yaml.Node {
+	_, fields := mappingValue(root, "fields")
+	if fields == nil || fields.Kind != yaml.SequenceNode {
+		return nil
+	}
+	nodes := make([]*yaml.Node, len(fields.Content))
+	for i, item := range fields.Content {
+		if item.Kind == yaml.MappingNode {
+			nodes[i] = item
+		}
+	}
+	return nodes
+}
+
+func fieldByCode(root *yaml.Node, code string) *yaml.Node {
+	for _, field := range fieldNodes(root) {
+		if _, v := mappingValue(field, "code"); v != nil && v.Value == code {
+			return field
+		}
+	}
+	return nil
+}
+
+// fieldAtLine - field mapping that contains zero-based line: the last field starting at or before it
+func fieldAtLine(root *yaml.Node, line int) *yaml.Node {
+	var found *yaml.Node
+	for _, field := range fieldNodes(root) {
+		if field != nil && field.Line-1 <= line {
+			found = field
+		}
+	}
+	return found
+}
+
+// nodeWidth - width of scalar in source in runes, quotes included
+func nodeWidth(n *yaml.Node) int {
+	width := utf8.RuneCountInString(n.Value)
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		width += 2
+	}
+	return width
+}
+
+// nodeRange - range of node in document; multi-line scalars end at line end
+func nodeRange(lines []string, n *yaml.Node) Range {
+	line, col := n.Line-1, n.Column-1
+	end := col
+	if n.Kind == yaml.ScalarNode && !strings.Contains(n.Value, "\n") {
+		end = col + nodeWidth(n)
+	} else if line < len(lines) {
+		end = utf8.RuneCountInString(lines[line])
+	}
+	return Range{
+		Start: Position{Line: line, Character: utf16Offset(lineAt(lines, line), col)},
+		End:   Position{Line: line, Character: utf16Offset(lineAt(lines, line), end)},
+	}
+}
+
+// lineRange - whole line
+func lineRange(lines []string, line int) Range {
+	return Range{
+		Start: Position{Line: line},
+		End:   Position{Line: line, Character: utf16Offset(lineAt(lines, line), utf8.RuneCountInString(lineAt(lines, line)))},
+	}
+}
+
+// hit - scalar under cursor
+type hit struct {
+	node    *yaml.Node
+	isKey   bool
+	key     string     // key of mapping entry the node belongs to
+	mapping *yaml.Node // mapping of the entry
+	field   *yaml.Node // field mapping when the node is inside fields
+}
+
+// nodeAt - scalar of root at zero-based line and rune column
+func nodeAt(root *yaml.Node, line, col int) *hit {
+	var walk func(n *yaml.Node, field *yaml.Node) *hit
+	contains := func(n *yaml.Node) bool {
+		return n.Kind == yaml.ScalarNode && n.Line-1 == line && col >= n.Column-1 && col <= n.Column-1+nodeWidth(n)
+	}
+	walk = func(n *yaml.Node, field *yaml.Node) *hit {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				if contains(k) {
+					return &hit{node: k, isKey: true, key: k.Value, mapping: n, field: field}
+				}
+				if contains(v) {
+					return &hit{node: v, key: k.Value, mapping: n, field: field}
+				}
+				if h := walk(v, field); h != nil {
+					if h.mapping == nil {
+						h.key, h.mapping = k.Value, n
+					}
+					return h
+				}
+			}
+		case yaml.SequenceNode:
+			for _, item := range n.Content {
+				itemField := field
+				if _, fields := mappingValue(root, "fields"); n == fields {
+					itemField = item
+				}
+				if contains(item) {
+					return &hit{node: item, field: itemField}
+				}
+				if h := walk(item, itemField); h != nil {
+					return h
+				}
+			}
+		}
+		return nil
+	}
+	return walk(root, nil)
+}
+
+func lineAt(lines []string, line int) string {
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// utf16Offset - rune column -> UTF-16 offset in line
+func utf16Offset(line string, runeCol int) int {
+	offset := 0
+	for i, r := range []rune(line) {
+		if i >= runeCol {
+			break
+		}
+		offset++
+		if r >= 0x10000 {
+			offset++
+		}
+	}
+	if n := utf8.RuneCountInString(line); runeCol > n {
+		offset += runeCol - n
+	}
+	return offset
+}
+
+// runeColumn - UTF-16 offset -> rune column in line
+func runeColumn(line string, utf16Col int) int {
+	col, offset := 0, 0
+	for _, r := range line {
+		if offset >= utf16Col {
+			break
+		}
+		offset++
+		if r >= 0x10000 {
+			offset++
+		}
+		col++
+	}
+	return col
+}
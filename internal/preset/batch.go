@@ -0,0 +1,370 @@
+package preset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// record file formats
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// BatchOptions - streaming validation of record files
+type BatchOptions struct {
+	Format  string // jsonl or csv, empty - by file extension
+	Region  string
+	Workers int
+	// CSV header -> field code; other headers are matched by code, then by field name in any locale
+	Columns map[string]string
+}
+
+// RowResult - violations of one record; Line is the line of the record in the input
+type RowResult struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+// BatchSummary - totals of batch validation
+type BatchSummary struct {
+	Rows     int            `json:"rows"`
+	Valid    int            `json:"valid"`
+	Invalid  int            `json:"invalid"`
+	ByField  map[string]int `json:"errors_by_field,omitempty"`
+	Unmapped []string       `json:"unmapped_columns,omitempty"`
+	Duration time.Duration  `json:"duration"`
+}
+
+// rows in flight per worker: reader waits when report falls behind, memory stays bounded
+const batchWindowPerWorker = 64
+
+// ValidateRecordsFile - ValidateStream over file, format by extension unless set
+func ValidateRecordsFile(entity map[string]any, path string, opts BatchOptions, report func(RowResult) error) (BatchSummary, error) {
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			opts.Format = FormatCSV
+		case ".jsonl", ".ndjson":
+			opts.Format = FormatJSONL
+		default:
+			return BatchSummary{}, fmt.Errorf("%s: unknown format, expected .jsonl or .csv", path)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return BatchSummary{}, err
+	}
+	defer f.Close()
+
+	return ValidateStream(entity, f, opts, report)
+}
+
+type batchRow struct {
+	line   int
+	record map[string]any
+	err    string // parse error of the row
+}
+
+// ValidateStream - validate records read from r with a pool of workers; report is called
+// for every invalid row in input order
+func ValidateStream(entity map[string]any, r io.Reader, opts BatchOptions, report func(RowResult) error) (BatchSummary, error) {
+	start := time.Now()
+	summary := BatchSummary{ByField: make(map[string]int)}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	var read func(emit func(batchRow) bool) error
+	switch opts.Format {
+	case FormatJSONL:
+		read = func(emit func(batchRow) bool) error { return readJSONL(r, emit) }
+	case FormatCSV:
+		res := newCSVResolver(entity, opts.Columns)
+		// written by reader before readErr is sent
+		read = func(emit func(batchRow) bool) error { return readCSV(r, res, &summary.Unmapped, emit) }
+	default:
+		return summary, fmt.Errorf("unknown records format '%s'", opts.Format)
+	}
+
+	window := make(chan struct{}, workers*batchWindowPerWorker)
+	jobs := make(chan indexedRow, workers)
+	results := make(chan indexedResult, workers)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				res := RowResult{Line: job.row.line}
+				if job.row.err != "" {
+					res.Errors = []string{job.row.err}
+				} else {
+					res.Errors = ValidateRecord(entity, job.row.record, opts.Region)
+				}
+				results <- indexedResult{index: job.index, result: res}
+			}
+		}()
+	}
+
+	// Feed jobs
+	readErr := make(chan error, 1)
+	go func() {
+		index := 0
+		err := read(func(row batchRow) bool {
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return false
+			}
+			jobs <- indexedRow{index: index, row: row}
+			index++
+			return true
+		})
+		close(jobs)
+		readErr <- err
+	}()
+
+	// Wait completion
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect results in input order
+	pending := make(map[int]RowResult)
+	next := 0
+	var reportErr error
+	for res := range results {
+		pending[res.index] = res.result
+		for {
+			row, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			summary.Rows++
+			if len(row.Errors) == 0 {
+				summary.Valid++
+				continue
+			}
+			summary.Invalid++
+			for _, e := range row.Errors {
+				summary.ByField[errorField(e)]++
+			}
+			if reportErr == nil && report != nil {
+				if reportErr = report(row); reportErr != nil {
+					close(stop)
+				}
+			}
+		}
+	}
+
+	summary.Duration = time.Since(start)
+	if err := <-readErr; err != nil {
+		return summary, err
+	}
+	return summary, reportErr
+}
+
+type indexedRow struct {
+	index int
+	row   batchRow
+}
+
+type indexedResult struct {
+	index  int
+	result RowResult
+}
+
+// errorField - field code of "field <code>: ..." message, "" for record-level errors
+func errorField(message string) string {
+	rest, ok := strings.CutPrefix(message, "field ")
+	if !ok {
+		return ""
+	}
+	code, _, found := strings.Cut(rest, ":")
+	if !found {
+		return ""
+	}
+	return code
+}
+
+func readJSONL(r io.Reader, emit func(batchRow) bool) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			row := batchRow{line: line}
+
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			if decErr := decoder.Decode(&row.record); decErr != nil {
+				row.err = fmt.Sprintf("invalid JSON: %v", decErr)
+				row.record = nil
+			} else if row.record == nil {
+				row.err = "record must be JSON object"
+			}
+
+			if !emit(row) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+type csvColumn struct {
+	code  string
+	field map[string]any // nil - column is not mapped to a field
+}
+
+// csvResolver - field of header cell: explicit mapping, code, then field name in any locale
+type csvResolver struct {
+	mapping map[string]string
+	byCode  map[string]map[string]any
+	byName  map[string]string // lowercase code or name -> code
+}
+
+func newCSVResolver(entity map[string]any, mapping map[string]string) *csvResolver {
+	res := &csvResolver{
+		mapping: mapping,
+		byCode:  make(map[string]map[string]any),
+		byName:  make(map[string]string),
+	}
+	fields := entityFields(entity)
+	for _, field := range fields {
+		code, _ := getFieldCodeAndType(field)
+		res.byCode[code] = field
+		res.byName[strings.ToLower(code)] = code
+	}
+	for _, field := range fields {
+		code, _ := getFieldCodeAndType(field)
+		names := localizedMap(field["name"])
+		if names == nil {
+			names = map[string]string{"": LocalizedText(field["name"], "")}
+		}
+		for _, name := range names {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if _, taken := res.byName[key]; key != "" && !taken {
+				res.byName[key] = code
+			}
+		}
+	}
+	return res
+}
+
+// columns - fields of header cells and sorted list of unmapped cells
+func (res *csvResolver) columns(header []string) ([]csvColumn, []string) {
+	columns := make([]csvColumn, len(header))
+	var unmapped []string
+	for i, cell := range header {
+		cell = strings.TrimSpace(strings.TrimPrefix(cell, "\uFEFF"))
+		code, ok := res.mapping[cell]
+		if !ok {
+			code = res.byName[strings.ToLower(cell)]
+		}
+		if field := res.byCode[code]; field != nil {
+			columns[i] = csvColumn{code: code, field: field}
+		} else {
+			unmapped = append(unmapped, cell)
+		}
+	}
+	sort.Strings(unmapped)
+	return columns, unmapped
+}
+
+// csvValue - cell as JSON value of field kind; cells that do not convert stay strings
+// and are reported by the type check
+func csvValue(field map[string]any, cell string) any {
+	_, typeStr := getFieldCodeAndType(field)
+	switch FieldKind(typeStr) {
+	case TypeNumber, TypeInteger:
+		return json.Number(cell)
+	case TypeBoolean:
+		switch strings.ToLower(cell) {
+		case "true", "1":
+			return true
+		case "false", "0":
+			return false
+		}
+	case TypeDecimal:
+		if encoding, _ := field["encoding"].(string); encoding == DecimalAsNumber {
+			return json.Number(cell)
+		}
+	}
+	return cell
+}
+
+// readCSV - first row is header, empty cells are absent values
+func readCSV(r io.Reader, res *csvResolver, unmapped *[]string, emit func(batchRow) bool) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+	columns, notMapped := res.columns(header)
+	*unmapped = notMapped
+
+	for {
+		cells, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var row batchRow
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			row.line, row.err = parseErr.StartLine, parseErr.Err.Error()
+		case err != nil:
+			return err
+		case len(cells) != len(columns):
+			row.line, _ = reader.FieldPos(0)
+			row.err = fmt.Sprintf("expected %d columns, got %d", len(columns), len(cells))
+		default:
+			row.line, _ = reader.FieldPos(0)
+			row.record = make(map[string]any, len(cells))
+			for i, cell := range cells {
+				if columns[i].field == nil || cell == "" {
+					continue
+				}
+				row.record[columns[i].code] = csvValue(columns[i].field, cell)
+			}
+		}
+
+		if !emit(row) {
+			return nil
+		}
+	}
+}
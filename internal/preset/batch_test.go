@@ -0,0 +1,189 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func batchTestEntity() map[string]any {
+	return map[string]any{"fields": []any{
+		map[string]any{"code": "inn", "name": map[string]any{"ru": "ИНН", "en": "Tax ID"}, "type": "string", "required": true, "min": 10, "max": 12},
+		map[string]any{"code": "title", "name": map[string]any{"ru": "Наименование", "en": "Title"}, "type": "string"},
+		map[string]any{"code": "staff", "name": "Staff", "type": "integer", "min": 1},
+		map[string]any{"code": "active", "name": "Active", "type": "boolean"},
+	}}
+}
+
+// lineReader - one line per Read, counts lines handed out
+type lineReader struct {
+	lines []string
+	read  atomic.Int64
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	n := int(r.read.Load())
+	if n >= len(r.lines) {
+		return 0, io.EOF
+	}
+	r.read.Add(1)
+	return copy(p, r.lines[n]), nil
+}
+
+// slow row holds a worker, so later rows wait for it in the window
+func init() {
+	RegisterType(TypeDefinition{
+		Name:   "test_slow",
+		Kind:   TypeString,
+		Schema: func(map[string]any) map[string]any { return map[string]any{"type": "string"} },
+		Decode: decodeString,
+		Check: func(_ map[string]any, value any) []string {
+			if value == "slow" {
+				time.Sleep(100 * time.Millisecond)
+			}
+			if value != "ok" && value != "slow" {
+				return []string{"not ok"}
+			}
+			return nil
+		},
+	})
+}
+
+func TestValidateStreamOrder(t *testing.T) {
+	const rows, workers = 2000, 2
+	entity := map[string]any{"fields": []any{map[string]any{"code": "v", "type": "test_slow"}}}
+	lines := make([]string, rows)
+	for i := range lines {
+		// first row is slow, every third row is invalid
+		value := "ok"
+		switch {
+		case i == 0:
+			value = "slow"
+		case i%3 == 0:
+			value = "bad"
+		}
+		lines[i] = fmt.Sprintf(`{"v": %q}`+"\n", value)
+	}
+	r := &lineReader{lines: lines}
+
+	var reported []int
+	readAhead := int64(-1)
+	summary, err := ValidateStream(entity, r, BatchOptions{Format: FormatJSONL, Workers: workers},
+		func(row RowResult) error {
+			if readAhead < 0 {
+				readAhead = r.read.Load()
+			}
+			reported = append(reported, row.Line)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Rows != rows || summary.Invalid != rows/3 || summary.Valid != rows-rows/3 {
+		t.Errorf("summary rows %d valid %d invalid %d", summary.Rows, summary.Valid, summary.Invalid)
+	}
+	if len(reported) != rows/3 {
+		t.Fatalf("reported %d rows, want %d", len(reported), rows/3)
+	}
+	for i, line := range reported {
+		if want := (i+1)*3 + 1; line != want {
+			t.Fatalf("report %d is line %d, want %d", i, line, want)
+		}
+	}
+	// rows read while the first one is validated: the window and a few rows in hand
+	if limit := int64(workers*batchWindowPerWorker + 4); readAhead > limit {
+		t.Errorf("read %d rows ahead of the slow one, window allows %d", readAhead, limit)
+	}
+}
+
+func TestValidateStreamReportError(t *testing.T) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = `{"inn": "1"}` + "\n"
+	}
+	stopErr := errors.New("report closed")
+	calls := 0
+	_, err := ValidateStream(batchTestEntity(), strings.NewReader(strings.Join(lines, "")),
+		BatchOptions{Format: FormatJSONL, Workers: 4}, func(RowResult) error {
+			calls++
+			if calls == 10 {
+				return stopErr
+			}
+			return nil
+		})
+	if !errors.Is(err, stopErr) || calls != 10 {
+		t.Errorf("error = %v after %d reports, want %v after 10", err, calls, stopErr)
+	}
+}
+
+func TestValidateStreamCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  map[string]string
+		input    string
+		rows     []RowResult
+		unmapped []string
+	}{
+		{
+			name:  "codes",
+			input: "inn,title,staff,active\n7707083893,Acme,10,1\n,Acme,0,yes\n",
+			rows: []RowResult{{Line: 3, Errors: []string{
+				"field inn: required",
+				"field staff: value 0 is less than min 1",
+				"field active: expected boolean, got string",
+			}}},
+		},
+		{
+			name:  "names in any locale, BOM and spaces",
+			input: "\uFEFFИНН, Title ,STAFF\n7707083893,Acme,5\n12,Acme,5\n",
+			rows:  []RowResult{{Line: 3, Errors: []string{"field inn: length 2 is less than min 10"}}},
+		},
+		{
+			name:    "explicit mapping wins",
+			columns: map[string]string{"Client": "inn", "Title": "title"},
+			input:   "Client,Title\n7707083893,Acme\n",
+		},
+		{
+			name:     "unknown columns",
+			input:    "inn,Comment,extra\n7707083893,note,1\n",
+			unmapped: []string{"Comment", "extra"},
+		},
+		{
+			name:     "mapping to unknown field",
+			columns:  map[string]string{"Client": "client_inn"},
+			input:    "Client,inn\nx,7707083893\n",
+			unmapped: []string{"Client"},
+		},
+		{
+			name:  "column count",
+			input: "inn,title\n7707083893\n",
+			rows:  []RowResult{{Line: 2, Errors: []string{"expected 2 columns, got 1"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []RowResult
+			summary, err := ValidateStream(batchTestEntity(), strings.NewReader(tt.input),
+				BatchOptions{Format: FormatCSV, Columns: tt.columns, Workers: 2},
+				func(row RowResult) error {
+					rows = append(rows, row)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %v, want %v", rows, tt.rows)
+			}
+			if !reflect.DeepEqual(summary.Unmapped, tt.unmapped) {
+				t.Errorf("unmapped = %v, want %v", summary.Unmapped, tt.unmapped)
+			}
+		})
+	}
+}
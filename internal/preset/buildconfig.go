@@ -0,0 +1,210 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// ConfigFileName - per-package settings of the tool, next to package.yml
+const ConfigFileName = "yieldaa.yml"
+
+// duplicate policies of files with same content
+const (
+	DuplicatesSkip  = "skip"  // processed once, other copies are ignored
+	DuplicatesError = "error" // every copy after the first is a validation error
+)
+
+// BuildConfig - build policy of the package: yieldaa.yml or build section of package.yml.
+// Zero values mean defaults, flags of the CLI override the config
+type BuildConfig struct {
+	Workers    int          `yaml:"workers,omitempty" json:"workers,omitempty"`
+	Strict     bool         `yaml:"strict,omitempty" json:"strict,omitempty"` // lint warnings fail the build
+	Duplicates string       `yaml:"duplicates,omitempty" json:"duplicates,omitempty"`
+	Limits     BuildLimits  `yaml:"limits,omitempty" json:"limits,omitempty"`
+	Outputs    BuildOutputs `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	Lint       LintConfig   `yaml:"lint,omitempty" json:"lint,omitempty"`
+	// private key file of bundle signatures, empty - bundles are not signed
+	SigningKey string `yaml:"signing_key,omitempty" json:"signing_key,omitempty"`
+	// registry directory or http(s) URL of publish and install
+	Registry string `yaml:"registry,omitempty" json:"registry,omitempty"`
+}
+
+// BuildLimits - limits of package.yml and entity files
+type BuildLimits struct {
+	RequiredKeys []string `yaml:"required_keys,omitempty" json:"required_keys,omitempty"` // top-level keys of entity
+	NameMin      int      `yaml:"name_min,omitempty" json:"name_min,omitempty"`           // package name length
+	NameMax      int      `yaml:"name_max,omitempty" json:"name_max,omitempty"`
+	RegionMin    int      `yaml:"region_min,omitempty" json:"region_min,omitempty"`
+	RegionMax    int      `yaml:"region_max,omitempty" json:"region_max,omitempty"`
+	MaxFields    int      `yaml:"max_fields,omitempty" json:"max_fields,omitempty"` // fields per entity, 0 - no limit
+}
+
+// BuildOutputs - output formats and paths of CLI commands, empty - command default
+type BuildOutputs struct {
+	Entities string            `yaml:"entities,omitempty" json:"entities,omitempty"` // entities.json of build and watch
+	Bundle   string            `yaml:"bundle,omitempty" json:"bundle,omitempty"`     // bundle of build, empty - none
+	I18n     string            `yaml:"i18n,omitempty" json:"i18n,omitempty"`         // annotations or split
+	OpenAPI  string            `yaml:"openapi,omitempty" json:"openapi,omitempty"`
+	Proto    string            `yaml:"proto,omitempty" json:"proto,omitempty"`
+	Releases string            `yaml:"releases,omitempty" json:"releases,omitempty"` // release bundles, default <dir>/releases
+	Gen      map[string]string `yaml:"gen,omitempty" json:"gen,omitempty"`           // gen target -> output directory
+}
+
+// DefaultBuildLimits - limits used when the config sets none
+func DefaultBuildLimits() BuildLimits {
+	return BuildLimits{
+		RequiredKeys: []string{"module", "object", "property", "code", "name", "fields"},
+		NameMin:      4,
+		NameMax:      32,
+		RegionMin:    2,
+		RegionMax:    3,
+	}
+}
+
+// withDefaults - zero values replaced by defaults
+func (c BuildConfig) withDefaults() BuildConfig {
+	def := DefaultBuildLimits()
+	if c.Duplicates == "" {
+		c.Duplicates = DuplicatesSkip
+	}
+	if len(c.Limits.RequiredKeys) == 0 {
+		c.Limits.RequiredKeys = def.RequiredKeys
+	}
+	if c.Limits.NameMin == 0 {
+		c.Limits.NameMin = def.NameMin
+	}
+	if c.Limits.NameMax == 0 {
+		c.Limits.NameMax = def.NameMax
+	}
+	if c.Limits.RegionMin == 0 {
+		c.Limits.RegionMin = def.RegionMin
+	}
+	if c.Limits.RegionMax == 0 {
+		c.Limits.RegionMax = def.RegionMax
+	}
+	return c
+}
+
+// validate - settings of the config are consistent
+func (c BuildConfig) validate() error {
+	if c.Workers < 0 {
+		return fmt.Errorf("'workers' must not be negative, got %d", c.Workers)
+	}
+	if c.Duplicates != DuplicatesSkip && c.Duplicates != DuplicatesError {
+		return fmt.Errorf("'duplicates' must be %s or %s, got %s", DuplicatesSkip, DuplicatesError, c.Duplicates)
+	}
+
+	l := c.Limits
+	for _, key := range l.RequiredKeys {
+		if key == "" {
+			return fmt.Errorf("'limits.required_keys' must not contain empty keys")
+		}
+	}
+	if l.NameMin < 1 || l.NameMin > l.NameMax {
+		return fmt.Errorf("'limits.name_min' and 'limits.name_max' must be 1 <= min <= max, got %d-%d", l.NameMin, l.NameMax)
+	}
+	if l.RegionMin < 1 || l.RegionMin > l.RegionMax {
+		return fmt.Errorf("'limits.region_min' and 'limits.region_max' must be 1 <= min <= max, got %d-%d", l.RegionMin, l.RegionMax)
+	}
+	if l.MaxFields < 0 {
+		return fmt.Errorf("'limits.max_fields' must not be negative, got %d", l.MaxFields)
+	}
+
+	if i18n := c.Outputs.I18n; i18n != "" && i18n != I18nAnnotations && i18n != I18nSplit {
+		return fmt.Errorf("'outputs.i18n' must be %s or %s, got %s", I18nAnnotations, I18nSplit, i18n)
+	}
+
+	_, err := c.Lint.severities()
+	return err
+}
+
+// LoadBuildConfig - build section of package.yml overridden by yieldaa.yml of dir, with defaults;
+// missing files are not errors
+func LoadBuildConfig(dir string) (BuildConfig, error) {
+	return loadBuildConfig(dirFS(dir))
+}
+
+// LoadBuildConfigFS - build config of preset at the root of fsys
+func LoadBuildConfigFS(fsys fs.FS) (BuildConfig, error) {
+	return loadBuildConfig(presetFS{fsys: fsys})
+}
+
+func loadBuildConfig(p presetFS) (BuildConfig, error) {
+	var pkg struct {
+		Build BuildConfig `json:"build"`
+	}
+	data, err := p.readFile("package.yml")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return BuildConfig{}, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &pkg); err != nil {
+			return BuildConfig{}, fmt.Errorf("invalid YAML in package.yml: %w", err)
+		}
+	}
+	return mergeBuildConfig(p, pkg.Build)
+}
+
+// mergeBuildConfig - keys of yieldaa.yml over cfg, then defaults and validation
+func mergeBuildConfig(p presetFS, cfg BuildConfig) (BuildConfig, error) {
+	data, err := p.readFile(ConfigFileName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return BuildConfig{}, err
+	}
+	if err == nil {
+		// keys absent in the file keep values of package.yml, unknown keys are typos
+		if err := unmarshalYAMLStrict(data, &cfg); err != nil {
+			return BuildConfig{}, fmt.Errorf("invalid YAML in %s: %w", ConfigFileName, err)
+		}
+	}
+
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return BuildConfig{}, fmt.Errorf("error validating build config: %w", err)
+	}
+	return cfg, nil
+}
+
+// unmarshalYAMLStrict - yaml.Unmarshal failing on keys that v does not have
+func unmarshalYAMLStrict(data []byte, v any) error {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	return decodeJSONStrict(j, v)
+}
+
+// decodeJSONStrict - json.Unmarshal failing on keys that v does not have
+func decodeJSONStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errors.New(strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// buildConfig - config of the package, defaults without package
+func buildConfig(pkg *Package) BuildConfig {
+	if pkg == nil {
+		return BuildConfig{}.withDefaults()
+	}
+	return pkg.Build.withDefaults()
+}
+
+// workersOf - workers of options, of build config otherwise
+func workersOf(opts ProcessOptions, pkg *Package) int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	if w := buildConfig(pkg).Workers; w > 0 {
+		return w
+	}
+	return DefaultWorkers
+}
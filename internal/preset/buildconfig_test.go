@@ -0,0 +1,105 @@
+package preset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadBuildConfigFS(t *testing.T) {
+	const pkg = `name: test
+version: 1.0.0
+build:
+  workers: 8
+  outputs:
+    gen:
+      go: gen/go
+  lint:
+    rules:
+      field-examples: off
+      string-max: error
+`
+	tests := []struct {
+		name    string
+		config  string
+		errText string
+		check   func(t *testing.T, cfg BuildConfig)
+	}{
+		{
+			name: "package only",
+			check: func(t *testing.T, cfg BuildConfig) {
+				if cfg.Workers != 8 || cfg.Duplicates != DuplicatesSkip {
+					t.Errorf("workers %d, duplicates %s", cfg.Workers, cfg.Duplicates)
+				}
+			},
+		},
+		{
+			name:   "empty config",
+			config: "",
+			check: func(t *testing.T, cfg BuildConfig) {
+				if cfg.Workers != 8 || len(cfg.Lint.Rules) != 2 {
+					t.Errorf("workers %d, lint %v", cfg.Workers, cfg.Lint.Rules)
+				}
+			},
+		},
+		{
+			name:   "lint rules merged by key",
+			config: "lint:\n  rules:\n    string-max: warning\n    snake-case: on\n",
+			check: func(t *testing.T, cfg BuildConfig) {
+				want := map[string]string{"field-examples": LintOff, "string-max": LintWarning, "snake-case": LintOn}
+				if !reflect.DeepEqual(cfg.Lint.Rules, want) {
+					t.Errorf("lint rules = %v, want %v", cfg.Lint.Rules, want)
+				}
+			},
+		},
+		{
+			name:   "keys absent in config kept",
+			config: "strict: true\noutputs:\n  openapi: api.json\n",
+			check: func(t *testing.T, cfg BuildConfig) {
+				if !cfg.Strict || cfg.Workers != 8 || cfg.Outputs.OpenAPI != "api.json" || cfg.Outputs.Gen["go"] != "gen/go" {
+					t.Errorf("config = %+v", cfg)
+				}
+			},
+		},
+		{
+			name:    "unknown key",
+			config:  "worker: 4\n",
+			errText: `invalid YAML in yieldaa.yml: unknown field "worker"`,
+		},
+		{
+			name:    "unknown nested key",
+			config:  "limits:\n  name_minimum: 2\n",
+			errText: `unknown field "name_minimum"`,
+		},
+		{
+			name:    "unknown lint key",
+			config:  "lint:\n  rule:\n    string-max: off\n",
+			errText: `lint: unknown field "rule"`,
+		},
+		{
+			name:    "unknown lint rule",
+			config:  "lint:\n  rules:\n    string-min: off\n",
+			errText: "lint: unknown rule 'string-min'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"package.yml": {Data: []byte(pkg)}}
+			if tt.name != "package only" {
+				fsys[ConfigFileName] = &fstest.MapFile{Data: []byte(tt.config)}
+			}
+			cfg, err := LoadBuildConfigFS(fsys)
+			if tt.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("error = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package preset
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BundleFormat - version of bundle layout, readers refuse newer formats
+const BundleFormat = 1
+
+// BundleExt - file extension of bundles
+const BundleExt = ".tar.gz"
+
+// bundle layout: manifest.json, signature.json (signed bundles), entities/<key>.json,
+// schemas/<key>.json, schemas/<locale>/<key>.json
+const (
+	bundleManifest  = "manifest.json"
+	bundleSignature = "signature.json"
+	bundleEntities  = "entities/"
+	bundleSchemas   = "schemas/"
+)
+
+// limit of one bundle part, the bundle is untrusted input
+const maxBundlePart = 64 << 20
+
+// BundleManifest - package metadata and index of a bundle
+type BundleManifest struct {
+	Format      int    `json:"format"`
+	ToolVersion string `json:"tool_version"`
+
+	Name          string            `json:"name"`
+	Version       string            `json:"version"`
+	Region        string            `json:"region,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+	Dependencies  map[string]string `json:"dependencies,omitempty"`
+	Locales       []string          `json:"locales,omitempty"`
+	DefaultLocale string            `json:"default_locale,omitempty"`
+
+	EntitiesStructureHash uint32        `json:"entities_structure_hash"`
+	Entities              []BundleEntry `json:"entities"` // sorted by key
+
+	// sha256 of every part of the bundle but manifest and signature, by part name
+	Checksums map[string]string `json:"checksums"`
+}
+
+// BundleEntry - entity of bundle index
+type BundleEntry struct {
+	Key         string `json:"key"`
+	Source      string `json:"source"` // path of entity file under entities directory
+	Size        int64  `json:"size"`
+	ContentHash string `json:"content_hash"`
+	Entity      string `json:"entity"` // part with compiled entity
+	Schema      string `json:"schema"` // part with JSON Schema
+	// locale -> part with JSON Schema of the locale (i18n split)
+	LocalizedSchemas map[string]string `json:"localized_schemas,omitempty"`
+}
+
+// Bundle - opened bundle
+type Bundle struct {
+	Manifest BundleManifest
+	Entities map[string]map[string]any // by key
+	Schemas  map[string]map[string]any // by key
+	// by key, then by locale
+	LocalizedSchemas map[string]map[string]map[string]any
+
+	Signature *BundleSignature // nil - unsigned
+}
+
+// BundleOptions - checks of bundle reader
+type BundleOptions struct {
+	Trust *TrustStore // not nil - unsigned bundles and signatures of other keys are refused
+}
+
+// WriteBundle - valid entities of the package as tar.gz, signed with key unless nil;
+// same input gives same bytes
+func WriteBundle(w io.Writer, pkg *Package, processed []ProcessedEntity, key *SigningKey) (*BundleManifest, error) {
+	manifest := &BundleManifest{
+		Format:                BundleFormat,
+		ToolVersion:           ToolVersion,
+		Name:                  pkg.Name,
+		Version:               pkg.Version,
+		Region:                pkg.Region,
+		Description:           pkg.Description,
+		Tags:                  pkg.Tags,
+		Dependencies:          pkg.Dependencies,
+		Locales:               pkg.Locales,
+		DefaultLocale:         pkg.DefaultLocale,
+		EntitiesStructureHash: pkg.EntitiesStructureHash,
+		Entities:              []BundleEntry{},
+		Checksums:             make(map[string]string),
+	}
+
+	parts := make(map[string][]byte)
+	for _, pe := range validEntities(processed) {
+		key := EntityKey(pe.ParsedData)
+		entry := BundleEntry{
+			Key:         key,
+			Source:      bundleSource(pe.File),
+			Size:        pe.File.Size,
+			ContentHash: pe.ContentHash,
+			Entity:      bundleEntities + key + ".json",
+			Schema:      bundleSchemas + key + ".json",
+		}
+		entity, err := json.Marshal(pe.ParsedData)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		schema, err := json.Marshal(pe.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: schema: %w", key, err)
+		}
+		parts[entry.Entity], parts[entry.Schema] = entity, schema
+		for locale, localized := range pe.LocalizedSchemas {
+			data, err := json.Marshal(localized)
+			if err != nil {
+				return nil, fmt.Errorf("%s: schema %s: %w", key, locale, err)
+			}
+			if entry.LocalizedSchemas == nil {
+				entry.LocalizedSchemas = make(map[string]string)
+			}
+			name := bundleSchemas + locale + "/" + key + ".json"
+			entry.LocalizedSchemas[locale], parts[name] = name, data
+		}
+		manifest.Entities = append(manifest.Entities, entry)
+	}
+	for name, data := range parts {
+		manifest.Checksums[name] = sha256Hex(data)
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	// manifest first: readers learn the format before the parts
+	if err := write(bundleManifest, manifestData); err != nil {
+		return nil, err
+	}
+	if key != nil {
+		sig, err := json.MarshalIndent(signManifest(manifestData, key), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("signature: %w", err)
+		}
+		if err := write(bundleSignature, sig); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(parts) {
+		if err := write(name, parts[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// SaveBundle - WriteBundle to file
+func SaveBundle(pkg *Package, processed []ProcessedEntity, outputPath string, key *SigningKey) (*BundleManifest, error) {
+	var buf bytes.Buffer
+	manifest, err := WriteBundle(&buf, pkg, processed, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(buf.Bytes(), outputPath); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ReadBundle - parts of tar.gz bundle; checksums, the index and the signature, if any, are verified
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	return ReadBundleWith(r, BundleOptions{})
+}
+
+// ReadBundleWith - ReadBundle with trust store check
+func ReadBundleWith(r io.Reader, opts BundleOptions) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	defer gz.Close()
+
+	parts := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if hdr.Size > maxBundlePart {
+			return nil, fmt.Errorf("bundle: %s: part is too large", name)
+		}
+		if _, dup := parts[name]; dup {
+			return nil, fmt.Errorf("bundle: %s: duplicate part", name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBundlePart))
+		if err != nil {
+			return nil, fmt.Errorf("bundle: %s: %w", name, err)
+		}
+		parts[name] = data
+	}
+
+	data, ok := parts[bundleManifest]
+	if !ok {
+		return nil, fmt.Errorf("bundle: no %s", bundleManifest)
+	}
+	b := &Bundle{
+		Entities:         make(map[string]map[string]any),
+		Schemas:          make(map[string]map[string]any),
+		LocalizedSchemas: make(map[string]map[string]map[string]any),
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("bundle: %s: %w", bundleManifest, err)
+	}
+	if b.Manifest.Format < 1 || b.Manifest.Format > BundleFormat {
+		return nil, fmt.Errorf("bundle: format %d is not supported, tool reads up to %d", b.Manifest.Format, BundleFormat)
+	}
+
+	if sigData, ok := parts[bundleSignature]; ok {
+		var sig BundleSignature
+		if err := json.Unmarshal(sigData, &sig); err != nil {
+			return nil, fmt.Errorf("bundle: %s: %w", bundleSignature, err)
+		}
+		if err := sig.verify(data); err != nil {
+			return nil, fmt.Errorf("bundle: %w", err)
+		}
+		b.Signature = &sig
+	}
+	if opts.Trust != nil {
+		if err := opts.Trust.check(b.Signature); err != nil {
+			return nil, fmt.Errorf("bundle: %w", err)
+		}
+	}
+
+	for name, data := range parts {
+		if name == bundleManifest || name == bundleSignature {
+			continue
+		}
+		sum, ok := b.Manifest.Checksums[name]
+		if !ok {
+			return nil, fmt.Errorf("bundle: %s: part is not in manifest", name)
+		}
+		if sha256Hex(data) != sum {
+			return nil, fmt.Errorf("bundle: %s: checksum mismatch", name)
+		}
+	}
+
+	for _, entry := range b.Manifest.Entities {
+		entity, err := bundlePart(parts, entry.Entity)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := bundlePart(parts, entry.Schema)
+		if err != nil {
+			return nil, err
+		}
+		if key := EntityKey(entity); key != entry.Key {
+			return nil, fmt.Errorf("bundle: %s: entity key %s, index says %s", entry.Entity, key, entry.Key)
+		}
+		if _, dup := b.Entities[entry.Key]; dup {
+			return nil, fmt.Errorf("bundle: entity %s is listed twice", entry.Key)
+		}
+		b.Entities[entry.Key] = entity
+		b.Schemas[entry.Key] = schema
+		for locale, name := range entry.LocalizedSchemas {
+			localized, err := bundlePart(parts, name)
+			if err != nil {
+				return nil, err
+			}
+			if b.LocalizedSchemas[entry.Key] == nil {
+				b.LocalizedSchemas[entry.Key] = make(map[string]map[string]any)
+			}
+			b.LocalizedSchemas[entry.Key][locale] = localized
+		}
+	}
+	return b, nil
+}
+
+// OpenBundle - ReadBundle of file
+func OpenBundle(bundlePath string) (*Bundle, error) {
+	return OpenBundleWith(bundlePath, BundleOptions{})
+}
+
+// OpenBundleWith - ReadBundleWith of file
+func OpenBundleWith(bundlePath string, opts BundleOptions) (*Bundle, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBundleWith(f, opts)
+}
+
+// LoadBundle - package and processed entities of bundle file, as LoadAndProcessPreset
+// gives them for the source directory
+func LoadBundle(bundlePath string) (*Package, []ProcessedEntity, error) {
+	return LoadBundleWith(bundlePath, BundleOptions{})
+}
+
+// LoadBundleWith - LoadBundle with trust store check
+func LoadBundleWith(bundlePath string, opts BundleOptions) (*Package, []ProcessedEntity, error) {
+	b, err := OpenBundleWith(bundlePath, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkg, processed := b.Package(bundlePath)
+	return pkg, processed, nil
+}
+
+// Package - package and processed entities of bundle; paths of entity files are
+// <bundlePath>/<source>
+func (b *Bundle) Package(bundlePath string) (*Package, []ProcessedEntity) {
+	m := b.Manifest
+	pkg := &Package{
+		Name:                  m.Name,
+		Version:               m.Version,
+		Region:                m.Region,
+		Description:           m.Description,
+		Tags:                  m.Tags,
+		Dependencies:          m.Dependencies,
+		Locales:               m.Locales,
+		DefaultLocale:         m.DefaultLocale,
+		EntitiesCount:         len(m.Entities),
+		EntitiesStructureHash: m.EntitiesStructureHash,
+	}
+
+	processed := make([]ProcessedEntity, 0, len(m.Entities))
+	for _, entry := range m.Entities {
+		file := EntityFile{
+			Path:        filepath.Join(bundlePath, filepath.FromSlash(entry.Source)),
+			Size:        entry.Size,
+			ContentHash: entry.ContentHash,
+			name:        entry.Source,
+			root:        ".",
+		}
+		pkg.EntitiesFiles = append(pkg.EntitiesFiles, file)
+		pkg.EntitiesTotalSize += entry.Size
+
+		jsonData, _ := json.Marshal(b.Entities[entry.Key])
+		processed = append(processed, ProcessedEntity{
+			File:        file,
+			ContentHash: entry.ContentHash,
+			JSONData:    jsonData,
+			ParsedData:  b.Entities[entry.Key],
+			Schema:      b.Schemas[entry.Key],
+			Locale:      PackageLocale(pkg),
+
+			LocalizedSchemas: b.LocalizedSchemas[entry.Key],
+		})
+	}
+	return pkg, processed
+}
+
+// IsBundle - path names a bundle file rather than a source directory
+func IsBundle(p string) bool {
+	return strings.HasSuffix(strings.ToLower(p), BundleExt) || strings.HasSuffix(strings.ToLower(p), ".tgz")
+}
+
+func bundlePart(parts map[string][]byte, name string) (map[string]any, error) {
+	data, ok := parts[name]
+	if !ok {
+		return nil, fmt.Errorf("bundle: missing part %s", name)
+	}
+	var v map[string]any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("bundle: %s: %w", name, err)
+	}
+	return v, nil
+}
+
+// bundleSource - slash path of entity file under the scanned entities directory, the same
+// for any spelling of the preset directory; machine paths stay out of bundles
+func bundleSource(file EntityFile) string {
+	if file.name != "" {
+		if file.root == "." {
+			return file.name
+		}
+		if rel, ok := strings.CutPrefix(file.name, file.root+"/"); ok {
+			return rel
+		}
+	}
+	p := filepath.ToSlash(file.Path)
+	if i := strings.LastIndex(p, "/entities/"); i >= 0 {
+		return p[i+len("/entities/"):]
+	}
+	return path.Base(p)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package preset
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const bundleTestEntity = `module: crm
+object: client
+property: requisite
+code: %s
+name: Sample
+fields:
+  - code: inn
+    name: INN
+    type: string
+    required: true
+`
+
+func TestBundleSourceIndependentOfDirSpelling(t *testing.T) {
+	// structure hash of the manifest covers modification times
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"package.yml":                 {Data: []byte("name: test\nversion: 1.0.0\n"), ModTime: mtime},
+		"entities/clients/ooo.yml":    {Data: []byte(fmt.Sprintf(bundleTestEntity, "ooo")), ModTime: mtime},
+		"entities/individuals/ip.yml": {Data: []byte(fmt.Sprintf(bundleTestEntity, "ip")), ModTime: mtime},
+	}
+	dir := t.TempDir()
+	for name, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, f.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+	}
+
+	bundle := func(load func() (*Package, []ProcessedEntity, []error)) ([]byte, *BundleManifest) {
+		pkg, processed, errs := load()
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		var buf bytes.Buffer
+		m, err := WriteBundle(&buf, pkg, processed, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes(), m
+	}
+
+	want, m := bundle(func() (*Package, []ProcessedEntity, []error) {
+		return LoadAndProcessPresetWith(dir, ProcessOptions{Workers: 1})
+	})
+	sources := map[string]bool{}
+	for _, e := range m.Entities {
+		sources[e.Source] = true
+	}
+	if !sources["clients/ooo.yml"] || !sources["individuals/ip.yml"] {
+		t.Fatalf("sources = %v, want paths under entities/", sources)
+	}
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	loads := map[string]func() (*Package, []ProcessedEntity, []error){
+		"dot": func() (*Package, []ProcessedEntity, []error) {
+			return LoadAndProcessPresetWith(".", ProcessOptions{Workers: 1})
+		},
+		"fs": func() (*Package, []ProcessedEntity, []error) {
+			return LoadAndProcessPresetFS(files, ProcessOptions{Workers: 1})
+		},
+	}
+	for name, load := range loads {
+		if got, _ := bundle(load); !bytes.Equal(got, want) {
+			t.Errorf("%s: bundle differs from bundle of %s", name, dir)
+		}
+	}
+
+	// bundle of bundle keeps sources
+	b, err := ReadBundle(bytes.NewReader(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := bundle(func() (*Package, []ProcessedEntity, []error) {
+		pkg, processed := b.Package("x.tar.gz")
+		return pkg, processed, nil
+	}); !bytes.Equal(got, want) {
+		t.Error("bundle of bundle differs")
+	}
+}
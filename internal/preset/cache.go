@@ -0,0 +1,335 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BuildCache - on-disk cache of ProcessEntity results keyed by content hash,
+// build of the tool and package settings that affect processing.
+// Layout: <dir>/<CacheVersion>/<key[:2]>/<key>.json
+type BuildCache struct {
+	dir string
+
+	hits, misses, writes, failures atomic.Int64
+}
+
+// CacheStats - counters of one run
+type CacheStats struct {
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+	Writes   int64 `json:"writes"`
+	Failures int64 `json:"failures"`
+}
+
+// cacheEntry - stored result of ProcessEntity, file metadata is taken from the current run
+type cacheEntry struct {
+	ToolVersion string          `json:"tool_version"`
+	ContentHash string          `json:"content_hash"`
+	JSONData    json.RawMessage `json:"json_data"`
+	Schema      map[string]any  `json:"schema,omitempty"`
+	Errors      []string        `json:"errors,omitempty"`
+	Locale      string          `json:"locale,omitempty"`
+}
+
+// DefaultCacheDir - user cache directory, .yieldaa-cache in working directory as fallback
+func DefaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "yieldaa-runtime")
+	}
+	return ".yieldaa-cache"
+}
+
+// CacheVersion - cache directory of this build: ToolVersion and build id. Results of other
+// builds are not reused, their validation code may differ under the same ToolVersion
+func CacheVersion() string {
+	return cacheVersion()
+}
+
+var cacheVersion = sync.OnceValue(func() string {
+	if id := buildID(); id != "" {
+		return ToolVersion + "-" + id
+	}
+	return ToolVersion
+})
+
+// buildID - vcs revision of clean builds, hash of the executable otherwise; "" - unknown
+func buildID() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		revision, modified := "", false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if revision != "" && !modified {
+			return revision[:min(12, len(revision))]
+		}
+	}
+
+	// local and dirty builds: every change of the code changes the binary
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		return ""
+	}
+	return calculateContentHash(data)[:12]
+}
+
+// cache directory marker, Cache Directory Tagging Specification: backup tools skip the
+// directory, prune and clear refuse directories without it
+const (
+	cacheTagFile      = "CACHEDIR.TAG"
+	cacheTagSignature = "Signature: 8a477f597d28d172789f06886806bc55"
+)
+
+// cacheVersionRegex - names of cache version directories: ToolVersion and optional build id
+var cacheVersionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[0-9a-f]{12})?$`)
+
+// OpenBuildCache - cache in dir, created and tagged when missing
+func OpenBuildCache(dir string) (*BuildCache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, cacheVersion()), 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory: %w", err)
+	}
+	tag := filepath.Join(dir, cacheTagFile)
+	if _, err := os.Stat(tag); errors.Is(err, fs.ErrNotExist) {
+		if err := writeFile([]byte(cacheTagSignature+"\n# build cache of yieldaa runtime, see cli cache\n"), tag); err != nil {
+			return nil, fmt.Errorf("tag cache directory: %w", err)
+		}
+	}
+	return &BuildCache{dir: dir}, nil
+}
+
+// ExistingBuildCache - cache created by OpenBuildCache in dir, nothing is created;
+// error wrapping fs.ErrNotExist when dir does not exist
+func ExistingBuildCache(dir string) (*BuildCache, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, cacheTagFile))
+	if err != nil || !strings.HasPrefix(string(data), cacheTagSignature) {
+		return nil, fmt.Errorf("%s is not a build cache: no %s", dir, cacheTagFile)
+	}
+	return &BuildCache{dir: dir}, nil
+}
+
+func (c *BuildCache) Dir() string {
+	return c.dir
+}
+
+func (c *BuildCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Writes:   c.writes.Load(),
+		Failures: c.failures.Load(),
+	}
+}
+
+// cacheKey - content hash combined with package settings used by ProcessEntity
+func cacheKey(contentHash string, pkg *Package) string {
+	parts := []string{contentHash}
+	if pkg != nil {
+		limits := buildConfig(pkg).Limits
+		parts = append(parts, pkg.Region, strings.Join(pkg.Locales, ","), pkg.DefaultLocale,
+			strings.Join(limits.RequiredKeys, ","), strconv.Itoa(limits.MaxFields))
+	}
+	return calculateContentHash([]byte(strings.Join(parts, "\x00")))
+}
+
+func (c *BuildCache) entryPath(key string) string {
+	return filepath.Join(c.dir, cacheVersion(), key[:2], key+".json")
+}
+
+// Load - cached result for file content, ok is false on miss
+func (c *BuildCache) Load(file EntityFile, contentHash string, pkg *Package) (ProcessedEntity, bool) {
+	path := c.entryPath(cacheKey(contentHash, pkg))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.failures.Add(1)
+		}
+		c.misses.Add(1)
+		return ProcessedEntity{}, false
+	}
+
+	var entry cacheEntry
+	var parsed map[string]any
+	if json.Unmarshal(data, &entry) != nil || entry.ContentHash != contentHash ||
+		json.Unmarshal(entry.JSONData, &parsed) != nil {
+		// broken or foreign entry, will be overwritten
+		c.failures.Add(1)
+		c.misses.Add(1)
+		return ProcessedEntity{}, false
+	}
+
+	// mtime is the last use, prune removes entries by it
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	c.hits.Add(1)
+
+	return ProcessedEntity{
+		File:        file,
+		ContentHash: entry.ContentHash,
+		JSONData:    []byte(entry.JSONData),
+		ParsedData:  parsed,
+		Schema:      entry.Schema,
+		Errors:      entry.Errors,
+		Locale:      entry.Locale,
+	}, true
+}
+
+// Store - save result of ProcessEntity; fatal results are not cached
+func (c *BuildCache) Store(result ProcessedEntity, pkg *Package) {
+	if result.FatalError != nil || result.JSONData == nil {
+		return
+	}
+
+	data, err := json.Marshal(cacheEntry{
+		ToolVersion: cacheVersion(),
+		ContentHash: result.ContentHash,
+		JSONData:    result.JSONData,
+		Schema:      result.Schema,
+		Errors:      result.Errors,
+		Locale:      result.Locale,
+	})
+	if err == nil {
+		err = writeFile(data, c.entryPath(cacheKey(result.ContentHash, pkg)))
+	}
+	if err != nil {
+		c.failures.Add(1)
+		return
+	}
+	c.writes.Add(1)
+}
+
+// CacheUsage - entries and size per cache version directory
+type CacheUsage struct {
+	Version string
+	Entries int
+	Size    int64
+}
+
+// versions - cache version directories; other files of the directory are not the cache's
+func (c *BuildCache) versions() ([]string, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() && cacheVersionRegex.MatchString(e.Name()) {
+			versions = append(versions, e.Name())
+		}
+	}
+	return versions, nil
+}
+
+// Usage - disk usage of cache by cache version
+func (c *BuildCache) Usage() ([]CacheUsage, error) {
+	versions, err := c.versions()
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []CacheUsage
+	for _, v := range versions {
+		u := CacheUsage{Version: v}
+		err := filepath.WalkDir(filepath.Join(c.dir, v), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if info, err := d.Info(); err == nil {
+				u.Entries++
+				u.Size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// Prune - remove entries of other builds and entries not used for maxAge (0 - keep all)
+func (c *BuildCache) Prune(maxAge time.Duration) (removed int, err error) {
+	versions, err := c.versions()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, v := range versions {
+		path := filepath.Join(c.dir, v)
+		if v != cacheVersion() {
+			n, _ := countFiles(path)
+			if err := os.RemoveAll(path); err != nil {
+				return removed, err
+			}
+			removed += n
+		}
+	}
+
+	if maxAge <= 0 {
+		return removed, nil
+	}
+	deadline := time.Now().Add(-maxAge)
+	err = filepath.WalkDir(filepath.Join(c.dir, cacheVersion()), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err == nil && info.ModTime().Before(deadline) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Clear - remove entries of all builds; the tagged directory itself stays
+func (c *BuildCache) Clear() error {
+	versions, err := c.versions()
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := os.RemoveAll(filepath.Join(c.dir, v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countFiles(dir string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	return n, err
+}
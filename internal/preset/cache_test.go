@@ -0,0 +1,101 @@
+package preset
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheVersionIdentifiesBuild(t *testing.T) {
+	v := CacheVersion()
+	if !strings.HasPrefix(v, ToolVersion+"-") {
+		t.Fatalf("CacheVersion = %s, want %s-<build id>", v, ToolVersion)
+	}
+	if CacheVersion() != v {
+		t.Error("CacheVersion is not stable within a build")
+	}
+}
+
+func TestBuildCacheStoreLoadPrune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenBuildCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := &Package{Region: "ru"}
+	result := ProcessedEntity{ContentHash: "abc", JSONData: []byte(`{"code":"x"}`), Errors: []string{"e"}}
+	cache.Store(result, pkg)
+
+	got, ok := cache.Load(EntityFile{Path: "x.yml"}, "abc", pkg)
+	if !ok || string(got.JSONData) != `{"code":"x"}` || len(got.Errors) != 1 {
+		t.Fatalf("Load = %+v, %v", got, ok)
+	}
+	if _, ok := cache.Load(EntityFile{Path: "x.yml"}, "abc", &Package{Region: "kz"}); ok {
+		t.Error("entry of other package settings loaded")
+	}
+
+	// results of other builds of the same tool version are never read and pruned
+	stale := filepath.Join(dir, ToolVersion, "ab", "entry.json")
+	if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := cache.Prune(0)
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune = %d, %v; want 1 stale entry removed", removed, err)
+	}
+	if _, ok := cache.Load(EntityFile{Path: "x.yml"}, "abc", pkg); !ok {
+		t.Error("entry of current build pruned")
+	}
+}
+
+func TestBuildCacheKeepsForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+	foreign := []string{filepath.Join(dir, "other-tool", "data"), filepath.Join(dir, "notes.txt")}
+	for _, path := range foreign {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x"), 0644)
+	}
+
+	// untagged directory is refused, nothing is created in it
+	if _, err := ExistingBuildCache(dir); err == nil {
+		t.Fatal("untagged directory opened as cache")
+	}
+	if _, err := os.Stat(filepath.Join(dir, CacheVersion())); err == nil {
+		t.Fatal("ExistingBuildCache created the version directory")
+	}
+	if _, err := ExistingBuildCache(filepath.Join(dir, "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing directory: %v, want fs.ErrNotExist", err)
+	}
+
+	if _, err := OpenBuildCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(dir, ToolVersion+"-0123456789ab"), 0755)
+	cache, err := ExistingBuildCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Prune(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ToolVersion+"-0123456789ab")); err == nil {
+		t.Error("version directory of other build not pruned")
+	}
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, CacheVersion())); err == nil {
+		t.Error("version directory not cleared")
+	}
+	for _, path := range foreign {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("foreign file removed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// change kinds
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// EntityChange - difference of entity or field between two releases;
+// Level - bump required by the change
+type EntityChange struct {
+	Kind   string `json:"kind"`
+	Entity string `json:"entity"`
+	Field  string `json:"field,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	Level  string `json:"level"`
+}
+
+func (c EntityChange) String() string {
+	s := "entity " + c.Entity
+	if c.Field != "" {
+		s = "field " + c.Entity + "." + c.Field
+	}
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	if c.Level == BumpMajor && c.Kind == ChangeChanged {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// constraint keys of field: new or changed value may reject data that was valid
+var restrictingKeys = []string{
+	"validator", "format", "phone_region", "pattern",
+	"precision", "scale", "encoding", "currency", "currency_field", "bik_field",
+}
+
+// descriptive keys of field: changes do not affect data
+var descriptiveKeys = []string{"name", "description", "default", "examples"}
+
+// DiffEntities - changes from prev to next, entity key -> parsed entity; sorted by entity and field
+func DiffEntities(prev, next map[string]map[string]any) []EntityChange {
+	var changes []EntityChange
+	for _, key := range sortedKeys(prev) {
+		if _, ok := next[key]; !ok {
+			changes = append(changes, EntityChange{Kind: ChangeRemoved, Entity: key, Level: BumpMajor})
+		}
+	}
+	for _, key := range sortedKeys(next) {
+		old, ok := prev[key]
+		if !ok {
+			changes = append(changes, EntityChange{Kind: ChangeAdded, Entity: key, Level: BumpMinor})
+			continue
+		}
+		changes = append(changes, diffEntity(key, old, next[key])...)
+	}
+	return changes
+}
+
+// RequiredBump - highest level of changes
+func RequiredBump(changes []EntityChange) string {
+	level := BumpNone
+	for _, c := range changes {
+		level = maxBump(level, c.Level)
+	}
+	return level
+}
+
+func diffEntity(key string, prev, next map[string]any) []EntityChange {
+	var changes []EntityChange
+	for _, k := range []string{"name", "description"} {
+		if !sameValue(prev[k], next[k]) {
+			changes = append(changes, EntityChange{Kind: ChangeChanged, Entity: key, Detail: k + " changed", Level: BumpPatch})
+		}
+	}
+
+	prevFields, nextFields := fieldsMap(prev), fieldsMap(next)
+	for _, code := range sortedKeys(prevFields) {
+		if _, ok := nextFields[code]; !ok {
+			changes = append(changes, EntityChange{Kind: ChangeRemoved, Entity: key, Field: code, Level: BumpMajor})
+		}
+	}
+	for _, code := range sortedKeys(nextFields) {
+		field := nextFields[code]
+		old, ok := prevFields[code]
+		if !ok {
+			level := BumpMinor
+			if required, _ := field["required"].(bool); required {
+				level = BumpMajor
+			}
+			changes = append(changes, EntityChange{Kind: ChangeAdded, Entity: key, Field: code, Level: level})
+			continue
+		}
+		for _, d := range diffField(old, field) {
+			changes = append(changes, EntityChange{Kind: ChangeChanged, Entity: key, Field: code, Detail: d.detail, Level: d.level})
+		}
+	}
+	return changes
+}
+
+type fieldDiff struct {
+	detail string
+	level  string
+}
+
+func diffField(prev, next map[string]any) []fieldDiff {
+	var diffs []fieldDiff
+	add := func(level, format string, args ...any) {
+		diffs = append(diffs, fieldDiff{fmt.Sprintf(format, args...), level})
+	}
+
+	for _, k := range []string{"type", "ref"} {
+		if !sameValue(prev[k], next[k]) {
+			add(BumpMajor, "%s %s -> %s", k, showValue(prev[k]), showValue(next[k]))
+		}
+	}
+
+	wasRequired, _ := prev["required"].(bool)
+	isRequired, _ := next["required"].(bool)
+	switch {
+	case !wasRequired && isRequired:
+		add(BumpMajor, "became required")
+	case wasRequired && !isRequired:
+		add(BumpMinor, "became optional")
+	}
+
+	// enum values
+	prevValues, nextValues := valueSet(prev["values"]), valueSet(next["values"])
+	var removed, added []string
+	for v := range prevValues {
+		if !nextValues[v] {
+			removed = append(removed, v)
+		}
+	}
+	for v := range nextValues {
+		if !prevValues[v] {
+			added = append(added, v)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	if len(removed) > 0 {
+		add(BumpMajor, "values removed: %s", strings.Join(removed, ", "))
+	}
+	if len(added) > 0 {
+		add(BumpMinor, "values added: %s", strings.Join(added, ", "))
+	}
+
+	// bounds: tighter bound rejects data that was valid
+	for _, k := range []string{"min", "max"} {
+		p, n := getNumberValue(prev, k), getNumberValue(next, k)
+		switch {
+		case p == nil && n == nil:
+		case n == nil:
+			add(BumpMinor, "%s %v removed", k, *p)
+		case p == nil:
+			add(BumpMajor, "%s %v added", k, *n)
+		case *p != *n:
+			tighter := (k == "min" && *n > *p) || (k == "max" && *n < *p)
+			level := BumpMinor
+			if tighter {
+				level = BumpMajor
+			}
+			add(level, "%s %v -> %v", k, *p, *n)
+		}
+	}
+
+	// multipleOf or multiple_of: new step is looser only when old values stay its multiples
+	switch p, n := getMultipleOf(prev), getMultipleOf(next); {
+	case p == nil && n == nil:
+	case n == nil:
+		add(BumpMinor, "multiple_of %v removed", *p)
+	case p == nil:
+		add(BumpMajor, "multiple_of %v added", *n)
+	case *p != *n:
+		level := BumpMajor
+		if ratio := *p / *n; *n != 0 && ratio >= 1 && math.Abs(ratio-math.Round(ratio)) < 1e-9 {
+			level = BumpMinor
+		}
+		add(level, "multiple_of %v -> %v", *p, *n)
+	}
+
+	for _, k := range restrictingKeys {
+		switch p, n := prev[k], next[k]; {
+		case sameValue(p, n):
+		case n == nil:
+			add(BumpMinor, "%s removed", k)
+		case p == nil:
+			add(BumpMajor, "%s %s added", k, showValue(n))
+		default:
+			add(BumpMajor, "%s %s -> %s", k, showValue(p), showValue(n))
+		}
+	}
+
+	for _, k := range descriptiveKeys {
+		if !sameValue(prev[k], next[k]) {
+			add(BumpPatch, "%s changed", k)
+		}
+	}
+	return diffs
+}
+
+// fieldsMap - fields of entity by code
+func fieldsMap(entity map[string]any) map[string]map[string]any {
+	result := make(map[string]map[string]any)
+	for _, field := range entityFields(entity) {
+		if code, _ := getFieldCodeAndType(field); code != "" {
+			result[code] = field
+		}
+	}
+	return result
+}
+
+func valueSet(v any) map[string]bool {
+	values, _ := v.([]any)
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[showValue(value)] = true
+	}
+	return set
+}
+
+// sameValue - values are equal as JSON, numbers of YAML and of JSON compare equal
+func sameValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func showValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if v == nil {
+		return "none"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// FormatChangelog - CHANGELOG.md entry of version
+func FormatChangelog(version, date string, changes []EntityChange) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s - %s\n", version, date)
+
+	sections := []struct {
+		title string
+		kind  string
+	}{
+		{"Added", ChangeAdded},
+		{"Removed", ChangeRemoved},
+		{"Changed", ChangeChanged},
+	}
+	for _, s := range sections {
+		header := false
+		for _, c := range changes {
+			if c.Kind != s.kind {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&b, "\n### %s\n\n", s.title)
+				header = true
+			}
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+	if len(changes) == 0 {
+		b.WriteString("\nNo changes of entities.\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package preset
+
+import "testing"
+
+// diffOneField - changes of entity with one field "count", nil - no field
+func diffOneField(prevField, nextField map[string]any) []EntityChange {
+	entity := func(field map[string]any) map[string]map[string]any {
+		if field == nil {
+			return map[string]map[string]any{"crm.client.info.main": {"fields": []any{}}}
+		}
+		field["code"] = "count"
+		return map[string]map[string]any{"crm.client.info.main": {"fields": []any{field}}}
+	}
+	return DiffEntities(entity(prevField), entity(nextField))
+}
+
+func TestDiffEntitiesFieldLevels(t *testing.T) {
+	integer := func(kv ...any) map[string]any {
+		field := map[string]any{"type": "integer"}
+		for i := 0; i < len(kv); i += 2 {
+			field[kv[i].(string)] = kv[i+1]
+		}
+		return field
+	}
+
+	tests := []struct {
+		name string
+		prev map[string]any
+		next map[string]any
+		want string
+	}{
+		{"no changes", integer(), integer(), BumpNone},
+		{"field added", nil, integer(), BumpMinor},
+		{"required field added", nil, integer("required", true), BumpMajor},
+		{"field removed", integer(), nil, BumpMajor},
+		{"type changed", integer(), map[string]any{"type": "string"}, BumpMajor},
+		{"became required", integer(), integer("required", true), BumpMajor},
+		{"became optional", integer("required", true), integer(), BumpMinor},
+		{"min added", integer(), integer("min", 1.0), BumpMajor},
+		{"min raised", integer("min", 1.0), integer("min", 2.0), BumpMajor},
+		{"min lowered", integer("min", 2.0), integer("min", 1.0), BumpMinor},
+		{"max removed", integer("max", 10.0), integer(), BumpMinor},
+		{"multipleOf added", integer(), integer("multipleOf", 5.0), BumpMajor},
+		{"multiple_of added", integer(), integer("multiple_of", 5.0), BumpMajor},
+		{"multipleOf removed", integer("multipleOf", 5.0), integer(), BumpMinor},
+		{"multiple_of renamed to multipleOf", integer("multiple_of", 5.0), integer("multipleOf", 5.0), BumpNone},
+		{"multipleOf tightened", integer("multipleOf", 5.0), integer("multipleOf", 10.0), BumpMajor},
+		{"multipleOf loosened", integer("multipleOf", 10.0), integer("multipleOf", 5.0), BumpMinor},
+		{"multipleOf not a divisor", integer("multipleOf", 10.0), integer("multipleOf", 4.0), BumpMajor},
+		{"decimal step loosened", integer("multipleOf", 0.3), integer("multipleOf", 0.1), BumpMinor},
+		{"pattern added", map[string]any{"type": "string"}, map[string]any{"type": "string", "pattern": "^a$"}, BumpMajor},
+		{"description changed", integer("description", "a"), integer("description", "b"), BumpPatch},
+		{"values added", map[string]any{"type": "enum", "values": []any{"a"}},
+			map[string]any{"type": "enum", "values": []any{"a", "b"}}, BumpMinor},
+		{"values removed", map[string]any{"type": "enum", "values": []any{"a", "b"}},
+			map[string]any{"type": "enum", "values": []any{"a"}}, BumpMajor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffOneField(tt.prev, tt.next)
+			if got := RequiredBump(changes); got != tt.want {
+				t.Errorf("RequiredBump = %s, want %s; changes: %v", got, tt.want, changes)
+			}
+		})
+	}
+}
+
+func TestDiffEntitiesEntities(t *testing.T) {
+	a := map[string]any{"fields": []any{}}
+	prev := map[string]map[string]any{"crm.client.info.a": a, "crm.client.info.b": a}
+	next := map[string]map[string]any{"crm.client.info.a": a, "crm.client.info.c": a}
+
+	changes := DiffEntities(prev, next)
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want removed b and added c", changes)
+	}
+	if c := changes[0]; c.Kind != ChangeRemoved || c.Entity != "crm.client.info.b" || c.Level != BumpMajor {
+		t.Errorf("changes[0] = %+v, want major removal of b", c)
+	}
+	if c := changes[1]; c.Kind != ChangeAdded || c.Entity != "crm.client.info.c" || c.Level != BumpMinor {
+		t.Errorf("changes[1] = %+v, want minor addition of c", c)
+	}
+	if got := RequiredBump(changes); got != BumpMajor {
+		t.Errorf("RequiredBump = %s, want major", got)
+	}
+	if got := RequiredBump(nil); got != BumpNone {
+		t.Errorf("RequiredBump(nil) = %s, want none", got)
+	}
+}
@@ -2,8 +2,6 @@ package preset
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"regexp"
 	"unicode/utf8"
 
@@ -11,10 +9,8 @@ import (
 )
 
 // loadConfig - try to load package.yml
-func loadConfig(dir string) (*Package, error) {
-	configPath := filepath.Join(dir, "package.yml")
-
-	config, err := os.ReadFile(configPath)
+func loadConfig(p presetFS) (*Package, error) {
+	config, err := p.readFile("package.yml")
 	if err != nil {
 		return nil, fmt.Errorf("cannot read package.yml: %w", err)
 	}
@@ -24,6 +20,12 @@ func loadConfig(dir string) (*Package, error) {
 		return nil, fmt.Errorf("invalid YAML in package.yml: %w", err)
 	}
 
+	build, err := mergeBuildConfig(p, pkg.Build)
+	if err != nil {
+		return nil, err
+	}
+	pkg.Build = build
+
 	if err := validateConfig(pkg); err != nil {
 		return nil, fmt.Errorf("error validating package.yml: %w", err)
 	}
@@ -33,16 +35,18 @@ func loadConfig(dir string) (*Package, error) {
 
 // validateConfig - validate config fields
 func validateConfig(pkg Package) error {
+	limits := buildConfig(&pkg).Limits
+
 	// name
 	if pkg.Name == "" {
 		return fmt.Errorf("'name' is required")
 	}
 
 	nameLen := utf8.RuneCountInString(pkg.Name)
-	if nameLen < 4 || nameLen > 32 {
+	if nameLen < limits.NameMin || nameLen > limits.NameMax {
 		return fmt.Errorf(
-			"'name' must be 4-32 characters, got %d (%s)",
-			nameLen, pkg.Name)
+			"'name' must be %d-%d characters, got %d (%s)",
+			limits.NameMin, limits.NameMax, nameLen, pkg.Name)
 	}
 
 	// version
@@ -60,12 +64,41 @@ func validateConfig(pkg Package) error {
 	// region
 	if pkg.Region != "" {
 		regionLen := utf8.RuneCountInString(pkg.Region)
-		if regionLen < 2 || regionLen > 3 {
+		if regionLen < limits.RegionMin || regionLen > limits.RegionMax {
 			return fmt.Errorf(
-				"'region' must be 2-3 characters (e.g. 'ru'), got %s",
-				pkg.Region)
+				"'region' must be %d-%d characters (e.g. 'ru'), got %s",
+				limits.RegionMin, limits.RegionMax, pkg.Region)
+		}
+	}
+
+	// locales
+	for _, locale := range pkg.Locales {
+		if !localeRegex.MatchString(locale) {
+			return fmt.Errorf(
+				"'locales' must contain 2-3 letter codes (e.g. 'en'), got %s",
+				locale)
+		}
+	}
+	if pkg.DefaultLocale != "" && len(pkg.Locales) > 0 {
+		found := false
+		for _, locale := range pkg.Locales {
+			found = found || locale == pkg.DefaultLocale
+		}
+		if !found {
+			return fmt.Errorf(
+				"'default_locale' %s is not listed in 'locales'",
+				pkg.DefaultLocale)
+		}
+	}
+
+	// dependencies
+	for _, name := range sortedKeys(pkg.Dependencies) {
+		if _, err := ParseConstraint(pkg.Dependencies[name]); err != nil {
+			return fmt.Errorf("'dependencies' %s: %w", name, err)
 		}
 	}
 
 	return nil
 }
+
+var localeRegex = regexp.MustCompile(`^[a-z]{2,3}$`)
@@ -0,0 +1,358 @@
+package preset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SQL dialects of DDL generator
+const (
+	SQLPostgres = "postgres"
+	SQLSQLite   = "sqlite"
+)
+
+var sqlDialects = []string{SQLPostgres, SQLSQLite}
+
+// GenerateSQL - CREATE TABLE scripts for all dialects (<dialect>.sql -> content)
+func GenerateSQL(pkg *Package, processed []ProcessedEntity) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(sqlDialects))
+	for _, dialect := range sqlDialects {
+		ddl, err := GenerateDDL(pkg, processed, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dialect, err)
+		}
+		files[dialect+".sql"] = []byte(ddl)
+	}
+	return files, nil
+}
+
+// TableName - table of entity records: module_object_code
+func TableName(parsed map[string]any) string {
+	return strings.Join([]string{
+		GetFieldString(parsed, "module"),
+		GetFieldString(parsed, "object"),
+		GetFieldString(parsed, "code"),
+	}, "_")
+}
+
+// GenerateDDL - one table per valid entity with surrogate id key,
+// reference fields become foreign keys to id of referenced table
+func GenerateDDL(pkg *Package, processed []ProcessedEntity, dialect string) (string, error) {
+	if dialect != SQLPostgres && dialect != SQLSQLite {
+		return "", fmt.Errorf("unknown SQL dialect '%s' (available: %s)", dialect, strings.Join(sqlDialects, ", "))
+	}
+
+	entities := validEntities(processed)
+	tables := make(map[string]string, len(entities)) // entity key -> table
+	owners := make(map[string]string, len(entities)) // table -> entity key
+	for _, pe := range entities {
+		key, table := EntityKey(pe.ParsedData), TableName(pe.ParsedData)
+		if dialect == SQLPostgres && len(table) > sqlMaxIdentLen {
+			return "", fmt.Errorf("%s: table name '%s' is longer than %d bytes", key, table, sqlMaxIdentLen)
+		}
+		if other, exists := owners[table]; exists {
+			return "", fmt.Errorf("entities '%s' and '%s' map to the same table '%s'", other, key, table)
+		}
+		owners[table] = key
+		tables[key] = table
+	}
+
+	g := &ddlGenerator{dialect: dialect, tables: tables, locale: PackageLocale(pkg)}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s\n-- preset %s v%s, dialect %s\n", generatedHeader, pkg.Name, pkg.Version, dialect)
+	if dialect == SQLSQLite {
+		b.WriteString("-- foreign keys are enforced only with PRAGMA foreign_keys = ON\n")
+	}
+
+	for _, pe := range entities {
+		table, err := g.table(pe.ParsedData)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", pe.File.Path, err)
+		}
+		b.WriteString("\n")
+		b.WriteString(table)
+	}
+
+	// postgres: constraints after all tables, so order and cycles do not matter
+	if len(g.foreignKeys) > 0 {
+		b.WriteString("\n")
+		for _, fk := range g.foreignKeys {
+			b.WriteString(fk)
+		}
+	}
+
+	return b.String(), nil
+}
+
+type ddlGenerator struct {
+	dialect     string
+	tables      map[string]string
+	locale      string
+	foreignKeys []string
+}
+
+func (g *ddlGenerator) table(parsed map[string]any) (string, error) {
+	table := TableName(parsed)
+	columns := []string{g.idColumn()}
+	var comments, indexes strings.Builder
+
+	title := LocalizedText(parsed["name"], g.locale)
+	if title != "" {
+		fmt.Fprintf(&comments, "COMMENT ON TABLE %s IS %s;\n", sqlIdent(table), sqlString(title))
+	}
+
+	for _, field := range entityFields(parsed) {
+		code, typeStr := getFieldCodeAndType(field)
+		if code == "id" {
+			return "", fmt.Errorf("field id: code is reserved for primary key column")
+		}
+		if g.dialect == SQLPostgres && len(code) > sqlMaxIdentLen {
+			return "", fmt.Errorf("field %s: column name is longer than %d bytes", code, sqlMaxIdentLen)
+		}
+
+		column, err := g.column(table, field)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", code, err)
+		}
+		columns = append(columns, column)
+
+		if typeStr == string(TypeReference) {
+			ref, _ := field["ref"].(string)
+			fmt.Fprintf(&indexes, "CREATE INDEX %s ON %s (%s);\n",
+				sqlIdent(sqlName(table, code, "idx")), sqlIdent(table), sqlIdent(code))
+			if g.dialect == SQLPostgres {
+				g.foreignKeys = append(g.foreignKeys, fmt.Sprintf(
+					"ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (\"id\");\n",
+					sqlIdent(table), sqlIdent(sqlName(table, code, "fkey")), sqlIdent(code), sqlIdent(g.tables[ref])))
+			}
+		}
+
+		if name := LocalizedText(field["name"], g.locale); name != "" {
+			fmt.Fprintf(&comments, "COMMENT ON COLUMN %s.%s IS %s;\n", sqlIdent(table), sqlIdent(code), sqlString(name))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s\n", EntityKey(parsed))
+	if title != "" && g.dialect == SQLSQLite {
+		fmt.Fprintf(&b, "-- %s\n", strings.ReplaceAll(title, "\n", " "))
+	}
+	fmt.Fprintf(&b, "CREATE TABLE %s (\n    %s\n);\n", sqlIdent(table), strings.Join(columns, ",\n    "))
+	b.WriteString(indexes.String())
+	if g.dialect == SQLPostgres {
+		b.WriteString(comments.String())
+	}
+	return b.String(), nil
+}
+
+func (g *ddlGenerator) idColumn() string {
+	if g.dialect == SQLPostgres {
+		return `"id" BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY`
+	}
+	return `"id" INTEGER PRIMARY KEY`
+}
+
+// column - definition of field column: type, NOT NULL, DEFAULT, CHECK, REFERENCES
+func (g *ddlGenerator) column(table string, field map[string]any) (string, error) {
+	code, typeStr := getFieldCodeAndType(field)
+	col := sqlIdent(code)
+	required, _ := field["required"].(bool)
+
+	sqlType := g.columnType(field)
+	checks := g.checks(col, field)
+
+	parts := []string{col, sqlType}
+	if required {
+		parts = append(parts, "NOT NULL")
+	}
+	if def, exists := field["default"]; exists && def != nil {
+		literal, err := g.literal(typeStr, def)
+		if err != nil {
+			return "", fmt.Errorf("default: %w", err)
+		}
+		parts = append(parts, "DEFAULT "+literal)
+	}
+	if len(checks) > 0 {
+		parts = append(parts, fmt.Sprintf("CONSTRAINT %s CHECK (%s)",
+			sqlIdent(sqlName(table, code, "check")), strings.Join(checks, " AND ")))
+	}
+
+	if typeStr == string(TypeReference) {
+		ref, _ := field["ref"].(string)
+		target, exists := g.tables[ref]
+		if !exists {
+			return "", fmt.Errorf("referenced entity '%s' has no table", ref)
+		}
+		if g.dialect == SQLSQLite {
+			parts = append(parts, fmt.Sprintf("REFERENCES %s (\"id\")", sqlIdent(target)))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (g *ddlGenerator) columnType(field map[string]any) string {
+	_, typeStr := getFieldCodeAndType(field)
+	pg := g.dialect == SQLPostgres
+
+	switch FieldKind(typeStr) {
+	case TypeNumber:
+		if pg {
+			return "DOUBLE PRECISION"
+		}
+		return "REAL"
+	case TypeInteger:
+		if pg {
+			return "BIGINT"
+		}
+		return "INTEGER"
+	case TypeBoolean:
+		if pg {
+			return "BOOLEAN"
+		}
+		return "INTEGER"
+	case TypeDecimal:
+		if !pg {
+			// sqlite NUMERIC affinity loses precision, decimals are kept as canonical text
+			return "TEXT"
+		}
+		spec, _ := resolveDecimalSpec(field)
+		// NUMERIC(p) means scale 0, so without both limits column is unconstrained
+		if spec.Precision > 0 && spec.Scale >= 0 {
+			return fmt.Sprintf("NUMERIC(%d, %d)", spec.Precision, spec.Scale)
+		}
+		return "NUMERIC"
+	}
+	return "TEXT"
+}
+
+// checks - CHECK conditions for enum values, min/max and string length
+func (g *ddlGenerator) checks(col string, field map[string]any) []string {
+	_, typeStr := getFieldCodeAndType(field)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+	var checks []string
+
+	if typeStr == string(TypeEnum) {
+		values, _ := field["values"].([]any)
+		literals := make([]string, 0, len(values))
+		for _, v := range values {
+			s, _ := v.(string)
+			literals = append(literals, sqlString(s))
+		}
+		return []string{fmt.Sprintf("%s IN (%s)", col, strings.Join(literals, ", "))}
+	}
+
+	switch FieldKind(typeStr) {
+	case TypeNumber, TypeInteger:
+		if min != nil {
+			checks = append(checks, fmt.Sprintf("%s >= %s", col, strconv.FormatFloat(*min, 'f', -1, 64)))
+		}
+		if max != nil {
+			checks = append(checks, fmt.Sprintf("%s <= %s", col, strconv.FormatFloat(*max, 'f', -1, 64)))
+		}
+
+	case TypeBoolean:
+		if g.dialect == SQLSQLite {
+			checks = append(checks, col+" IN (0, 1)")
+		}
+
+	case TypeDecimal:
+		spec, _ := resolveDecimalSpec(field)
+		value := col
+		if g.dialect == SQLSQLite {
+			// approximate range check over text, exact one is done by runtime validator
+			value = fmt.Sprintf("CAST(%s AS REAL)", col)
+		}
+		if spec.Min != nil {
+			checks = append(checks, fmt.Sprintf("%s >= %s", value, spec.Min.Text))
+		}
+		if spec.Max != nil {
+			checks = append(checks, fmt.Sprintf("%s <= %s", value, spec.Max.Text))
+		}
+
+	case TypeString:
+		length := "length"
+		if g.dialect == SQLPostgres {
+			length = "char_length"
+		}
+		if min != nil && *min > 0 {
+			checks = append(checks, fmt.Sprintf("%s(%s) >= %d", length, col, int(*min)))
+		}
+		if max != nil {
+			checks = append(checks, fmt.Sprintf("%s(%s) <= %d", length, col, int(*max)))
+		}
+	}
+
+	return checks
+}
+
+// literal - SQL literal of default value
+func (g *ddlGenerator) literal(typeStr string, value any) (string, error) {
+	switch FieldKind(typeStr) {
+	case TypeDecimal:
+		d, err := toDecimal(value)
+		if err != nil {
+			return "", err
+		}
+		if g.dialect == SQLSQLite {
+			return sqlString(d.Text), nil
+		}
+		return d.Text, nil
+
+	case TypeNumber, TypeInteger:
+		f, ok := getFloat(map[string]any{"v": value}, "v")
+		if !ok {
+			return "", fmt.Errorf("expected number, got %T", value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+
+	case TypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected boolean, got %T", value)
+		}
+		switch {
+		case g.dialect == SQLSQLite && b:
+			return "1", nil
+		case g.dialect == SQLSQLite:
+			return "0", nil
+		}
+		return strings.ToUpper(strconv.FormatBool(b)), nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("expected string, got %T", value)
+	}
+	return sqlString(s), nil
+}
+
+// postgres truncates longer identifiers, so names differing only in the tail collide
+const sqlMaxIdentLen = 63
+
+// sqlName - name of constraint or index of table column: <table>_<column>_<suffix>;
+// a name over sqlMaxIdentLen is cut and ends with hash of the full name instead
+func sqlName(table, column, suffix string) string {
+	name := table + "_" + column + "_" + suffix
+	if len(name) <= sqlMaxIdentLen {
+		return name
+	}
+	hash := sha256Hex([]byte(name))[:8]
+	head := name[:sqlMaxIdentLen-len(suffix)-len(hash)-2]
+	for !utf8.ValidString(head) {
+		head = head[:len(head)-1]
+	}
+	return head + "_" + hash + "_" + suffix
+}
+
+func sqlIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
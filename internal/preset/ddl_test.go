@@ -0,0 +1,121 @@
+package preset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLName(t *testing.T) {
+	long := strings.Repeat("counterparty_", 4)
+	tests := []struct {
+		name         string
+		table, code  string
+		suffix, want string
+	}{
+		{"short", "crm_client_individual", "inn", "check", "crm_client_individual_inn_check"},
+		{"exactly limit", strings.Repeat("t", 52), "col", "fkey", strings.Repeat("t", 52) + "_col_fkey"},
+		{"long", long + "contract", "counterparty_id", "fkey", ""},
+		{"long multibyte", strings.Repeat("контрагент_", 4), "ссылка", "idx", ""},
+	}
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sqlName(tt.table, tt.code, tt.suffix)
+			if len(got) > sqlMaxIdentLen {
+				t.Errorf("sqlName = %q, %d bytes", got, len(got))
+			}
+			if !strings.HasSuffix(got, "_"+tt.suffix) {
+				t.Errorf("sqlName = %q, want suffix %q", got, tt.suffix)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("sqlName = %q, want %q", got, tt.want)
+			}
+			if other, exists := seen[got]; exists {
+				t.Errorf("sqlName = %q, same as of %s", got, other)
+			}
+			seen[got] = tt.name
+		})
+	}
+
+	// names differing only past the limit stay distinct
+	a := sqlName(long+"contract", "counterparty_id", "fkey")
+	b := sqlName(long+"contract", "counterparty_id2", "fkey")
+	if a == b {
+		t.Errorf("long names collide: %q", a)
+	}
+}
+
+func TestGenerateDDLReferences(t *testing.T) {
+	long := strings.Repeat("counterparty_", 3)
+	company := testEntity("crm", "client", "requisite", "company",
+		map[string]any{"code": "inn", "type": "string", "required": true, "min": 10, "max": 12})
+	contract := testEntity("crm", long+"contract", "requisite", "main",
+		map[string]any{"code": "company_id", "type": "reference", "ref": "crm.client.requisite.company"},
+		map[string]any{"code": "company_backup_id", "type": "reference", "ref": "crm.client.requisite.company"})
+	processed := []ProcessedEntity{company, contract}
+	pkg := &Package{Name: "test", Version: "1.0.0"}
+
+	ddl, err := GenerateDDL(pkg, processed, SQLPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"inn" TEXT NOT NULL CONSTRAINT "crm_client_company_inn_check" CHECK (char_length("inn") >= 10 AND char_length("inn") <= 12)`,
+		`REFERENCES "crm_client_company" ("id");`,
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("postgres DDL misses %s:\n%s", want, ddl)
+		}
+	}
+	constraints := make(map[string]bool)
+	for _, line := range strings.Split(ddl, "\n") {
+		if !strings.HasPrefix(line, "ALTER TABLE") && !strings.HasPrefix(line, "CREATE INDEX") {
+			continue
+		}
+		name := strings.Fields(strings.TrimPrefix(line, "ALTER TABLE "))[3]
+		if strings.HasPrefix(line, "CREATE INDEX") {
+			name = strings.Fields(line)[2]
+		}
+		name = strings.Trim(name, `"`)
+		if len(name) > sqlMaxIdentLen || constraints[name] {
+			t.Errorf("constraint name %q: too long or repeated", name)
+		}
+		constraints[name] = true
+	}
+	if len(constraints) != 4 {
+		t.Errorf("got %d foreign keys and indexes, want 4:\n%s", len(constraints), ddl)
+	}
+
+	ddl, err = GenerateDDL(pkg, processed, SQLSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ddl, `"company_id" INTEGER REFERENCES "crm_client_company" ("id")`) {
+		t.Errorf("sqlite DDL misses inline foreign key:\n%s", ddl)
+	}
+
+	tooLong := testEntity("crm", strings.Repeat("x", 60), "requisite", "main",
+		map[string]any{"code": "inn", "type": "string"})
+	if _, err := GenerateDDL(pkg, []ProcessedEntity{tooLong}, SQLPostgres); err == nil ||
+		!strings.Contains(err.Error(), "is longer than 63 bytes") {
+		t.Errorf("error = %v, want table name length error", err)
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	company := testEntity("crm", "client", "requisite", "company",
+		map[string]any{"code": "inn", "type": "string"})
+	contract := testEntity("crm", "contract", "requisite", "main",
+		map[string]any{"code": "company_id", "type": "reference", "ref": "crm.client.requisite.company"},
+		map[string]any{"code": "bank_id", "type": "reference", "ref": "crm.bank.requisite.main"})
+	processed := []ProcessedEntity{company, contract}
+
+	checkReferences(processed)
+	if len(processed[0].Errors) != 0 || processed[0].Schema == nil {
+		t.Errorf("referenced entity got errors %v", processed[0].Errors)
+	}
+	want := []string{"field bank_id: ref 'crm.bank.requisite.main' not found in package"}
+	if got := processed[1].Errors; len(got) != 1 || got[0] != want[0] || processed[1].Schema != nil {
+		t.Errorf("errors = %v, schema %v; want %v and no schema", got, processed[1].Schema, want)
+	}
+}
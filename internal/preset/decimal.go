@@ -0,0 +1,98 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Decimal - exact decimal value, never goes through float64 arithmetic
+type Decimal struct {
+	Text   string   // canonical text: optional '-', digits, optional '.' + digits
+	Rat    *big.Rat // exact value for comparisons
+	Scale  int      // digits after the point, trailing zeros ignored
+	Digits int      // significant digits: integer part without leading zeros + scale
+}
+
+var decimalRegex = regexp.MustCompile(`^([+-])?([0-9]+)(?:\.([0-9]+))?$`)
+
+// ParseDecimal - parse plain decimal notation (no exponent), no surrounding spaces
+// as in the schema pattern
+func ParseDecimal(s string) (Decimal, error) {
+	m := decimalRegex.FindStringSubmatch(s)
+	if m == nil {
+		return Decimal{}, fmt.Errorf("invalid decimal '%s'", s)
+	}
+
+	sign, intPart, fracPart := m[1], strings.TrimLeft(m[2], "0"), strings.TrimRight(m[3], "0")
+
+	text := intPart
+	if text == "" {
+		text = "0"
+	}
+	if fracPart != "" {
+		text += "." + fracPart
+	}
+	if sign == "-" && text != "0" {
+		text = "-" + text
+	}
+
+	rat, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal '%s'", s)
+	}
+
+	return Decimal{
+		Text:   text,
+		Rat:    rat,
+		Scale:  len(fracPart),
+		Digits: len(intPart) + len(fracPart),
+	}, nil
+}
+
+// toDecimal - decimal from raw YAML/JSON value: string, json.Number or number
+func toDecimal(value any) (Decimal, error) {
+	switch v := value.(type) {
+	case string:
+		return ParseDecimal(v)
+	case json.Number:
+		return ParseDecimal(v.String())
+	case float64:
+		// shortest representation that round-trips, i.e. the literal as written
+		return ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case int:
+		return ParseDecimal(strconv.Itoa(v))
+	case int64:
+		return ParseDecimal(strconv.FormatInt(v, 10))
+	}
+	return Decimal{}, fmt.Errorf("expected decimal string or number, got %T", value)
+}
+
+// decimalParam - optional decimal key of field definition (min, max, default)
+func decimalParam(field map[string]any, key string) (*Decimal, error) {
+	raw, exists := field[key]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	d, err := toDecimal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	return &d, nil
+}
+
+// intParam - optional non-negative integer key of field definition (precision, scale)
+func intParam(field map[string]any, key string) (int, bool, error) {
+	raw, exists := field[key]
+	if !exists {
+		return 0, false, nil
+	}
+	f, ok := raw.(float64)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, true, fmt.Errorf("%s must be non-negative integer", key)
+	}
+	return int(f), true, nil
+}
@@ -0,0 +1,450 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// docCatalog - package and its entities grouped by module -> object -> property
+type docCatalog struct {
+	Name        string
+	Version     string
+	Description string
+	Tags        []string
+	Modules     []*docGroup
+	Entities    []*docEntity
+}
+
+// docGroup - module, object or property level of index
+type docGroup struct {
+	Code     string
+	Groups   []*docGroup  // objects of module, properties of object
+	Entities []*docEntity // entities of property
+}
+
+type docEntity struct {
+	Key         string
+	Code        string
+	Title       string
+	Description string
+	Path        string // relative to catalog root, without extension
+	Fields      []docField
+}
+
+type docField struct {
+	Code        string
+	Name        string
+	Description string
+	Type        string
+	Details     []string // enum values, format, validator, precision
+	Required    bool
+	Pattern     string
+	Range       string
+	Default     string
+	Examples    []string
+	Ref         *docEntity
+	RefKey      string
+}
+
+// GenerateMarkdownDocs - README.md with index and page per entity
+func GenerateMarkdownDocs(pkg *Package, processed []ProcessedEntity) (map[string][]byte, error) {
+	catalog := buildDocCatalog(pkg, processed)
+	files := make(map[string][]byte, len(catalog.Entities)+1)
+
+	files["README.md"] = []byte(markdownIndex(catalog))
+	for _, e := range catalog.Entities {
+		files[e.Path+".md"] = []byte(markdownEntity(catalog, e))
+	}
+	return files, nil
+}
+
+// GenerateHTMLDocs - static site: index.html and page per entity
+func GenerateHTMLDocs(pkg *Package, processed []ProcessedEntity) (map[string][]byte, error) {
+	catalog := buildDocCatalog(pkg, processed)
+	files := make(map[string][]byte, len(catalog.Entities)+1)
+
+	var buf bytes.Buffer
+	if err := htmlDocs.ExecuteTemplate(&buf, "index", map[string]any{"Catalog": catalog}); err != nil {
+		return nil, fmt.Errorf("render index: %w", err)
+	}
+	files["index.html"] = bytes.Clone(buf.Bytes())
+
+	for _, e := range catalog.Entities {
+		buf.Reset()
+		data := map[string]any{"Catalog": catalog, "Entity": e}
+		if err := htmlDocs.ExecuteTemplate(&buf, "entity", data); err != nil {
+			return nil, fmt.Errorf("render %s: %w", e.Key, err)
+		}
+		files[e.Path+".html"] = bytes.Clone(buf.Bytes())
+	}
+	return files, nil
+}
+
+func buildDocCatalog(pkg *Package, processed []ProcessedEntity) *docCatalog {
+	locale := PackageLocale(pkg)
+	catalog := &docCatalog{
+		Name:        pkg.Name,
+		Version:     pkg.Version,
+		Description: pkg.Description,
+		Tags:        pkg.Tags,
+	}
+
+	entities := validEntities(processed)
+	byKey := make(map[string]*docEntity, len(entities))
+	for _, pe := range entities {
+		e := &docEntity{
+			Key:         EntityKey(pe.ParsedData),
+			Code:        GetFieldString(pe.ParsedData, "code"),
+			Title:       LocalizedText(pe.ParsedData["name"], locale),
+			Description: LocalizedText(pe.ParsedData["description"], locale),
+			Path: path.Join(pathSegment(GetFieldString(pe.ParsedData, "module")),
+				strings.TrimSuffix(goFileName(pe.ParsedData), ".gen.go")),
+		}
+		byKey[e.Key] = e
+		catalog.Entities = append(catalog.Entities, e)
+	}
+
+	for i, pe := range entities {
+		for _, field := range entityFields(pe.ParsedData) {
+			f := docFieldOf(field, locale)
+			if f.RefKey != "" {
+				f.Ref = byKey[f.RefKey]
+			}
+			catalog.Entities[i].Fields = append(catalog.Entities[i].Fields, f)
+		}
+	}
+
+	// index: module -> object -> property -> entities, entities are sorted by key already
+	index := func(groups *[]*docGroup, code string) *docGroup {
+		for _, g := range *groups {
+			if g.Code == code {
+				return g
+			}
+		}
+		g := &docGroup{Code: code}
+		*groups = append(*groups, g)
+		return g
+	}
+	for i, pe := range entities {
+		module := index(&catalog.Modules, GetFieldString(pe.ParsedData, "module"))
+		object := index(&module.Groups, GetFieldString(pe.ParsedData, "object"))
+		property := index(&object.Groups, GetFieldString(pe.ParsedData, "property"))
+		property.Entities = append(property.Entities, catalog.Entities[i])
+	}
+
+	return catalog
+}
+
+func docFieldOf(field map[string]any, locale string) docField {
+	code, typeStr := getFieldCodeAndType(field)
+	required, _ := field["required"].(bool)
+	f := docField{
+		Code:        code,
+		Name:        LocalizedText(field["name"], locale),
+		Description: LocalizedText(field["description"], locale),
+		Type:        typeStr,
+		Required:    required,
+		Range:       docRange(field),
+	}
+
+	if pattern, ok := field["pattern"].(string); ok {
+		f.Pattern = pattern
+	}
+	if def, exists := field["default"]; exists && def != nil {
+		f.Default = docValue(def)
+	}
+	if examples, ok := field["examples"].([]any); ok {
+		for _, ex := range examples {
+			f.Examples = append(f.Examples, docValue(ex))
+		}
+	}
+
+	if values, ok := field["values"].([]any); ok && typeStr == string(TypeEnum) {
+		list := make([]string, 0, len(values))
+		for _, v := range values {
+			s, _ := v.(string)
+			list = append(list, strconv.Quote(s))
+		}
+		f.Details = append(f.Details, "values: "+strings.Join(list, ", "))
+	}
+	for _, key := range []string{"format", "phone_region", "validator", "currency", "currency_field", "encoding"} {
+		if v, ok := field[key].(string); ok && v != "" {
+			f.Details = append(f.Details, key+": "+v)
+		}
+	}
+	if FieldKind(typeStr) == TypeDecimal {
+		spec, _ := resolveDecimalSpec(field)
+		if spec.Precision > 0 {
+			f.Details = append(f.Details, fmt.Sprintf("precision: %d", spec.Precision))
+		}
+		if spec.Scale >= 0 {
+			f.Details = append(f.Details, fmt.Sprintf("scale: %d", spec.Scale))
+		}
+	}
+	if typeStr == string(TypeReference) {
+		f.RefKey, _ = field["ref"].(string)
+	}
+
+	return f
+}
+
+// docRange - min/max of value, length for strings
+func docRange(field map[string]any) string {
+	_, typeStr := getFieldCodeAndType(field)
+	var min, max string
+
+	if FieldKind(typeStr) == TypeDecimal {
+		spec, _ := resolveDecimalSpec(field)
+		if spec.Min != nil {
+			min = spec.Min.Text
+		}
+		if spec.Max != nil {
+			max = spec.Max.Text
+		}
+	} else {
+		if v := getNumberValue(field, "min"); v != nil {
+			min = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+		if v := getNumberValue(field, "max"); v != nil {
+			max = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+
+	var r string
+	switch {
+	case min != "" && max != "":
+		r = min + " … " + max
+	case min != "":
+		r = "≥ " + min
+	case max != "":
+		r = "≤ " + max
+	default:
+		return ""
+	}
+	if FieldKind(typeStr) == TypeString && typeStr != string(TypeEnum) {
+		r = "length " + r
+	}
+	return r
+}
+
+// docValue - YAML value as shown in docs: strings as is, others as JSON
+func docValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// relativeLink - link from page at 'from' to page at 'to' (both relative to root)
+func relativeLink(from, to string) string {
+	depth := strings.Count(from, "/")
+	return strings.Repeat("../", depth) + to
+}
+
+// --- markdown
+
+func markdownIndex(c *docCatalog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nVersion: `%s`\n", mdText(c.Name), c.Version)
+	if c.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", c.Description)
+	}
+	if len(c.Tags) > 0 {
+		tags := make([]string, len(c.Tags))
+		for i, tag := range c.Tags {
+			tags[i] = mdCode(tag)
+		}
+		fmt.Fprintf(&b, "\nTags: %s\n", strings.Join(tags, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n## Entities\n")
+	for _, module := range c.Modules {
+		fmt.Fprintf(&b, "\n### %s\n\n", mdText(module.Code))
+		for _, object := range module.Groups {
+			fmt.Fprintf(&b, "- **%s**\n", mdText(object.Code))
+			for _, property := range object.Groups {
+				fmt.Fprintf(&b, "  - %s\n", mdText(property.Code))
+				for _, e := range property.Entities {
+					fmt.Fprintf(&b, "    - [%s](%s.md) — %s\n", mdText(e.Code), e.Path, mdText(e.Title))
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+func markdownEntity(c *docCatalog, e *docEntity) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", mdText(e.Title))
+	fmt.Fprintf(&b, "[%s v%s](%s) / %s\n\n", mdText(c.Name), c.Version, relativeLink(e.Path, "README.md"), mdCode(e.Key))
+	if e.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", e.Description)
+	}
+
+	b.WriteString("| Code | Name | Type | Required | Pattern | Range | Default | Examples |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, f := range e.Fields {
+		name := mdCell(f.Name)
+		if f.Description != "" {
+			name += "<br>" + mdCell(f.Description)
+		}
+
+		typ := mdCode(f.Type)
+		switch {
+		case f.Ref != nil:
+			typ += fmt.Sprintf(" → [%s](%s.md)", mdCell(f.Ref.Key), relativeLink(e.Path, f.Ref.Path))
+		case f.RefKey != "":
+			typ += " → " + mdCode(f.RefKey)
+		}
+		for _, detail := range f.Details {
+			typ += "<br>" + mdCell(detail)
+		}
+
+		required := ""
+		if f.Required {
+			required = "yes"
+		}
+		pattern := ""
+		if f.Pattern != "" {
+			pattern = mdCode(f.Pattern)
+		}
+		defaultValue := ""
+		if f.Default != "" {
+			defaultValue = mdCode(f.Default)
+		}
+		examples := make([]string, len(f.Examples))
+		for i, ex := range f.Examples {
+			examples[i] = mdCode(ex)
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			mdCode(f.Code), name, typ, required, pattern, mdCell(f.Range), defaultValue, strings.Join(examples, " "))
+	}
+	return b.String()
+}
+
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", "`", "\\`", "|", `\|`)
+
+// mdText - plain text with markdown syntax escaped
+func mdText(s string) string {
+	return mdEscaper.Replace(s)
+}
+
+// mdCell - text inside table cell, one line
+func mdCell(s string) string {
+	return strings.ReplaceAll(mdText(s), "\n", "<br>")
+}
+
+// mdCode - code span usable inside table cell
+func mdCode(s string) string {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\n", " "), "|", `\|`)
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// --- html
+
+var htmlDocs = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"link": relativeLink,
+}).Parse(htmlDocsTemplate))
+
+const htmlDocsTemplate = `
+{{- define "head" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; max-width: 1200px; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+code { background: #f5f5f5; padding: 1px 4px; border-radius: 3px; word-break: break-all; }
+.muted { color: #777; }
+ul { line-height: 1.6; }
+</style>
+</head>
+<body>
+{{- end -}}
+
+{{- define "index" -}}
+{{template "head" .Catalog.Name}}
+<h1>{{.Catalog.Name}}</h1>
+<p>Version: <code>{{.Catalog.Version}}</code></p>
+{{- with .Catalog.Description}}
+<p>{{.}}</p>
+{{- end}}
+{{- with .Catalog.Tags}}
+<p>Tags: {{range $i, $t := .}}{{if $i}}, {{end}}<code>{{$t}}</code>{{end}}</p>
+{{- end}}
+<h2>Entities</h2>
+{{- range .Catalog.Modules}}
+<h3 id="{{.Code}}">{{.Code}}</h3>
+<ul>
+{{- range .Groups}}
+<li><strong>{{.Code}}</strong>
+<ul>
+{{- range .Groups}}
+<li>{{.Code}}
+<ul>
+{{- range .Entities}}
+<li><a href="{{.Path}}.html">{{.Code}}</a> — {{.Title}}</li>
+{{- end}}
+</ul>
+</li>
+{{- end}}
+</ul>
+</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+{{end -}}
+
+{{- define "entity" -}}
+{{- $e := .Entity -}}
+{{template "head" $e.Title}}
+<p class="muted"><a href="{{link $e.Path "index.html"}}">{{.Catalog.Name}} v{{.Catalog.Version}}</a> / <code>{{$e.Key}}</code></p>
+<h1>{{$e.Title}}</h1>
+{{- with $e.Description}}
+<p>{{.}}</p>
+{{- end}}
+<table>
+<tr><th>Code</th><th>Name</th><th>Type</th><th>Required</th><th>Pattern</th><th>Range</th><th>Default</th><th>Examples</th></tr>
+{{- range $e.Fields}}
+<tr id="{{.Code}}">
+<td><code>{{.Code}}</code></td>
+<td>{{.Name}}{{with .Description}}<br><span class="muted">{{.}}</span>{{end}}</td>
+<td><code>{{.Type}}</code>
+{{- if .Ref}} → <a href="{{link $e.Path .Ref.Path}}.html">{{.Ref.Key}}</a>{{else if .RefKey}} → <code>{{.RefKey}}</code>{{end}}
+{{- range .Details}}<br><span class="muted">{{.}}</span>{{end}}</td>
+<td>{{if .Required}}yes{{end}}</td>
+<td>{{with .Pattern}}<code>{{.}}</code>{{end}}</td>
+<td>{{.Range}}</td>
+<td>{{with .Default}}<code>{{.}}</code>{{end}}</td>
+<td>{{range .Examples}}<code>{{.}}</code> {{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+{{end -}}
+`
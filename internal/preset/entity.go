@@ -7,7 +7,7 @@ import (
 	"github.com/ghodss/yaml"
 )
 
-func ProcessEntity(file EntityFile, data []byte) ProcessedEntity {
+func ProcessEntity(file EntityFile, data []byte, pkg *Package) ProcessedEntity {
 	result := ProcessedEntity{File: file}
 
 	// xxHash64 вместо CRC32
@@ -30,18 +30,34 @@ func ProcessEntity(file EntityFile, data []byte) ProcessedEntity {
 	result.ParsedData = parsed
 
 	// validation round 2
-	if errs := validateStructure(parsed); len(errs) > 0 {
+	if errs := validateStructure(parsed, buildConfig(pkg).Limits); len(errs) > 0 {
 		result.Errors = append(result.Errors, errs...)
 	}
 
+	region, locale := "", PackageLocale(pkg)
+	var locales []string
+	if pkg != nil {
+		region, locales = pkg.Region, pkg.Locales
+	}
+	result.Locale = locale
+
 	// validation round 3
-	if errs := validateFieldsDirectly(parsed); len(errs) > 0 {
+	if errs := validateFieldsDirectly(parsed, region); len(errs) > 0 {
+		result.Errors = append(result.Errors, errs...)
+	}
+
+	// validation round 4
+	if errs := validateTranslations(parsed, locales); len(errs) > 0 {
 		result.Errors = append(result.Errors, errs...)
 	}
 
 	// generate schema
 	if result.FatalError == nil && len(result.Errors) == 0 {
-		schema, err := GenerateJSONSchema(result.ParsedData)
+		schema, err := GenerateJSONSchema(result.ParsedData, SchemaOptions{
+			Region:   region,
+			Locale:   locale,
+			Annotate: true,
+		})
 		if err != nil {
 			result.Errors = append(result.Errors,
 				fmt.Sprintf("JSON Schema generation failed: %v", err))
@@ -54,11 +70,10 @@ func ProcessEntity(file EntityFile, data []byte) ProcessedEntity {
 }
 
 // structure validation
-func validateStructure(data map[string]any) []string {
+func validateStructure(data map[string]any, limits BuildLimits) []string {
 	var errors []string
 
-	required := []string{"module", "object", "property", "code", "name", "fields"}
-	for _, field := range required {
+	for _, field := range limits.RequiredKeys {
 		if _, exists := data[field]; !exists {
 			errors = append(errors, fmt.Sprintf("missing: %s", field))
 		}
@@ -68,13 +83,15 @@ func validateStructure(data map[string]any) []string {
 		errors = append(errors, "fields must be array")
 	} else if len(fields) == 0 {
 		errors = append(errors, "fields array empty")
+	} else if limits.MaxFields > 0 && len(fields) > limits.MaxFields {
+		errors = append(errors, fmt.Sprintf("fields: %d fields, limit is %d", len(fields), limits.MaxFields))
 	}
 
 	return errors
 }
 
 // fields validation
-func validateFieldsDirectly(data map[string]any) []string {
+func validateFieldsDirectly(data map[string]any, region string) []string {
 	var errors []string
 
 	fields, ok := data["fields"].([]any)
@@ -83,6 +100,7 @@ func validateFieldsDirectly(data map[string]any) []string {
 	}
 
 	seenCodes := make(map[string]bool)
+	byCode := fieldsByCode(fields)
 
 	for i, fieldAny := range fields {
 		field, ok := fieldAny.(map[string]any)
@@ -107,29 +125,18 @@ func validateFieldsDirectly(data map[string]any) []string {
 			continue
 		}
 
-		if pattern, ok := field["pattern"].(string); ok && pattern != "" {
-			if valid, errMsg := validatePattern(pattern); !valid {
-				errors = append(errors, fmt.Sprintf("field %s: %s", code, errMsg))
+		if def, _ := LookupType(typeStr); def.CheckDefinition != nil {
+			for _, err := range def.CheckDefinition(field, byCode) {
+				errors = append(errors, fmt.Sprintf("field %s: %s", code, err))
 			}
 		}
 
-		if min := getNumberValue(field, "min"); min != nil {
-			max := getNumberValue(field, "max")
-			if minMaxErrs := validateMinMax(min, max, typeStr); len(minMaxErrs) > 0 {
-				for _, err := range minMaxErrs {
-					errors = append(errors, fmt.Sprintf("field %s: %s", code, err))
-				}
-			}
+		for _, err := range validateFieldFormat(field) {
+			errors = append(errors, fmt.Sprintf("field %s: %s", code, err))
 		}
 
-		if typeStr == "enum" {
-			if values, ok := field["values"].([]any); ok {
-				if valid, errMsg := validateEnumValues(values); !valid {
-					errors = append(errors, fmt.Sprintf("field %s: %s", code, errMsg))
-				}
-			} else {
-				errors = append(errors, fmt.Sprintf("field %s: enum requires values array", code))
-			}
+		for _, err := range validateFieldValidator(field, byCode, region) {
+			errors = append(errors, fmt.Sprintf("field %s: %s", code, err))
 		}
 	}
 
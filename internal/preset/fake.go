@@ -0,0 +1,647 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FakeOptions - sample records generation
+type FakeOptions struct {
+	Count  int
+	Seed   int64
+	Region string
+	// every record breaks exactly one rule of the entity, for negative tests
+	Invalid bool
+	// FormatJSONL or FormatCSV: invalid records stay invalid after encoding and reading
+	// back in the format; "" - in-memory records
+	Format string
+}
+
+// attempts to get a value (or invalid record) passing the checks
+const fakeAttempts = 100
+
+// FakeRecords - sample records of entity; valid records pass ValidateRecord,
+// invalid ones fail it. Same seed gives same records
+func FakeRecords(entity map[string]any, opts FakeOptions) ([]map[string]any, error) {
+	r := rand.New(rand.NewSource(opts.Seed))
+	fields := fakeFieldOrder(entityFields(entity))
+
+	records := make([]map[string]any, 0, opts.Count)
+	for i := 0; i < opts.Count; i++ {
+		record, err := fakeRecord(r, entity, fields, opts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Invalid {
+			if record, err = fakeInvalid(r, entity, record, opts); err != nil {
+				return nil, err
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// fakeFieldOrder - fields depending on other fields (bik_field, currency_field) go last
+func fakeFieldOrder(fields []map[string]any) []map[string]any {
+	ordered := append([]map[string]any(nil), fields...)
+	dependent := func(field map[string]any) bool {
+		for key := range field {
+			if strings.HasSuffix(key, "_field") {
+				return true
+			}
+		}
+		return false
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !dependent(ordered[i]) && dependent(ordered[j])
+	})
+	return ordered
+}
+
+// fakeCurrencies - sample currencies of string currency_field targets
+var fakeCurrencies = []string{"RUB", "USD", "EUR", "CNY", "KZT"}
+
+func fakeRecord(r *rand.Rand, entity map[string]any, fields []map[string]any, opts FakeOptions) (map[string]any, error) {
+	currencyFields := make(map[string]bool)
+	for _, field := range fields {
+		if code, ok := field["currency_field"].(string); ok {
+			currencyFields[code] = true
+		}
+	}
+
+	for attempt := 0; attempt < fakeAttempts; attempt++ {
+		record := make(map[string]any, len(fields))
+		for _, field := range fields {
+			code, _ := getFieldCodeAndType(field)
+			if required, _ := field["required"].(bool); !required && r.Intn(10) < 3 {
+				continue
+			}
+
+			value, err := fakeFieldValue(r, field, record, opts)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", code, err)
+			}
+			if s, ok := value.(string); ok && currencyFields[code] && !currencyRegex.MatchString(s) {
+				value = fakeCurrencies[r.Intn(len(fakeCurrencies))]
+			}
+			record[code] = value
+		}
+
+		if len(ValidateRecord(entity, record, opts.Region)) == 0 {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot generate valid record of %s", EntityKey(entity))
+}
+
+// fakeFieldValue - value passing field checks: example, enum value, validator example,
+// format sample, pattern or random value of type kind
+func fakeFieldValue(r *rand.Rand, field, record map[string]any, opts FakeOptions) (any, error) {
+	valid := func(value any) bool {
+		return value != nil && len(validateValue(field, value, record, opts.Region)) == 0
+	}
+
+	if examples, ok := field["examples"].([]any); ok && len(examples) > 0 {
+		if value := examples[r.Intn(len(examples))]; valid(value) {
+			return value, nil
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < fakeAttempts; attempt++ {
+		value, err := fakeRandomValue(r, field, record, opts)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if valid(value) {
+			return value, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("cannot generate valid value, add examples")
+}
+
+func fakeRandomValue(r *rand.Rand, field, record map[string]any, opts FakeOptions) (any, error) {
+	_, typeStr := getFieldCodeAndType(field)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+
+	switch typeStr {
+	case string(TypeEnum):
+		values, _ := field["values"].([]any)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("enum has no values")
+		}
+		return values[r.Intn(len(values))], nil
+	case string(TypeReference):
+		// ids of records generated in the same run
+		return int64(1 + r.Intn(max1(opts.Count))), nil
+	}
+
+	switch FieldKind(typeStr) {
+	case TypeBoolean:
+		return r.Intn(2) == 1, nil
+
+	case TypeInteger:
+		lo, hi := fakeBounds(min, max)
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+		if hi < lo {
+			return nil, fmt.Errorf("empty integer range")
+		}
+		return int64(lo) + r.Int63n(int64(hi-lo)+1), nil
+
+	case TypeNumber:
+		lo, hi := fakeBounds(min, max)
+		if multiple := getMultipleOf(field); multiple != nil && *multiple > 0 {
+			from, to := math.Ceil(lo / *multiple), math.Floor(hi / *multiple)
+			if to < from {
+				return nil, fmt.Errorf("no multiple of %v in range", *multiple)
+			}
+			return (from + float64(r.Int63n(int64(to-from)+1))) * *multiple, nil
+		}
+		value := math.Round((lo+r.Float64()*(hi-lo))*100) / 100
+		return math.Min(math.Max(value, lo), hi), nil
+
+	case TypeDecimal:
+		return fakeDecimal(r, field)
+	}
+
+	// string kind
+	if name, ok := field["validator"].(string); ok && name != "" {
+		if v, found := LookupValidator(opts.Region, name); found && v.Example != nil {
+			return v.Example(r, field, record), nil
+		}
+	}
+	if name, ok := field["format"].(string); ok && name != "" && fakeFormats[name] != nil {
+		return fakeFormats[name](r, fieldFormatRegion(field, opts.Region)), nil
+	}
+	if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+		return fakeFromPattern(r, pattern)
+	}
+
+	lo, hi := 3, 12
+	if min != nil {
+		lo = int(*min)
+		if hi < lo {
+			hi = lo + 8
+		}
+	}
+	if max != nil {
+		hi = int(*max)
+		if lo > hi {
+			lo = hi
+		}
+	}
+	// keep samples readable when max is large
+	if hi > lo+20 {
+		hi = lo + 20
+	}
+	return fakeWord(r, lo+r.Intn(hi-lo+1)), nil
+}
+
+// fakeBounds - range of sample numbers, 0..1000 by default
+func fakeBounds(min, max *float64) (float64, float64) {
+	switch {
+	case min != nil && max != nil:
+		return *min, *max
+	case min != nil:
+		return *min, *min + 1000
+	case max != nil:
+		return math.Min(0, *max-1000), *max
+	}
+	return 0, 1000
+}
+
+func max1(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// fakeDecimal - exact decimal with field scale inside spec range
+func fakeDecimal(r *rand.Rand, field map[string]any) (any, error) {
+	spec, errs := resolveDecimalSpec(field)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	scale := spec.Scale
+	if scale < 0 {
+		scale = 2
+	}
+	unit := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+
+	lo, hi := big.NewRat(0, 1), big.NewRat(1000, 1)
+	switch {
+	case spec.Min != nil && spec.Max != nil:
+		lo, hi = spec.Min.Rat, spec.Max.Rat
+	case spec.Min != nil:
+		lo, hi = spec.Min.Rat, new(big.Rat).Add(spec.Min.Rat, big.NewRat(1000, 1))
+	case spec.Max != nil:
+		hi = spec.Max.Rat
+		if hi.Sign() < 0 {
+			lo = new(big.Rat).Sub(hi, big.NewRat(1000, 1))
+		}
+	}
+	if spec.Precision > 0 {
+		// |value| < 10^(precision-scale)
+		limit := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(spec.Precision-scale)), nil))
+		limit.Sub(limit, unit)
+		if hi.Cmp(limit) > 0 {
+			hi = limit
+		}
+	}
+
+	// value = units * 10^-scale, units in [ceil(lo/unit), floor(hi/unit)]
+	from := ratCeil(new(big.Rat).Quo(lo, unit))
+	to := ratFloor(new(big.Rat).Quo(hi, unit))
+	span := new(big.Int).Sub(to, from)
+	if span.Sign() < 0 {
+		return nil, fmt.Errorf("empty decimal range")
+	}
+	units := new(big.Int).Add(from, new(big.Int).Rand(r, span.Add(span, big.NewInt(1))))
+
+	text := new(big.Rat).Mul(new(big.Rat).SetInt(units), unit).FloatString(scale)
+	if encoding, _ := field["encoding"].(string); encoding == DecimalAsNumber {
+		return json.Number(text), nil
+	}
+	return text, nil
+}
+
+// ratFloor - Euclidean division by positive denominator is floor
+func ratFloor(x *big.Rat) *big.Int {
+	return new(big.Int).Div(x.Num(), x.Denom())
+}
+
+func ratCeil(x *big.Rat) *big.Int {
+	q := ratFloor(x)
+	if !x.IsInt() {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+const fakeLetters = "abcdefghijklmnopqrstuvwxyz"
+
+func fakeWord(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = fakeLetters[r.Intn(len(fakeLetters))]
+	}
+	return string(b)
+}
+
+// fakeFormats - sample values of string formats
+var fakeFormats = map[string]func(r *rand.Rand, region string) string{
+	"email": func(r *rand.Rand, _ string) string {
+		return fakeWord(r, 5+r.Intn(5)) + "@" + fakeWord(r, 4+r.Intn(6)) + ".example"
+	},
+	"uri": fakeURL,
+	"url": fakeURL,
+	"uuid": func(r *rand.Rand, _ string) string {
+		b := make([]byte, 16)
+		r.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	},
+	"ipv4": func(r *rand.Rand, _ string) string {
+		return fmt.Sprintf("10.%d.%d.%d", r.Intn(256), r.Intn(256), 1+r.Intn(254))
+	},
+	"ipv6": func(r *rand.Rand, _ string) string {
+		return fmt.Sprintf("2001:db8::%x:%x", r.Intn(0x10000), r.Intn(0x10000))
+	},
+	"hostname": func(r *rand.Rand, _ string) string {
+		return fakeWord(r, 4+r.Intn(6)) + ".example"
+	},
+	"date": func(r *rand.Rand, _ string) string {
+		return fmt.Sprintf("%d-%02d-%02d", 1995+r.Intn(30), 1+r.Intn(12), 1+r.Intn(28))
+	},
+	"phone": func(r *rand.Rand, region string) string {
+		p, ok := phoneRegions[region]
+		if !ok || p.NSNLength == 0 {
+			p = phoneRegion{CountryCode: "7", NSNLength: 10}
+		}
+		digits := strconv.Itoa(1+r.Intn(9)) + fmt.Sprintf("%0*d", p.NSNLength-1, r.Int63n(int64(math.Pow10(p.NSNLength-1))))
+		return "+" + p.CountryCode + digits
+	},
+}
+
+func fakeURL(r *rand.Rand, _ string) string {
+	return "https://" + fakeWord(r, 4+r.Intn(6)) + ".example/" + fakeWord(r, 3+r.Intn(6))
+}
+
+// --- generation from regular expression syntax tree
+
+// unbounded repetitions (*, +, {n,}) add at most this many items
+const fakeMaxRepeat = 4
+
+func fakeFromPattern(r *rand.Rand, pattern string) (string, error) {
+	re, err := syntax.Parse(normalizePattern(pattern), syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("pattern: %w", err)
+	}
+	var b strings.Builder
+	fakeRegexp(r, re.Simplify(), &b)
+	return b.String(), nil
+}
+
+func fakeRegexp(r *rand.Rand, re *syntax.Regexp, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, c := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && r.Intn(2) == 0 {
+				c = []rune(strings.ToUpper(string(c)))[0]
+			}
+			b.WriteRune(c)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(fakeClassRune(r, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		b.WriteByte(fakeLetters[r.Intn(len(fakeLetters))])
+	case syntax.OpCapture:
+		fakeRegexp(r, re.Sub[0], b)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			fakeRegexp(r, sub, b)
+		}
+	case syntax.OpAlternate:
+		fakeRegexp(r, re.Sub[r.Intn(len(re.Sub))], b)
+	case syntax.OpQuest:
+		if r.Intn(2) == 0 {
+			fakeRegexp(r, re.Sub[0], b)
+		}
+	case syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		min, max := 0, re.Max
+		switch re.Op {
+		case syntax.OpPlus:
+			min = 1
+		case syntax.OpRepeat:
+			min = re.Min
+		}
+		if re.Op != syntax.OpRepeat || max < 0 {
+			max = min + fakeMaxRepeat
+		}
+		for n := min + r.Intn(max-min+1); n > 0; n-- {
+			fakeRegexp(r, re.Sub[0], b)
+		}
+	}
+	// anchors, word boundaries and empty match produce nothing
+}
+
+// fakeClassRune - rune of character class, printable ASCII is preferred for wide classes
+func fakeClassRune(r *rand.Rand, ranges []rune) rune {
+	pick := func(ranges []rune) rune {
+		total := 0
+		for i := 0; i < len(ranges); i += 2 {
+			total += int(ranges[i+1]-ranges[i]) + 1
+		}
+		n := r.Intn(total)
+		for i := 0; i < len(ranges); i += 2 {
+			size := int(ranges[i+1]-ranges[i]) + 1
+			if n < size {
+				return ranges[i] + rune(n)
+			}
+			n -= size
+		}
+		return ranges[0]
+	}
+
+	// negated classes like [^"] cover all of unicode
+	if last := ranges[len(ranges)-1]; last == utf8.MaxRune {
+		var ascii []rune
+		for i := 0; i < len(ranges); i += 2 {
+			lo, hi := max(ranges[i], 'a'), min(ranges[i+1], 'z')
+			if lo <= hi {
+				ascii = append(ascii, lo, hi)
+			}
+		}
+		if len(ascii) > 0 {
+			return pick(ascii)
+		}
+	}
+	return pick(ranges)
+}
+
+// --- invalid records
+
+// fakeInvalid - break one rule of valid record: required, type, enum, range, length,
+// pattern, validator or unknown key. A break counts when the record read back in
+// opts.Format fails validation
+func fakeInvalid(r *rand.Rand, entity, record map[string]any, opts FakeOptions) (map[string]any, error) {
+	fields := entityFields(entity)
+
+	for attempt := 0; attempt < fakeAttempts; attempt++ {
+		broken := make(map[string]any, len(record)+1)
+		for k, v := range record {
+			broken[k] = v
+		}
+
+		field := fields[r.Intn(len(fields))]
+		if !fakeBreak(r, field, broken, opts.Format) {
+			continue
+		}
+		invalid, err := fakeFails(entity, broken, opts)
+		if err != nil {
+			return nil, err
+		}
+		if invalid {
+			return broken, nil
+		}
+	}
+
+	// unknown key is always an error, but CSV reading ignores unknown columns
+	if opts.Format == FormatCSV {
+		return nil, fmt.Errorf("cannot break record of %s in %s, no rule survives the format", EntityKey(entity), opts.Format)
+	}
+	broken := make(map[string]any, len(record)+1)
+	for k, v := range record {
+		broken[k] = v
+	}
+	broken["_unknown"] = true
+	return broken, nil
+}
+
+// fakeFails - record fails ValidateRecord as read back from opts.Format
+func fakeFails(entity, record map[string]any, opts FakeOptions) (bool, error) {
+	var buf bytes.Buffer
+	var read func(emit func(batchRow) bool) error
+	switch opts.Format {
+	case "":
+		return len(ValidateRecord(entity, record, opts.Region)) > 0, nil
+	case FormatJSONL:
+		if err := WriteRecordsJSONL(&buf, []map[string]any{record}); err != nil {
+			return false, err
+		}
+		read = func(emit func(batchRow) bool) error { return readJSONL(&buf, emit) }
+	case FormatCSV:
+		if err := WriteRecordsCSV(&buf, entity, []map[string]any{record}); err != nil {
+			return false, err
+		}
+		var unmapped []string
+		read = func(emit func(batchRow) bool) error {
+			return readCSV(&buf, newCSVResolver(entity, nil), &unmapped, emit)
+		}
+	default:
+		return false, fmt.Errorf("unknown records format '%s'", opts.Format)
+	}
+
+	failed := false
+	err := read(func(row batchRow) bool {
+		failed = row.err != "" || len(ValidateRecord(entity, row.record, opts.Region)) > 0
+		return false
+	})
+	return failed, err
+}
+
+// fakeBreak - apply one random violation to field value, false if not applicable
+// or not representable in format
+func fakeBreak(r *rand.Rand, field, record map[string]any, format string) bool {
+	code, typeStr := getFieldCodeAndType(field)
+	required, _ := field["required"].(bool)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+	kind := FieldKind(typeStr)
+
+	switch r.Intn(6) {
+	case 0: // required
+		if !required {
+			return false
+		}
+		delete(record, code)
+
+	case 1: // type; a CSV cell of string field reads back as string
+		if kind == TypeString && format == FormatCSV {
+			return false
+		}
+		if kind == TypeString {
+			record[code] = int64(12345)
+		} else {
+			record[code] = "not a " + string(kind)
+		}
+
+	case 2: // enum value
+		if typeStr != string(TypeEnum) {
+			return false
+		}
+		record[code] = "__invalid__"
+
+	case 3: // range, length
+		switch {
+		case kind == TypeString && max != nil:
+			record[code] = fakeWord(r, int(*max)+1)
+		case kind == TypeString && min != nil && *min > 0:
+			record[code] = fakeWord(r, int(*min)-1)
+		case (kind == TypeNumber || kind == TypeInteger) && max != nil:
+			record[code] = *max + 1
+		case (kind == TypeNumber || kind == TypeInteger) && min != nil:
+			record[code] = *min - 1
+		case kind == TypeDecimal:
+			record[code] = "-99999999999999999999.999"
+		default:
+			return false
+		}
+
+	case 4: // pattern, format, validator
+		s, ok := record[code].(string)
+		_, hasPattern := field["pattern"]
+		_, hasFormat := field["format"]
+		_, hasValidator := field["validator"]
+		if !ok || !(hasPattern || hasFormat || hasValidator) {
+			return false
+		}
+		record[code] = s + "#"
+
+	case 5: // validator check digit
+		s, ok := record[code].(string)
+		if _, hasValidator := field["validator"]; !ok || !hasValidator || s == "" {
+			return false
+		}
+		last := s[len(s)-1]
+		if last < '0' || last > '9' {
+			return false
+		}
+		record[code] = s[:len(s)-1] + string(rune('0'+(last-'0'+1)%10))
+	}
+	return true
+}
+
+// --- output
+
+// WriteRecordsJSONL - one JSON object per line
+func WriteRecordsJSONL(w io.Writer, records []map[string]any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRecordsCSV - header of entity field codes (and unknown keys), empty cells for absent values
+func WriteRecordsCSV(w io.Writer, entity map[string]any, records []map[string]any) error {
+	var header []string
+	known := make(map[string]bool)
+	for _, field := range entityFields(entity) {
+		code, _ := getFieldCodeAndType(field)
+		header = append(header, code)
+		known[code] = true
+	}
+	var extra []string
+	for _, record := range records {
+		for key := range record {
+			if !known[key] {
+				known[key] = true
+				extra = append(extra, key)
+			}
+		}
+	}
+	sort.Strings(extra)
+	header = append(header, extra...)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	row := make([]string, len(header))
+	for _, record := range records {
+		for i, key := range header {
+			row[i] = csvCell(record[key])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,75 @@
+package preset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func fakeTestEntity() map[string]any {
+	return map[string]any{
+		"module": "crm", "object": "client", "property": "requisite", "code": "sample",
+		"fields": []any{
+			map[string]any{"code": "inn", "type": "string", "validator": "inn10", "required": true},
+			map[string]any{"code": "name", "type": "string", "min": 3.0, "max": 20.0, "required": true},
+			map[string]any{"code": "okpo", "type": "string", "pattern": "^[0-9]{8,10}$"},
+			map[string]any{"code": "tax", "type": "enum", "values": []any{"ОСН", "УСН"}},
+			map[string]any{"code": "staff", "type": "integer", "min": 1.0, "max": 1000.0},
+			map[string]any{"code": "active", "type": "boolean"},
+			map[string]any{"code": "capital", "type": "money", "currency": "RUB"},
+		},
+	}
+}
+
+func TestFakeRecordsRoundTrip(t *testing.T) {
+	entity := fakeTestEntity()
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		for _, invalid := range []bool{false, true} {
+			opts := FakeOptions{Count: 200, Seed: 7, Region: "ru", Invalid: invalid, Format: format}
+			records, err := FakeRecords(entity, opts)
+			if err != nil {
+				t.Fatalf("%s invalid=%v: %v", format, invalid, err)
+			}
+
+			var buf bytes.Buffer
+			if format == FormatCSV {
+				err = WriteRecordsCSV(&buf, entity, records)
+			} else {
+				err = WriteRecordsJSONL(&buf, records)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			summary, err := ValidateStream(entity, &buf, BatchOptions{Format: format, Region: "ru"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := summary.Valid
+			if invalid {
+				want = summary.Invalid
+			}
+			if summary.Rows != opts.Count || want != opts.Count {
+				t.Errorf("%s invalid=%v: %d rows, %d valid, %d invalid after round-trip",
+					format, invalid, summary.Rows, summary.Valid, summary.Invalid)
+			}
+		}
+	}
+}
+
+func TestFakeRecordsSeed(t *testing.T) {
+	entity := fakeTestEntity()
+	opts := FakeOptions{Count: 5, Seed: 42, Region: "ru"}
+	var a, b bytes.Buffer
+	for _, buf := range []*bytes.Buffer{&a, &b} {
+		records, err := FakeRecords(entity, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteRecordsJSONL(buf, records); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if a.String() != b.String() {
+		t.Errorf("same seed gives different records:\n%s\n%s", a.String(), b.String())
+	}
+}
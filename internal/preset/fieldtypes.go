@@ -0,0 +1,78 @@
+package preset
+
+import (
+	"sort"
+	"sync"
+)
+
+// TypeDefinition - field type plugin, built-in types are registered the same way
+type TypeDefinition struct {
+	Name FieldType
+	// underlying kind (string, number, integer, boolean, decimal) for generators
+	Kind FieldType
+
+	// build-time check of type specific keys of the field definition;
+	// fields - all fields of the entity by code
+	CheckDefinition func(field map[string]any, fields map[string]map[string]any) []string
+
+	// JSON Schema of the field, without title/description/examples
+	Schema func(field map[string]any) map[string]any
+
+	// raw decoded JSON value -> typed Go value
+	Decode func(field map[string]any, value any) (any, error)
+
+	// runtime check of decoded value
+	Check func(field map[string]any, value any) []string
+
+	// optional runtime check of decoded value against other values of the record
+	CheckRecord func(field map[string]any, value any, record map[string]any) []string
+}
+
+var (
+	typesMu    sync.RWMutex
+	fieldTypes = make(map[FieldType]TypeDefinition)
+)
+
+// RegisterType - add field type, replaces existing one with same name
+func RegisterType(def TypeDefinition) {
+	if def.Name == "" || def.Schema == nil || def.Decode == nil {
+		panic("preset: field type must have name, schema and decode funcs")
+	}
+	if def.Kind == "" {
+		def.Kind = def.Name
+	}
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	fieldTypes[def.Name] = def
+}
+
+// LookupType - find field type by name
+func LookupType(name string) (TypeDefinition, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	def, ok := fieldTypes[FieldType(name)]
+	return def, ok
+}
+
+// FieldTypes - sorted names of registered field types
+func FieldTypes() []string {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FieldKind - underlying JSON kind of the field type, string for unknown types
+func FieldKind(typeStr string) FieldType {
+	if def, ok := LookupType(typeStr); ok {
+		return def.Kind
+	}
+	return TypeString
+}
@@ -0,0 +1,314 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// built-in field types
+func init() {
+	RegisterType(TypeDefinition{
+		Name:            TypeString,
+		CheckDefinition: checkStringDefinition,
+		Schema:          stringSchema,
+		Decode:          decodeString,
+		Check:           checkStringValue,
+	})
+	RegisterType(TypeDefinition{
+		Name:            TypeNumber,
+		CheckDefinition: checkRangeDefinition,
+		Schema:          numberSchema,
+		Decode:          decodeNumber,
+		Check:           checkNumberValue,
+	})
+	RegisterType(TypeDefinition{
+		Name:            TypeInteger,
+		CheckDefinition: checkRangeDefinition,
+		Schema:          integerSchema,
+		Decode:          decodeInteger,
+		Check: func(field map[string]any, value any) []string {
+			return checkNumberValue(field, float64(value.(int64)))
+		},
+	})
+	RegisterType(TypeDefinition{
+		Name:   TypeBoolean,
+		Schema: booleanSchema,
+		Decode: func(_ map[string]any, value any) (any, error) {
+			if b, ok := value.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected boolean, got %T", value)
+		},
+	})
+	RegisterType(TypeDefinition{
+		Name:            TypeEnum,
+		Kind:            TypeString,
+		CheckDefinition: checkEnumDefinition,
+		Schema:          enumSchema,
+		Decode:          decodeString,
+		Check:           checkEnumValue,
+	})
+}
+
+// --- definition checks
+
+func checkStringDefinition(field map[string]any, _ map[string]map[string]any) []string {
+	var errors []string
+
+	if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+		if valid, errMsg := validatePattern(pattern); !valid {
+			errors = append(errors, errMsg)
+		}
+	}
+
+	return append(errors, checkRangeDefinition(field, nil)...)
+}
+
+func checkRangeDefinition(field map[string]any, _ map[string]map[string]any) []string {
+	_, typeStr := getFieldCodeAndType(field)
+	if min := getNumberValue(field, "min"); min != nil {
+		return validateMinMax(min, getNumberValue(field, "max"), typeStr)
+	}
+	return nil
+}
+
+func checkEnumDefinition(field map[string]any, _ map[string]map[string]any) []string {
+	values, ok := field["values"].([]any)
+	if !ok {
+		return []string{"enum requires values array"}
+	}
+	if valid, errMsg := validateEnumValues(values); !valid {
+		return []string{errMsg}
+	}
+	return nil
+}
+
+// --- JSON Schema
+
+func stringSchema(field map[string]any) map[string]any {
+	schema := map[string]any{"type": "string"}
+
+	if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+		normalized := normalizePatternForSchema(pattern)
+		if pattern == "YYYY-MM-DD" {
+			schema["format"] = "date"
+		}
+		schema["pattern"] = normalized
+	}
+
+	if min := getNumberValue(field, "min"); min != nil {
+		schema["minLength"] = int(*min)
+	}
+	if max := getNumberValue(field, "max"); max != nil {
+		schema["maxLength"] = int(*max)
+	}
+
+	// default value
+	if def, ok := field["default"].(string); ok && def != "" {
+		schema["default"] = def
+	}
+
+	return schema
+}
+
+func numberSchema(field map[string]any) map[string]any {
+	schema := map[string]any{"type": "number"}
+
+	// range
+	if min := getNumberValue(field, "min"); min != nil {
+		schema["minimum"] = *min
+	}
+	if max := getNumberValue(field, "max"); max != nil {
+		schema["maximum"] = *max
+	}
+
+	// multiplicity
+	if multiple := getMultipleOf(field); multiple != nil {
+		schema["multipleOf"] = *multiple
+	}
+
+	// default
+	if def, ok := field["default"].(float64); ok {
+		schema["default"] = def
+	}
+	if def, ok := field["default"].(int); ok {
+		schema["default"] = float64(def)
+	}
+
+	return schema
+}
+
+func integerSchema(field map[string]any) map[string]any {
+	schema := map[string]any{"type": "integer"}
+
+	// range
+	if min := getNumberValue(field, "min"); min != nil {
+		schema["minimum"] = int(*min)
+	}
+	if max := getNumberValue(field, "max"); max != nil {
+		schema["maximum"] = int(*max)
+	}
+
+	// multiplicity
+	if multiple := getMultipleOf(field); multiple != nil {
+		schema["multipleOf"] = *multiple
+	}
+
+	// default
+	if def, ok := field["default"].(float64); ok {
+		schema["default"] = int(def)
+	}
+	if def, ok := field["default"].(int); ok {
+		schema["default"] = def
+	}
+	if def, ok := field["default"].(string); ok && def != "" {
+		if intVal, err := strconv.Atoi(def); err == nil {
+			schema["default"] = intVal
+		}
+	}
+
+	return schema
+}
+
+func booleanSchema(field map[string]any) map[string]any {
+	schema := map[string]any{"type": "boolean"}
+
+	// default
+	if def, ok := field["default"].(bool); ok {
+		schema["default"] = def
+	}
+	if def, ok := field["default"].(string); ok && def != "" {
+		if def == "true" {
+			schema["default"] = true
+		} else if def == "false" {
+			schema["default"] = false
+		}
+	}
+
+	return schema
+}
+
+func enumSchema(field map[string]any) map[string]any {
+	schema := map[string]any{"type": "string"}
+
+	// allowable
+	if values, ok := field["values"].([]any); ok && len(values) > 0 {
+		schema["enum"] = values
+	}
+
+	// default
+	if def, ok := field["default"].(string); ok && def != "" {
+		schema["default"] = def
+	}
+
+	return schema
+}
+
+// --- decode
+
+func decodeString(_ map[string]any, value any) (any, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("expected string, got %T", value)
+}
+
+func decodeNumber(_ map[string]any, value any) (any, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("expected number, got %T", value)
+}
+
+func decodeInteger(field map[string]any, value any) (any, error) {
+	if n, ok := value.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	}
+
+	f, err := decodeNumber(field, value)
+	if err != nil {
+		return nil, fmt.Errorf("expected integer, got %T", value)
+	}
+	n := f.(float64)
+	if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+		return nil, fmt.Errorf("expected integer, got %v", n)
+	}
+	return int64(n), nil
+}
+
+// --- runtime checks
+
+func checkStringValue(field map[string]any, value any) []string {
+	s := value.(string)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+
+	var errors []string
+	length := float64(utf8.RuneCountInString(s))
+	if min != nil && length < *min {
+		errors = append(errors, fmt.Sprintf("length %d is less than min %d", int(length), int(*min)))
+	}
+	if max != nil && length > *max {
+		errors = append(errors, fmt.Sprintf("length %d is greater than max %d", int(length), int(*max)))
+	}
+
+	if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+		re, err := compiledPattern(pattern)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid pattern: %v", err))
+		} else if !re.MatchString(s) {
+			errors = append(errors, fmt.Sprintf("value does not match pattern %s", pattern))
+		}
+	}
+
+	return errors
+}
+
+func checkNumberValue(field map[string]any, value any) []string {
+	n := value.(float64)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+
+	var errors []string
+	if min != nil && n < *min {
+		errors = append(errors, fmt.Sprintf("value %v is less than min %v", n, *min))
+	}
+	if max != nil && n > *max {
+		errors = append(errors, fmt.Sprintf("value %v is greater than max %v", n, *max))
+	}
+
+	if multiple := getMultipleOf(field); multiple != nil && *multiple != 0 {
+		if q := n / *multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			errors = append(errors, fmt.Sprintf("value %v is not multiple of %v", n, *multiple))
+		}
+	}
+
+	return errors
+}
+
+func checkEnumValue(field map[string]any, value any) []string {
+	s := value.(string)
+	values, _ := field["values"].([]any)
+	for _, allowed := range values {
+		if allowed == s {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("value '%s' is not one of allowed values", s)}
+}
@@ -0,0 +1,353 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// decimal encodings in exported schemas
+const (
+	DecimalAsString = "string" // string with pattern, exact (default)
+	DecimalAsNumber = "number" // JSON number with multipleOf
+)
+
+const maxDecimalPrecision = 38
+
+var currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// exact numeric types: decimal, money, percent
+func init() {
+	RegisterType(TypeDefinition{
+		Name:            TypeDecimal,
+		Kind:            TypeDecimal,
+		CheckDefinition: checkDecimalDefinition,
+		Schema:          decimalSchema,
+		Decode:          decodeDecimal,
+		Check:           checkDecimalValue,
+	})
+	RegisterType(TypeDefinition{
+		Name: TypeMoney,
+		Kind: TypeDecimal,
+		CheckDefinition: func(field map[string]any, fields map[string]map[string]any) []string {
+			return append(checkDecimalDefinition(field, fields), checkCurrencyDefinition(field, fields)...)
+		},
+		Schema: func(field map[string]any) map[string]any {
+			schema := decimalSchema(field)
+			if currency, ok := field["currency"].(string); ok {
+				schema["x-currency"] = currency
+			}
+			if currencyField, ok := field["currency_field"].(string); ok {
+				schema["x-currency-field"] = currencyField
+			}
+			return schema
+		},
+		Decode:      decodeDecimal,
+		Check:       checkDecimalValue,
+		CheckRecord: checkCurrencyValue,
+	})
+	RegisterType(TypeDefinition{
+		Name:            TypePercent,
+		Kind:            TypeDecimal,
+		CheckDefinition: checkDecimalDefinition,
+		Schema: func(field map[string]any) map[string]any {
+			schema := decimalSchema(field)
+			schema["x-unit"] = "percent"
+			return schema
+		},
+		Decode: decodeDecimal,
+		Check:  checkDecimalValue,
+	})
+}
+
+// decimalSpec - resolved precision/scale/range of decimal field
+type decimalSpec struct {
+	Precision int // 0 - unlimited
+	Scale     int // -1 - unlimited
+	Min       *Decimal
+	Max       *Decimal
+	Encoding  string
+}
+
+// resolveDecimalSpec - field keys with type defaults (money: 18,2; percent: 0..100)
+func resolveDecimalSpec(field map[string]any) (decimalSpec, []string) {
+	_, typeStr := getFieldCodeAndType(field)
+	spec := decimalSpec{Scale: -1, Encoding: DecimalAsString}
+	var errors []string
+
+	if typeStr == string(TypeMoney) {
+		spec.Precision, spec.Scale = 18, 2
+	}
+
+	if precision, set, err := intParam(field, "precision"); err != nil {
+		errors = append(errors, err.Error())
+	} else if set {
+		spec.Precision = precision
+	}
+	if scale, set, err := intParam(field, "scale"); err != nil {
+		errors = append(errors, err.Error())
+	} else if set {
+		spec.Scale = scale
+	}
+
+	var err error
+	if spec.Min, err = decimalParam(field, "min"); err != nil {
+		errors = append(errors, err.Error())
+	}
+	if spec.Max, err = decimalParam(field, "max"); err != nil {
+		errors = append(errors, err.Error())
+	}
+	if typeStr == string(TypePercent) {
+		if _, exists := field["min"]; !exists {
+			zero, _ := ParseDecimal("0")
+			spec.Min = &zero
+		}
+		if _, exists := field["max"]; !exists {
+			hundred, _ := ParseDecimal("100")
+			spec.Max = &hundred
+		}
+	}
+
+	if encoding, ok := field["encoding"].(string); ok {
+		spec.Encoding = encoding
+	}
+
+	return spec, errors
+}
+
+// checkDecimal - precision, scale and range of value
+func (spec decimalSpec) checkDecimal(d Decimal) []string {
+	var errors []string
+
+	if spec.Scale >= 0 && d.Scale > spec.Scale {
+		errors = append(errors, fmt.Sprintf("value %s has more than %d digits after point", d.Text, spec.Scale))
+	}
+	if spec.Precision > 0 {
+		// fixed scale reserves its digits after point, otherwise all digits count
+		exceeds := d.Digits > spec.Precision
+		if spec.Scale >= 0 {
+			exceeds = d.Digits-d.Scale > spec.Precision-spec.Scale
+		}
+		if exceeds {
+			errors = append(errors, fmt.Sprintf("value %s exceeds precision %d", d.Text, spec.Precision))
+		}
+	}
+	if spec.Min != nil && d.Rat.Cmp(spec.Min.Rat) < 0 {
+		errors = append(errors, fmt.Sprintf("value %s is less than min %s", d.Text, spec.Min.Text))
+	}
+	if spec.Max != nil && d.Rat.Cmp(spec.Max.Rat) > 0 {
+		errors = append(errors, fmt.Sprintf("value %s is greater than max %s", d.Text, spec.Max.Text))
+	}
+
+	return errors
+}
+
+func checkDecimalDefinition(field map[string]any, _ map[string]map[string]any) []string {
+	spec, errors := resolveDecimalSpec(field)
+	if len(errors) > 0 {
+		return errors
+	}
+
+	if spec.Precision > maxDecimalPrecision {
+		errors = append(errors, fmt.Sprintf("precision must be 1-%d, got %d", maxDecimalPrecision, spec.Precision))
+	}
+	if _, set := field["precision"]; set && spec.Precision == 0 {
+		errors = append(errors, fmt.Sprintf("precision must be 1-%d, got 0", maxDecimalPrecision))
+	}
+	if spec.Precision > 0 && spec.Scale > spec.Precision {
+		errors = append(errors, fmt.Sprintf("scale %d cannot be greater than precision %d", spec.Scale, spec.Precision))
+	}
+	if spec.Encoding != DecimalAsString && spec.Encoding != DecimalAsNumber {
+		errors = append(errors, fmt.Sprintf("encoding must be '%s' or '%s', got '%s'",
+			DecimalAsString, DecimalAsNumber, spec.Encoding))
+	}
+	if spec.Min != nil && spec.Max != nil && spec.Min.Rat.Cmp(spec.Max.Rat) > 0 {
+		errors = append(errors, "min cannot be greater than max")
+	}
+
+	for _, key := range []string{"min", "max"} {
+		if d, _ := decimalParam(field, key); d != nil {
+			unbounded := spec
+			unbounded.Min, unbounded.Max = nil, nil
+			for _, err := range unbounded.checkDecimal(*d) {
+				errors = append(errors, fmt.Sprintf("%s: %s", key, err))
+			}
+		}
+	}
+
+	if def, err := decimalParam(field, "default"); err != nil {
+		errors = append(errors, err.Error())
+	} else if def != nil {
+		for _, err := range spec.checkDecimal(*def) {
+			errors = append(errors, fmt.Sprintf("default: %s", err))
+		}
+	}
+
+	return errors
+}
+
+func checkCurrencyDefinition(field map[string]any, fields map[string]map[string]any) []string {
+	currency, hasCurrency := field["currency"]
+	currencyField, hasCurrencyField := field["currency_field"]
+
+	switch {
+	case hasCurrency && hasCurrencyField:
+		return []string{"money requires either currency or currency_field, not both"}
+
+	case hasCurrency:
+		if s, ok := currency.(string); !ok || !currencyRegex.MatchString(s) {
+			return []string{fmt.Sprintf("currency must be ISO 4217 code (e.g. RUB), got %v", currency)}
+		}
+
+	case hasCurrencyField:
+		code, ok := currencyField.(string)
+		if !ok || code == "" {
+			return []string{"currency_field must be field code"}
+		}
+		target, exists := fields[code]
+		if !exists {
+			return []string{fmt.Sprintf("currency_field '%s' not found in entity fields", code)}
+		}
+		_, typeStr := getFieldCodeAndType(target)
+		if FieldKind(typeStr) != TypeString {
+			return []string{fmt.Sprintf("currency_field '%s' must be string or enum", code)}
+		}
+		if typeStr == string(TypeEnum) {
+			values, _ := target["values"].([]any)
+			for _, v := range values {
+				if s, ok := v.(string); !ok || !currencyRegex.MatchString(s) {
+					return []string{fmt.Sprintf("currency_field '%s' value must be ISO 4217 code (e.g. RUB), got %v", code, v)}
+				}
+			}
+		}
+
+	default:
+		return []string{"money requires currency or currency_field"}
+	}
+
+	return nil
+}
+
+// checkCurrencyValue - currency of money in the record is ISO 4217 code; missing
+// currency is reported by its own field
+func checkCurrencyValue(field map[string]any, _ any, record map[string]any) []string {
+	code, ok := field["currency_field"].(string)
+	if !ok {
+		return nil
+	}
+	currency, exists := record[code]
+	if !exists || currency == nil {
+		return nil
+	}
+	if s, ok := currency.(string); !ok || !currencyRegex.MatchString(s) {
+		return []string{fmt.Sprintf("currency %s must be ISO 4217 code (e.g. RUB), got %v", code, currency)}
+	}
+	return nil
+}
+
+// decimalPattern - regex for string encoded decimal of given precision/scale, same grammar
+// as ParseDecimal: optional sign, leading and trailing zeros are not significant.
+// Total digits of unset scale are not expressible, they are in x-decimal precision
+func decimalPattern(spec decimalSpec) string {
+	intPart := `[0-9]+`
+	if spec.Precision > 0 {
+		maxIntDigits := spec.Precision
+		if spec.Scale > 0 {
+			maxIntDigits -= spec.Scale
+		}
+		if maxIntDigits < 1 {
+			intPart = `0+`
+		} else {
+			intPart = fmt.Sprintf(`0*[0-9]{1,%d}`, maxIntDigits)
+		}
+	}
+
+	switch {
+	case spec.Scale == 0:
+		return fmt.Sprintf(`^[+-]?%s(\.0+)?$`, intPart)
+	case spec.Scale > 0:
+		return fmt.Sprintf(`^[+-]?%s(\.[0-9]{1,%d}0*)?$`, intPart, spec.Scale)
+	}
+	return fmt.Sprintf(`^[+-]?%s(\.[0-9]+)?$`, intPart)
+}
+
+func decimalSchema(field map[string]any) map[string]any {
+	spec, _ := resolveDecimalSpec(field)
+	schema := make(map[string]any)
+
+	def, _ := decimalParam(field, "default")
+
+	if spec.Encoding == DecimalAsNumber {
+		schema["type"] = "number"
+		if spec.Scale >= 0 {
+			multiple, _ := strconv.ParseFloat("1e-"+strconv.Itoa(spec.Scale), 64)
+			schema["multipleOf"] = multiple
+		}
+		if spec.Min != nil {
+			schema["minimum"], _ = spec.Min.Rat.Float64()
+		}
+		if spec.Max != nil {
+			schema["maximum"], _ = spec.Max.Rat.Float64()
+		}
+		if def != nil {
+			schema["default"], _ = def.Rat.Float64()
+		}
+		return schema
+	}
+
+	schema["type"] = "string"
+	schema["pattern"] = decimalPattern(spec)
+
+	// range is not expressible for strings, kept as annotation
+	annotation := make(map[string]any)
+	if spec.Precision > 0 {
+		annotation["precision"] = spec.Precision
+	}
+	if spec.Scale >= 0 {
+		annotation["scale"] = spec.Scale
+	}
+	if spec.Min != nil {
+		annotation["minimum"] = spec.Min.Text
+	}
+	if spec.Max != nil {
+		annotation["maximum"] = spec.Max.Text
+	}
+	if len(annotation) > 0 {
+		schema["x-decimal"] = annotation
+	}
+	if def != nil {
+		schema["default"] = def.Text
+	}
+
+	return schema
+}
+
+func decodeDecimal(field map[string]any, value any) (any, error) {
+	encoding, _ := field["encoding"].(string)
+
+	switch value.(type) {
+	case string:
+		if encoding == DecimalAsNumber {
+			return nil, fmt.Errorf("expected number, got string")
+		}
+	case json.Number, float64, int, int64:
+		if encoding != DecimalAsNumber {
+			return nil, fmt.Errorf("expected decimal string, got number")
+		}
+	}
+
+	d, err := toDecimal(value)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func checkDecimalValue(field map[string]any, value any) []string {
+	spec, errors := resolveDecimalSpec(field)
+	if len(errors) > 0 {
+		return errors
+	}
+	return spec.checkDecimal(value.(Decimal))
+}
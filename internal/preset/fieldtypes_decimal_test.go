@@ -0,0 +1,156 @@
+package preset
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func decimalEntity(field map[string]any) map[string]any {
+	field["code"] = "amount"
+	if _, ok := field["type"]; !ok {
+		field["type"] = "decimal"
+	}
+	return map[string]any{"fields": []any{field}}
+}
+
+func TestDecimalPrecisionScaleSign(t *testing.T) {
+	tests := []struct {
+		name  string
+		field map[string]any
+		value string
+		valid bool
+	}{
+		// precision without scale: all significant digits count
+		{"precision only, fits", map[string]any{"precision": 5.0}, "123.45", true},
+		{"precision only, integer fits", map[string]any{"precision": 5.0}, "12345", true},
+		{"precision only, fraction overflows", map[string]any{"precision": 5.0}, "123.456789", false},
+		{"precision only, integer overflows", map[string]any{"precision": 5.0}, "123456", false},
+		{"precision only, leading zeros ignored", map[string]any{"precision": 3.0}, "000123", true},
+		{"precision only, trailing zeros ignored", map[string]any{"precision": 3.0}, "1.2300", true},
+
+		// fixed scale: integer digits are precision - scale
+		{"scale, fits", map[string]any{"precision": 5.0, "scale": 2.0}, "123.45", true},
+		{"scale, integer overflows", map[string]any{"precision": 5.0, "scale": 2.0}, "1234.5", false},
+		{"scale, fraction overflows", map[string]any{"precision": 5.0, "scale": 2.0}, "1.234", false},
+		{"scale, trailing zeros ignored", map[string]any{"precision": 5.0, "scale": 2.0}, "1.2300", true},
+		{"scale 0, integer", map[string]any{"precision": 3.0, "scale": 0.0}, "123", true},
+		{"scale 0, zero fraction", map[string]any{"precision": 3.0, "scale": 0.0}, "123.0", true},
+		{"scale 0, fraction", map[string]any{"precision": 3.0, "scale": 0.0}, "12.5", false},
+		{"scale equals precision", map[string]any{"precision": 2.0, "scale": 2.0}, "0.99", true},
+		{"scale equals precision, integer", map[string]any{"precision": 2.0, "scale": 2.0}, "1.5", false},
+		{"scale only", map[string]any{"scale": 1.0}, "123456789.5", true},
+		{"scale only, overflows", map[string]any{"scale": 1.0}, "1.55", false},
+
+		// sign
+		{"negative", map[string]any{"precision": 3.0}, "-123", true},
+		{"plus sign", map[string]any{"precision": 3.0}, "+123", true},
+		{"negative overflows", map[string]any{"precision": 3.0, "scale": 1.0}, "-123.4", false},
+		{"double sign", map[string]any{}, "--1", false},
+		{"sign only", map[string]any{}, "-", false},
+
+		// spaces are not part of the grammar
+		{"leading space", map[string]any{}, " 1.5", false},
+		{"trailing newline", map[string]any{}, "1.5\n", false},
+
+		// money: 18,2 by default
+		{"money", map[string]any{"type": "money", "currency": "RUB"}, "1234567890123456.78", true},
+		{"money, scale overflows", map[string]any{"type": "money", "currency": "RUB"}, "1.234", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := decimalEntity(tt.field)
+			errs := ValidateRecord(entity, map[string]any{"amount": tt.value}, "")
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Fatalf("ValidateRecord(%q) valid = %v, want %v; errors: %v", tt.value, valid, tt.valid, errs)
+			}
+
+			// exported pattern follows the same grammar; total digits of unset scale
+			// are not expressible in it
+			spec, _ := resolveDecimalSpec(tt.field)
+			if spec.Precision > 0 && spec.Scale < 0 && !tt.valid {
+				return
+			}
+			re := regexp.MustCompile(decimalPattern(spec))
+			if matched := re.MatchString(tt.value); matched != tt.valid {
+				t.Errorf("pattern %s matches %q = %v, want %v", re, tt.value, matched, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in     string
+		text   string
+		scale  int
+		digits int
+	}{
+		{"0", "0", 0, 0},
+		{"-0.00", "0", 0, 0},
+		{"+1", "1", 0, 1},
+		{"007.50", "7.5", 1, 2},
+		{"-123.456", "-123.456", 3, 6},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", tt.in, err)
+		}
+		if d.Text != tt.text || d.Scale != tt.scale || d.Digits != tt.digits {
+			t.Errorf("ParseDecimal(%q) = %s scale %d digits %d, want %s scale %d digits %d",
+				tt.in, d.Text, d.Scale, d.Digits, tt.text, tt.scale, tt.digits)
+		}
+	}
+
+	for _, in := range []string{"", "-", "1e5", "1.", ".5", "1,5", "++1"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) accepted", in)
+		}
+	}
+}
+
+func TestMoneyCurrencyField(t *testing.T) {
+	amount := map[string]any{"code": "amount", "type": "money", "currency_field": "currency"}
+	currency := map[string]any{"code": "currency", "type": "string"}
+	entity := map[string]any{"fields": []any{amount, currency}}
+
+	tests := []struct {
+		name   string
+		record map[string]any
+		errors []string
+	}{
+		{"code", map[string]any{"amount": "10.50", "currency": "RUB"}, nil},
+		{"no currency", map[string]any{"amount": "10.50"}, nil},
+		{"lowercase", map[string]any{"amount": "10.50", "currency": "rub"},
+			[]string{"field amount: currency currency must be ISO 4217 code (e.g. RUB), got rub"}},
+		{"too long", map[string]any{"amount": "10.50", "currency": "RUBL"},
+			[]string{"field amount: currency currency must be ISO 4217 code (e.g. RUB), got RUBL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateRecord(entity, tt.record, "")
+			if !reflect.DeepEqual(errs, tt.errors) {
+				t.Errorf("ValidateRecord = %v, want %v", errs, tt.errors)
+			}
+		})
+	}
+
+	enum := map[string]any{"code": "currency", "type": "enum", "values": []any{"RUB", "dollar"}}
+	errs := checkCurrencyDefinition(amount, map[string]map[string]any{"currency": enum})
+	want := []string{"currency_field 'currency' value must be ISO 4217 code (e.g. RUB), got dollar"}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("checkCurrencyDefinition = %v, want %v", errs, want)
+	}
+
+	records, err := FakeRecords(entity, FakeOptions{Count: 50, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if errs := ValidateRecord(entity, record, ""); len(errs) > 0 {
+			t.Errorf("fake record %v: %v", record, errs)
+		}
+	}
+}
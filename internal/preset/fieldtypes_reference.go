@@ -0,0 +1,40 @@
+package preset
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// entity key: module.object.property.code
+var entityKeyRegex = regexp.MustCompile(`^[^.\s]+\.[^.\s]+\.[^.\s]+\.[^.\s]+$`)
+
+// reference to a record of another entity by its id
+func init() {
+	RegisterType(TypeDefinition{
+		Name:            TypeReference,
+		Kind:            TypeInteger,
+		CheckDefinition: checkReferenceDefinition,
+		Schema: func(field map[string]any) map[string]any {
+			ref, _ := field["ref"].(string)
+			return map[string]any{"type": "integer", "minimum": 1, "x-ref": ref}
+		},
+		Decode: decodeInteger,
+		Check: func(_ map[string]any, value any) []string {
+			if id := value.(int64); id < 1 {
+				return []string{fmt.Sprintf("reference id must be positive, got %d", id)}
+			}
+			return nil
+		},
+	})
+}
+
+func checkReferenceDefinition(field map[string]any, _ map[string]map[string]any) []string {
+	ref, ok := field["ref"].(string)
+	if !ok || ref == "" {
+		return []string{"reference requires 'ref' with entity key (module.object.property.code)"}
+	}
+	if !entityKeyRegex.MatchString(ref) {
+		return []string{fmt.Sprintf("ref '%s' must be entity key module.object.property.code", ref)}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package preset
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// canonical key order; unknown keys follow in source order
+var (
+	packageKeyOrder = []string{"name", "version", "description", "region", "locales", "default_locale", "tags", "dependencies", "build"}
+	entityKeyOrder  = []string{"module", "object", "property", "code", "name", "description", "fields"}
+	fieldKeyOrder   = []string{
+		"code", "name", "description", "type", "required",
+		"ref", "values", "validator", "format", "phone_region", "pattern",
+		"min", "max", "multiple_of", "precision", "scale", "encoding",
+		"currency", "currency_field", "bik_field",
+		"default", "examples",
+	}
+)
+
+// localized text keys: map of locale -> text, written in flow style
+var localizedKeys = map[string]bool{"name": true, "description": true}
+
+// FormatOptions - canonical layout settings
+type FormatOptions struct {
+	Locales []string // order of locales in localized text, others keep source order
+}
+
+// FormatResult - formatting of one file
+type FormatResult struct {
+	Path      string
+	Changed   bool
+	Formatted []byte
+	Err       error
+}
+
+// FormatPackageYAML - package.yml in canonical layout
+func FormatPackageYAML(data []byte) ([]byte, error) {
+	return formatYAML(data, func(root *yamlv3.Node) {
+		sortMapping(root, packageKeyOrder)
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if v := root.Content[i+1]; v.Kind == yamlv3.SequenceNode && scalarsOnly(v) {
+				v.Style = yamlv3.FlowStyle
+			}
+		}
+	})
+}
+
+// FormatEntityYAML - entity file in canonical layout: key order, quoting, indentation,
+// one blank line between fields; comments are kept with their keys
+func FormatEntityYAML(data []byte, opts FormatOptions) ([]byte, error) {
+	return formatYAML(data, func(root *yamlv3.Node) {
+		sortMapping(root, entityKeyOrder)
+		formatLocalized(root, opts.Locales)
+
+		_, fields := mappingNode(root, "fields")
+		if fields == nil || fields.Kind != yamlv3.SequenceNode {
+			return
+		}
+		fields.Style = 0
+		for _, field := range fields.Content {
+			if field.Kind != yamlv3.MappingNode {
+				continue
+			}
+			field.Style = 0
+			sortMapping(field, fieldKeyOrder)
+			formatLocalized(field, opts.Locales)
+			for i := 0; i+1 < len(field.Content); i += 2 {
+				if v := field.Content[i+1]; v.Kind == yamlv3.SequenceNode && scalarsOnly(v) {
+					v.Style = yamlv3.FlowStyle
+				}
+			}
+		}
+	})
+}
+
+// FormatPreset - format package.yml and entity files of dir; write - save changed files
+func FormatPreset(dir string, write bool) ([]FormatResult, error) {
+	configPath := filepath.Join(dir, "package.yml")
+	var opts FormatOptions
+	if pkg, err := loadConfig(dirFS(dir)); err == nil {
+		opts.Locales = pkg.Locales
+	}
+
+	files, err := ScanEntities(filepath.Join(dir, "entities"))
+	if err != nil {
+		return nil, fmt.Errorf("entities scan failed: %w", err)
+	}
+
+	paths := []string{configPath}
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	results := make([]FormatResult, 0, len(paths))
+	for _, path := range paths {
+		res := FormatResult{Path: path}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			res.Err = err
+			results = append(results, res)
+			continue
+		}
+
+		if path == configPath {
+			res.Formatted, res.Err = FormatPackageYAML(data)
+		} else {
+			res.Formatted, res.Err = FormatEntityYAML(data, opts)
+		}
+		res.Changed = res.Err == nil && !bytes.Equal(data, res.Formatted)
+
+		if write && res.Changed {
+			res.Err = writeFile(res.Formatted, path)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// formatYAML - parse, rearrange root mapping, normalise scalars and encode with indent 2
+func formatYAML(data []byte, arrange func(root *yamlv3.Node)) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+	root := doc.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("document must be mapping")
+	}
+
+	root.Style = 0
+	if len(root.Content) == 0 {
+		// empty mapping: nothing to arrange, the processor reports missing keys
+		normalizeScalars(&doc)
+		return encodeFormatted(data, &doc)
+	}
+	first, last := root.Content[0], root.Content[len(root.Content)-2]
+	arrange(root)
+	if root.Content[0] != first && first.HeadComment != "" {
+		// comment on top of the file stays on top when the first key moves
+		doc.HeadComment = strings.TrimSpace(doc.HeadComment + "\n" + first.HeadComment)
+		first.HeadComment = ""
+	}
+	if root.Content[len(root.Content)-2] != last && last.FootComment != "" {
+		// and comment at the end stays at the end when the last key moves
+		doc.FootComment = strings.TrimSpace(last.FootComment + "\n" + doc.FootComment)
+		last.FootComment = ""
+	}
+	normalizeScalars(&doc)
+	return encodeFormatted(data, &doc)
+}
+
+// encodeFormatted - document with indent 2 and separated fields; error if data seen by
+// the processor would differ from source data
+func encodeFormatted(data []byte, doc *yamlv3.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	formatted := separateFields(buf.Bytes())
+
+	// layout only: data seen by the processor must not change
+	var before, after any
+	if err := yaml.Unmarshal(data, &before); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(formatted, &after); err != nil || !reflect.DeepEqual(before, after) {
+		return nil, fmt.Errorf("formatting would change data: quote YAML 1.1 values like yes, no, on, n explicitly")
+	}
+	return formatted, nil
+}
+
+// sortMapping - reorder key/value pairs: keys of order first, then the rest in source order
+func sortMapping(m *yamlv3.Node, order []string) {
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		rank[key] = i
+	}
+
+	type pair struct{ key, value *yamlv3.Node }
+	var known = make([]*pair, len(order))
+	var rest []*pair
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		p := &pair{m.Content[i], m.Content[i+1]}
+		if r, ok := rank[p.key.Value]; ok && known[r] == nil {
+			known[r] = p
+		} else {
+			rest = append(rest, p)
+		}
+	}
+
+	content := make([]*yamlv3.Node, 0, len(m.Content))
+	for _, p := range append(known, rest...) {
+		if p != nil {
+			content = append(content, p.key, p.value)
+		}
+	}
+	m.Content = content
+}
+
+// formatLocalized - localized text maps in flow style, locales in package order
+func formatLocalized(m *yamlv3.Node, locales []string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		v := m.Content[i+1]
+		if !localizedKeys[m.Content[i].Value] || v.Kind != yamlv3.MappingNode {
+			continue
+		}
+		sortMapping(v, locales)
+		if !multiline(v) {
+			v.Style = yamlv3.FlowStyle
+		} else {
+			v.Style = 0
+		}
+	}
+}
+
+// normalizeScalars - strings plain where plain is unambiguous, double quotes otherwise;
+// multi-line strings as literal blocks. Strings of one list are quoted all or none
+func normalizeScalars(n *yamlv3.Node) {
+	quoteAll := false
+	for _, c := range n.Content {
+		normalizeScalars(c)
+		if n.Kind == yamlv3.SequenceNode && c.Style == yamlv3.DoubleQuotedStyle {
+			quoteAll = true
+		}
+	}
+	if quoteAll {
+		for _, c := range n.Content {
+			if isString(c) && c.Style != yamlv3.LiteralStyle {
+				c.Style = yamlv3.DoubleQuotedStyle
+			}
+		}
+	}
+
+	if !isString(n) {
+		return
+	}
+	switch {
+	case strings.Contains(n.Value, "\n"):
+		n.Style = yamlv3.LiteralStyle
+	case !plainSafe(n.Value):
+		n.Style = yamlv3.DoubleQuotedStyle
+	default:
+		n.Style = 0
+	}
+}
+
+func isString(n *yamlv3.Node) bool {
+	return n.Kind == yamlv3.ScalarNode && n.ShortTag() == "!!str"
+}
+
+var plainUnsafeRegex = regexp.MustCompile(`^[-?:,\[\]{}#&*!|>'"%@` + "`" + `\s]|: |\s#|[\s:]$|[\x00-\x1f\\,\[\]{}]`)
+
+// plainSafe - plain string is read back as the same string in block and flow context,
+// by YAML 1.2 and by YAML 1.1 of the processor (yes/no, 1:20 and the like are not strings there)
+func plainSafe(s string) bool {
+	if s == "" || plainUnsafeRegex.MatchString(s) {
+		return false
+	}
+	var v12, v11 map[string]any
+	doc := []byte("v: " + s)
+	if yamlv3.Unmarshal(doc, &v12) != nil || yaml.Unmarshal(doc, &v11) != nil {
+		return false
+	}
+	return v12["v"] == s && v11["v"] == s
+}
+
+func scalarsOnly(n *yamlv3.Node) bool {
+	for _, c := range n.Content {
+		if c.Kind != yamlv3.ScalarNode || strings.Contains(c.Value, "\n") ||
+			c.HeadComment != "" || c.LineComment != "" || c.FootComment != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func multiline(n *yamlv3.Node) bool {
+	for _, c := range n.Content {
+		if strings.Contains(c.Value, "\n") || c.HeadComment != "" || c.LineComment != "" || c.FootComment != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mappingNode - key and value nodes of mapping entry
+func mappingNode(m *yamlv3.Node, key string) (*yamlv3.Node, *yamlv3.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// separateFields - blank line between items of top-level fields sequence
+func separateFields(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	out := make([]string, 0, len(lines)+16)
+	inFields, first := false, true
+	for _, line := range lines {
+		if !strings.HasPrefix(line, " ") && line != "" && !strings.HasPrefix(line, "-") {
+			inFields, first = strings.HasPrefix(line, "fields:"), true
+		} else if inFields && (strings.HasPrefix(line, "  - ") || strings.HasPrefix(line, "  # ") || line == "  -") {
+			if !first && out[len(out)-1] != "" && !strings.HasPrefix(strings.TrimSpace(out[len(out)-1]), "#") {
+				out = append(out, "")
+			}
+			first = false
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
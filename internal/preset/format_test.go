@@ -0,0 +1,114 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const formatTestEntity = `# client requisites
+fields:
+  # tax id
+  - type: string
+    code: inn   # 10 digits
+    validator: inn10
+    required: true
+  - {code: kind, type: enum, values: [a, b]}
+code: sample
+name:
+  en: Sample
+  ru: Образец
+module: crm
+object: client
+property: requisite
+# trailing note
+`
+
+func TestFormatEntityYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string // in this order
+	}{
+		{"empty mapping", "{}\n", []string{"{}"}},
+		{"empty mapping with comment", "# empty\n{}\n", []string{"# empty", "{}"}},
+		{"key order and comments", formatTestEntity, []string{
+			"# client requisites\n\nmodule: crm\nobject: client\nproperty: requisite\ncode: sample\n",
+			"name: {ru: Образец, en: Sample}",
+			"  # tax id\n  - code: inn # 10 digits\n    type: string\n    required: true\n    validator: inn10\n",
+			"\n  - code: kind\n    type: enum\n    values: [a, b]\n",
+			"# trailing note",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := FormatEntityYAML([]byte(tt.input), FormatOptions{Locales: []string{"ru", "en"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rest := string(formatted)
+			for _, want := range tt.contains {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("formatted output lacks %q:\n%s", want, formatted)
+				}
+				rest = rest[i+len(want):]
+			}
+
+			again, err := FormatEntityYAML(formatted, FormatOptions{Locales: []string{"ru", "en"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(formatted) {
+				t.Errorf("second format changed output:\n%s\n---\n%s", formatted, again)
+			}
+		})
+	}
+}
+
+func TestFormatPackageYAML(t *testing.T) {
+	for _, input := range []string{"{}\n", "region: ru\nname: test\nversion: 1.0.0 # first\ntags:\n  - a\n  - b\n"} {
+		formatted, err := FormatPackageYAML([]byte(input))
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		again, err := FormatPackageYAML(formatted)
+		if err != nil || string(again) != string(formatted) {
+			t.Errorf("%q: second format changed output:\n%s\n---\n%s (%v)", input, formatted, again, err)
+		}
+	}
+
+	formatted, _ := FormatPackageYAML([]byte("region: ru\nname: test\nversion: 1.0.0 # first\ntags:\n  - a\n  - b\n"))
+	if want := "name: test\nversion: 1.0.0 # first\nregion: ru\ntags: [a, b]\n"; string(formatted) != want {
+		t.Errorf("FormatPackageYAML = %q, want %q", formatted, want)
+	}
+}
+
+func TestFormatKeepsData(t *testing.T) {
+	if _, err := FormatEntityYAML([]byte("[1, 2]\n"), FormatOptions{}); err == nil {
+		t.Error("sequence document accepted")
+	}
+	if _, err := FormatEntityYAML([]byte(""), FormatOptions{}); err == nil {
+		t.Error("empty document accepted")
+	}
+}
+
+func TestFormatPresetEmptyMapping(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "entities"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "package.yml"), []byte("{}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "entities", "a.yml"), []byte("{}\n"), 0644)
+
+	results, err := FormatPreset(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Err != nil || res.Changed {
+			t.Errorf("%s: changed %v, error %v", res.Path, res.Changed, res.Err)
+		}
+	}
+}
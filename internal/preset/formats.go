@@ -0,0 +1,283 @@
+package preset
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StringFormat - vetted check for structured strings, usable with or instead of pattern
+type StringFormat struct {
+	Name string
+	// JSON Schema 'format' value, empty - format has no standard name
+	SchemaFormat string
+	// region - field phone_region or package region
+	Check func(value, region string) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]StringFormat)
+)
+
+// RegisterFormat - add string format, replaces existing one with same name
+func RegisterFormat(f StringFormat) {
+	if f.Name == "" || f.Check == nil {
+		panic("preset: format must have name and check func")
+	}
+
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[f.Name] = f
+}
+
+// LookupFormat - find string format by name
+func LookupFormat(name string) (StringFormat, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Formats - sorted names of registered string formats
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterFormat(StringFormat{Name: "email", SchemaFormat: "email", Check: checkEmail})
+	RegisterFormat(StringFormat{Name: "uri", SchemaFormat: "uri", Check: checkURI})
+	RegisterFormat(StringFormat{Name: "url", SchemaFormat: "uri", Check: checkURI})
+	RegisterFormat(StringFormat{Name: "uuid", SchemaFormat: "uuid", Check: checkUUID})
+	RegisterFormat(StringFormat{Name: "ipv4", SchemaFormat: "ipv4", Check: checkIPv4})
+	RegisterFormat(StringFormat{Name: "ipv6", SchemaFormat: "ipv6", Check: checkIPv6})
+	RegisterFormat(StringFormat{Name: "hostname", SchemaFormat: "hostname", Check: checkHostnameFormat})
+	RegisterFormat(StringFormat{Name: "date", SchemaFormat: "date", Check: checkDate})
+	RegisterFormat(StringFormat{Name: "phone", Check: checkPhone})
+}
+
+// fieldFormatRegion - phone_region of field or package region
+func fieldFormatRegion(field map[string]any, region string) string {
+	if r, ok := field["phone_region"].(string); ok && r != "" {
+		return strings.ToLower(r)
+	}
+	return strings.ToLower(region)
+}
+
+// validateFieldFormat - build-time check of 'format' key
+func validateFieldFormat(field map[string]any) []string {
+	raw, exists := field["format"]
+	if !exists {
+		return nil
+	}
+
+	name, ok := raw.(string)
+	if !ok || name == "" {
+		return []string{"format must be non-empty string"}
+	}
+	if typeStr, _ := field["type"].(string); typeStr != string(TypeString) {
+		return []string{fmt.Sprintf("format '%s' requires type string, got '%s'", name, typeStr)}
+	}
+	if _, ok := LookupFormat(name); !ok {
+		return []string{fmt.Sprintf("unknown format '%s' (available: %s)", name, strings.Join(Formats(), ", "))}
+	}
+
+	if r, exists := field["phone_region"]; exists {
+		region, ok := r.(string)
+		if name != "phone" {
+			return []string{"phone_region is only allowed for format phone"}
+		}
+		if _, known := phoneRegions[strings.ToLower(region)]; !ok || !known {
+			return []string{fmt.Sprintf("unknown phone_region '%v'", r)}
+		}
+	}
+
+	return nil
+}
+
+// applyFormatSchema - JSON Schema 'format' for field with format key
+func applyFormatSchema(field map[string]any, schema map[string]any, region string) {
+	name, _ := field["format"].(string)
+	f, ok := LookupFormat(name)
+	if !ok {
+		return
+	}
+
+	if f.SchemaFormat != "" {
+		schema["format"] = f.SchemaFormat
+		return
+	}
+
+	schema["x-format"] = f.Name
+	if f.Name == "phone" {
+		if r := fieldFormatRegion(field, region); r != "" {
+			schema["x-phone-region"] = r
+		} else if _, hasPattern := schema["pattern"]; !hasPattern {
+			// without region only international numbers are valid
+			schema["pattern"] = `^\+[1-9][0-9]{1,14}$`
+		}
+	}
+}
+
+// --- checks
+
+func checkEmail(value, _ string) error {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Name != "" || addr.Address != value {
+		return fmt.Errorf("invalid email")
+	}
+
+	at := strings.LastIndex(value, "@")
+	if err := checkHostname(value[at+1:]); err != nil || !strings.Contains(value[at+1:], ".") {
+		return fmt.Errorf("invalid email domain")
+	}
+	return nil
+}
+
+func checkURI(value, _ string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+		return fmt.Errorf("invalid absolute URI")
+	}
+	return nil
+}
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func checkUUID(value, _ string) error {
+	if !uuidRegex.MatchString(value) {
+		return fmt.Errorf("invalid UUID")
+	}
+	return nil
+}
+
+func checkIPv4(value, _ string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is4() {
+		return fmt.Errorf("invalid IPv4 address")
+	}
+	return nil
+}
+
+func checkIPv6(value, _ string) error {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is6() || addr.Zone() != "" {
+		return fmt.Errorf("invalid IPv6 address")
+	}
+	return nil
+}
+
+var hostnameLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// checkHostname - RFC 1123
+func checkHostname(value string) error {
+	if value == "" || len(value) > 253 {
+		return fmt.Errorf("hostname must be 1-253 characters")
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(value, "."), ".") {
+		if !hostnameLabelRegex.MatchString(label) {
+			return fmt.Errorf("invalid hostname label '%s'", label)
+		}
+	}
+	return nil
+}
+
+func checkHostnameFormat(value, _ string) error {
+	return checkHostname(value)
+}
+
+func checkDate(value, _ string) error {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return fmt.Errorf("invalid date, expected YYYY-MM-DD")
+	}
+	return nil
+}
+
+// phoneRegion - country calling code, trunk prefix and national number length (0 - any)
+type phoneRegion struct {
+	CountryCode string
+	Trunk       string
+	NSNLength   int
+}
+
+var phoneRegions = map[string]phoneRegion{
+	"ru": {"7", "8", 10},
+	"kz": {"7", "8", 10},
+	"by": {"375", "80", 9},
+	"ua": {"380", "0", 9},
+	"uz": {"998", "", 9},
+	"kg": {"996", "0", 9},
+	"am": {"374", "0", 8},
+	"az": {"994", "0", 9},
+	"ge": {"995", "0", 9},
+	"tj": {"992", "", 9},
+	"md": {"373", "0", 8},
+	"us": {"1", "1", 10},
+	"ca": {"1", "1", 10},
+	"gb": {"44", "0", 0},
+	"de": {"49", "0", 0},
+	"cn": {"86", "0", 0},
+	"tr": {"90", "0", 10},
+}
+
+var (
+	e164Regex        = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	phoneSeparators  = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+	phoneDigitsRegex = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// checkPhone - E.164 (+79991234567); numbers without '+' are read in region national format
+func checkPhone(value, region string) error {
+	phone := phoneSeparators.Replace(value)
+
+	if strings.HasPrefix(phone, "+") {
+		if !e164Regex.MatchString(phone) {
+			return fmt.Errorf("invalid phone, expected E.164 (+<country><number>, up to 15 digits)")
+		}
+		if r, ok := phoneRegions[region]; ok && r.NSNLength > 0 && strings.HasPrefix(phone[1:], r.CountryCode) {
+			if len(phone)-1-len(r.CountryCode) != r.NSNLength {
+				return fmt.Errorf("invalid phone length for region %s", region)
+			}
+		}
+		return nil
+	}
+
+	r, ok := phoneRegions[region]
+	if !ok {
+		return fmt.Errorf("invalid phone, expected E.164 (+<country><number>)")
+	}
+	if !phoneDigitsRegex.MatchString(phone) {
+		return fmt.Errorf("phone must contain only digits and separators")
+	}
+
+	national := phone
+	switch {
+	case r.Trunk != "" && strings.HasPrefix(phone, r.Trunk) && (r.NSNLength == 0 || len(phone) == len(r.Trunk)+r.NSNLength):
+		national = phone[len(r.Trunk):]
+	case strings.HasPrefix(phone, r.CountryCode) && r.NSNLength > 0 && len(phone) == len(r.CountryCode)+r.NSNLength:
+		national = phone[len(r.CountryCode):]
+	}
+
+	if r.NSNLength > 0 && len(national) != r.NSNLength {
+		return fmt.Errorf("invalid phone length for region %s", region)
+	}
+	if len(r.CountryCode)+len(national) > 15 {
+		return fmt.Errorf("phone is longer than 15 digits")
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package preset
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// presetFS - filesystem of preset; dir - OS directory of the filesystem, paths of reports
+// are shown under it. dir "" - paths are shown as in fsys
+type presetFS struct {
+	fsys fs.FS
+	dir  string
+}
+
+func dirFS(dir string) presetFS {
+	return presetFS{fsys: os.DirFS(dir), dir: dir}
+}
+
+// path - report path of slash name of fsys
+func (p presetFS) path(name string) string {
+	if p.dir == "" {
+		return name
+	}
+	return filepath.Join(p.dir, filepath.FromSlash(name))
+}
+
+func (p presetFS) readFile(name string) ([]byte, error) {
+	data, err := fs.ReadFile(p.fsys, name)
+	return data, p.pathError(err)
+}
+
+// pathError - error of fsys with report path
+func (p presetFS) pathError(err error) error {
+	var pe *fs.PathError
+	if p.dir != "" && errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: p.path(pe.Path), Err: pe.Err}
+	}
+	return err
+}
+
+// readEntityFile - content of entity file: from the filesystem it was scanned in,
+// OS path otherwise (files of editors, bundles)
+func readEntityFile(file EntityFile) ([]byte, error) {
+	if file.fsys.fsys == nil {
+		return os.ReadFile(file.Path)
+	}
+	return file.fsys.readFile(file.name)
+}
+
+// source archives of preset: .tar.gz is a bundle, see IsBundle
+const (
+	ArchiveZip = ".zip"
+	ArchiveTar = ".tar"
+)
+
+// IsArchive - path names a zip or tar archive of preset sources
+func IsArchive(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ArchiveZip || ext == ArchiveTar
+}
+
+// OpenArchive - read-only filesystem of preset sources in zip or tar archive; an archive
+// of a single directory with package.yml is opened at that directory
+func OpenArchive(archivePath string) (fs.FS, error) {
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var fsys fs.FS
+	switch strings.ToLower(filepath.Ext(archivePath)) {
+	case ArchiveZip:
+		fsys, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	case ArchiveTar:
+		fsys, err = tarFS(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%s: archive must be %s or %s", archivePath, ArchiveZip, ArchiveTar)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", archivePath, err)
+	}
+
+	if _, err := fs.Stat(fsys, "package.yml"); err == nil {
+		return fsys, nil
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		if _, err := fs.Stat(fsys, path.Join(entries[0].Name(), "package.yml")); err == nil {
+			return fs.Sub(fsys, entries[0].Name())
+		}
+	}
+	return fsys, nil
+}
+
+// tarFS - regular files of tar archive; repacked into in-memory zip, which is an fs.FS.
+// A name repeated in the archive is its last copy, as on extraction
+func tarFS(r io.Reader) (fs.FS, error) {
+	type tarFile struct {
+		data    []byte
+		modTime time.Time
+	}
+	files := make(map[string]tarFile)
+	var names []string
+
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean(strings.TrimPrefix(h.Name, "./")), "/")
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid path in archive: %s", h.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := files[name]; !exists {
+			names = append(names, name)
+		}
+		files[name] = tarFile{data: data, modTime: h.ModTime}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Modified: files[name].modTime, Method: zip.Store})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name].data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
+
+// OverlayFS - layers merged into one filesystem: a file is taken from the first layer
+// having it, directories list entries of all layers
+func OverlayFS(layers ...fs.FS) fs.FS {
+	return overlayFS(layers)
+}
+
+type overlayFS []fs.FS
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o {
+		f, err := layer.Open(name)
+		if err != nil {
+			continue
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !info.IsDir() {
+			return f, nil
+		}
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &overlayDir{File: f, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir - entries of the directory in all layers, the first layer wins; sorted by name
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	found := false
+	for _, layer := range o {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, e := range layerEntries {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// overlayDir - directory of the first layer listing merged entries
+type overlayDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,280 @@
+package preset
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// tarEntry - header of test tar archive; body of regular files
+type tarEntry struct {
+	name string
+	dir  bool
+	body string
+}
+
+func tarArchive(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		if e.dir {
+			h = &tar.Header{Name: e.name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func dirNames(t *testing.T, fsys fs.FS, dir string) []string {
+	t.Helper()
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%s): %v", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestOverlayFS(t *testing.T) {
+	upper := fstest.MapFS{
+		"package.yml":           {Data: []byte("upper")},
+		"entities/a.yml":        {Data: []byte("upper a")},
+		"entities/local/c.yml":  {Data: []byte("upper c")},
+		"entities/shadowed.yml": {Data: []byte("upper shadowed")},
+	}
+	lower := fstest.MapFS{
+		"package.yml":           {Data: []byte("lower")},
+		"entities/b.yml":        {Data: []byte("lower b")},
+		"entities/shadowed.yml": {Data: []byte("lower shadowed")},
+		"schema/x.json":         {Data: []byte("lower x")},
+	}
+	fsys := OverlayFS(upper, lower)
+
+	files := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{"package.yml", "upper", nil},
+		{"entities/a.yml", "upper a", nil},
+		{"entities/b.yml", "lower b", nil},
+		{"entities/shadowed.yml", "upper shadowed", nil},
+		{"entities/local/c.yml", "upper c", nil},
+		{"schema/x.json", "lower x", nil},
+		{"entities/missing.yml", "", fs.ErrNotExist},
+		{"../package.yml", "", fs.ErrInvalid},
+	}
+	for _, tt := range files {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fs.ReadFile(fsys, tt.name)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil || string(data) != tt.want {
+				t.Errorf("ReadFile = %q, %v; want %q", data, err, tt.want)
+			}
+		})
+	}
+
+	dirs := []struct {
+		name string
+		want []string
+	}{
+		{".", []string{"entities", "package.yml", "schema"}},
+		{"entities", []string{"a.yml", "b.yml", "local", "shadowed.yml"}},
+		{"schema", []string{"x.json"}},
+	}
+	for _, tt := range dirs {
+		t.Run("readdir "+tt.name, func(t *testing.T) {
+			if got := dirNames(t, fsys, tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadDir = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := fstest.TestFS(fsys, "package.yml", "entities/a.yml", "entities/b.yml",
+		"entities/local/c.yml", "entities/shadowed.yml", "schema/x.json"); err != nil {
+		t.Error(err)
+	}
+}
+
+// statErrFS - filesystem of files failing Stat, tracking Close
+type statErrFS struct{ closed *bool }
+
+type statErrFile struct {
+	fs.File
+	closed *bool
+}
+
+func (f statErrFile) Stat() (fs.FileInfo, error) { return nil, errors.New("stat failed") }
+func (f statErrFile) Close() error               { *f.closed = true; return nil }
+
+func (s statErrFS) Open(name string) (fs.File, error) {
+	return statErrFile{closed: s.closed}, nil
+}
+
+func TestOverlayFSStatError(t *testing.T) {
+	closed := false
+	f, err := OverlayFS(statErrFS{closed: &closed}).Open("package.yml")
+	if err == nil || f != nil {
+		t.Fatalf("Open = %v, %v; want nil file and error", f, err)
+	}
+	if !closed {
+		t.Error("file of failed Stat is not closed")
+	}
+}
+
+func TestTarFS(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		files   map[string]string
+		dirs    map[string][]string
+		errText string
+	}{
+		{
+			name: "dot prefixes",
+			entries: []tarEntry{
+				{name: "./package.yml", body: "pkg"},
+				{name: "./entities/a.yml", body: "a"},
+			},
+			files: map[string]string{"package.yml": "pkg", "entities/a.yml": "a"},
+			dirs:  map[string][]string{".": {"entities", "package.yml"}},
+		},
+		{
+			name: "directory entries",
+			entries: []tarEntry{
+				{name: "./", dir: true},
+				{name: "entities/", dir: true},
+				{name: "entities/a.yml", body: "a"},
+				{name: "schema/", dir: true},
+			},
+			files: map[string]string{"entities/a.yml": "a"},
+			dirs:  map[string][]string{".": {"entities"}, "entities": {"a.yml"}},
+		},
+		{
+			name: "duplicate names",
+			entries: []tarEntry{
+				{name: "a.txt", body: "first"},
+				{name: "b.txt", body: "b"},
+				{name: "./a.txt", body: "second"},
+			},
+			files: map[string]string{"a.txt": "second", "b.txt": "b"},
+			dirs:  map[string][]string{".": {"a.txt", "b.txt"}},
+		},
+		{
+			name:    "escaping path",
+			entries: []tarEntry{{name: "../a.txt", body: "a"}},
+			errText: "invalid path in archive: ../a.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys, err := tarFS(bytes.NewReader(tarArchive(t, tt.entries...)))
+			if tt.errText != "" {
+				if err == nil || err.Error() != tt.errText {
+					t.Errorf("error = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.files {
+				if data, err := fs.ReadFile(fsys, name); err != nil || string(data) != want {
+					t.Errorf("ReadFile(%s) = %q, %v; want %q", name, data, err, want)
+				}
+			}
+			for dir, want := range tt.dirs {
+				if got := dirNames(t, fsys, dir); !reflect.DeepEqual(got, want) {
+					t.Errorf("ReadDir(%s) = %v, want %v", dir, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive string
+		data    func(t *testing.T) []byte
+		want    []string
+	}{
+		{"zip at root", "preset.zip", func(t *testing.T) []byte {
+			return zipArchive(t, map[string]string{"package.yml": "pkg", "entities/a.yml": "a"})
+		}, []string{"entities", "package.yml"}},
+		{"zip of single directory", "preset.zip", func(t *testing.T) []byte {
+			return zipArchive(t, map[string]string{"crm/package.yml": "pkg", "crm/entities/a.yml": "a"})
+		}, []string{"entities", "package.yml"}},
+		{"zip of directory without package", "preset.zip", func(t *testing.T) []byte {
+			return zipArchive(t, map[string]string{"crm/entities/a.yml": "a"})
+		}, []string{"crm"}},
+		{"zip of several directories", "preset.zip", func(t *testing.T) []byte {
+			return zipArchive(t, map[string]string{"crm/package.yml": "pkg", "hr/package.yml": "pkg"})
+		}, []string{"crm", "hr"}},
+		{"tar of single directory", "preset.tar", func(t *testing.T) []byte {
+			return tarArchive(t,
+				tarEntry{name: "./crm/", dir: true},
+				tarEntry{name: "./crm/package.yml", body: "pkg"},
+				tarEntry{name: "./crm/entities/a.yml", body: "a"})
+		}, []string{"entities", "package.yml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), tt.archive)
+			if err := os.WriteFile(archivePath, tt.data(t), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			fsys, err := OpenArchive(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dirNames(t, fsys, "."); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadDir(.) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package preset
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const generatedHeader = "Code generated by yieldaa-runtime. DO NOT EDIT."
+
+// GenerateGo - Go sources (relative path -> content): one package per module,
+// struct per entity with json tags and Validate() method
+func GenerateGo(pkg *Package, processed []ProcessedEntity) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	locale := PackageLocale(pkg)
+
+	// package -> module, lowercase file path -> entity key: names must not collide,
+	// also on case-insensitive filesystems
+	modules := make(map[string]string)
+	owners := make(map[string]string)
+
+	groups := groupByModule(validEntities(processed))
+	for _, module := range sortedKeys(groups) {
+		entities := groups[module]
+		pkgName := goPackageName(module)
+		if other, exists := modules[pkgName]; exists {
+			return nil, fmt.Errorf("module '%s': package %s already generated for module '%s'", module, pkgName, other)
+		}
+		modules[pkgName] = module
+
+		doc := fmt.Sprintf("// %s\n\n// Package %s contains entities of module '%s' of preset %s v%s.\npackage %s\n",
+			generatedHeader, pkgName, module, pkg.Name, pkg.Version, pkgName)
+		files[path.Join(pkgName, "doc.gen.go")] = []byte(doc)
+
+		seenTypes := make(map[string]string)
+		for _, pe := range entities {
+			g := &goGenerator{pkgName: pkgName, locale: locale, imports: make(map[string]bool)}
+
+			src, err := g.entity(pe.ParsedData)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pe.File.Path, err)
+			}
+			if other, exists := seenTypes[g.typeName]; exists {
+				return nil, fmt.Errorf("%s: type %s.%s already generated for %s",
+					pe.File.Path, pkgName, g.typeName, other)
+			}
+			seenTypes[g.typeName] = EntityKey(pe.ParsedData)
+
+			formatted, err := format.Source(src)
+			if err != nil {
+				return nil, fmt.Errorf("%s: generated code: %w", pe.File.Path, err)
+			}
+
+			name := path.Join(pkgName, goFileName(pe.ParsedData))
+			if other, exists := owners[strings.ToLower(name)]; exists {
+				return nil, fmt.Errorf("%s: file %s of %s already generated for %s",
+					pe.File.Path, name, EntityKey(pe.ParsedData), other)
+			}
+			owners[strings.ToLower(name)] = EntityKey(pe.ParsedData)
+			files[name] = formatted
+		}
+	}
+
+	return files, nil
+}
+
+// groupByModule - entities by module, order inside module is kept
+func groupByModule(entities []ProcessedEntity) map[string][]ProcessedEntity {
+	groups := make(map[string][]ProcessedEntity)
+	for _, pe := range entities {
+		module := GetFieldString(pe.ParsedData, "module")
+		groups[module] = append(groups[module], pe)
+	}
+	return groups
+}
+
+// goPackageName - lowercase identifier from module code
+func goPackageName(module string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(module) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) || token.IsKeyword(name) {
+		name = "m" + name
+	}
+	return name
+}
+
+// goFileName - object_property_code.gen.go, suffix keeps _test/_GOOS names harmless
+func goFileName(parsed map[string]any) string {
+	parts := []string{
+		GetFieldString(parsed, "object"),
+		GetFieldString(parsed, "property"),
+		GetFieldString(parsed, "code"),
+	}
+	return strings.ToLower(strings.Join(parts, "_")) + ".gen.go"
+}
+
+// goIdent - exported identifier from code
+func goIdent(code string) string {
+	ident := camelCase(code)
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "F" + ident
+	}
+	return ident
+}
+
+type goGenerator struct {
+	pkgName  string
+	locale   string
+	typeName string
+	imports  map[string]bool
+
+	decls    strings.Builder // package-level vars and enum types
+	fields   strings.Builder // struct body
+	validate strings.Builder // Validate() body
+}
+
+func (g *goGenerator) entity(parsed map[string]any) ([]byte, error) {
+	g.typeName = goIdent(strings.Join([]string{
+		GetFieldString(parsed, "object"),
+		GetFieldString(parsed, "property"),
+		GetFieldString(parsed, "code"),
+	}, "_"))
+
+	seen := map[string]string{"Validate": "method"}
+	for _, field := range entityFields(parsed) {
+		code, _ := field["code"].(string)
+		name := goIdent(code)
+		if other, exists := seen[name]; exists {
+			return nil, fmt.Errorf("field %s: Go name %s conflicts with %s", code, name, other)
+		}
+		seen[name] = code
+
+		if err := g.field(name, field); err != nil {
+			return nil, fmt.Errorf("field %s: %w", code, err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\npackage %s\n\n", generatedHeader, g.pkgName)
+
+	g.imports["errors"] = true
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, strconv.Quote(imp))
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+
+	b.WriteString(g.decls.String())
+
+	fmt.Fprintf(&b, "%s\n", goComment(g.typeName, LocalizedText(parsed["name"], g.locale),
+		LocalizedText(parsed["description"], g.locale), EntityKey(parsed)))
+	fmt.Fprintf(&b, "type %s struct {\n%s}\n\n", g.typeName, g.fields.String())
+
+	fmt.Fprintf(&b, "// Validate checks required fields, patterns, ranges and enum values.\n")
+	fmt.Fprintf(&b, "func (x *%s) Validate() error {\n\tvar errs []error\n%s\treturn errors.Join(errs...)\n}\n",
+		g.typeName, g.validate.String())
+
+	return []byte(b.String()), nil
+}
+
+func (g *goGenerator) field(name string, field map[string]any) error {
+	code, typeStr := getFieldCodeAndType(field)
+	required, _ := field["required"].(bool)
+	prefix := g.typeName + name // package-level names of the field
+
+	goType, err := g.goType(prefix, field)
+	if err != nil {
+		return err
+	}
+
+	if comment := goComment("", LocalizedText(field["name"], g.locale),
+		LocalizedText(field["description"], g.locale), ""); comment != "" {
+		fmt.Fprintf(&g.fields, "%s\n", indent(comment))
+	}
+	fmt.Fprintf(&g.fields, "\t%s *%s `json:\"%s,omitempty\"`\n", name, goType, code)
+
+	var checks strings.Builder
+	value := "*x." + name
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+
+	switch FieldKind(typeStr) {
+	case TypeString:
+		if typeStr == string(TypeEnum) {
+			fmt.Fprintf(&checks, "if !x.%s.Valid() {\n\terrs = append(errs, fmt.Errorf(\"%s: value '%%s' is not one of allowed values\", %s))\n}\n",
+				name, code, value)
+			break
+		}
+
+		if min != nil || max != nil {
+			g.imports["unicode/utf8"] = true
+			fmt.Fprintf(&checks, "n := utf8.RuneCountInString(%s)\n", value)
+			if min != nil {
+				fmt.Fprintf(&checks, "if n < %d {\n\terrs = append(errs, fmt.Errorf(\"%s: length %%d is less than min %d\", n))\n}\n",
+					int(*min), code, int(*min))
+			}
+			if max != nil {
+				fmt.Fprintf(&checks, "if n > %d {\n\terrs = append(errs, fmt.Errorf(\"%s: length %%d is greater than max %d\", n))\n}\n",
+					int(*max), code, int(*max))
+			}
+		}
+		if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+			g.patternCheck(&checks, prefix, normalizePattern(pattern), value, code)
+		}
+
+	case TypeNumber, TypeInteger:
+		literal := func(f float64) string {
+			if FieldKind(typeStr) == TypeInteger {
+				return strconv.FormatInt(int64(f), 10)
+			}
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		if min != nil {
+			fmt.Fprintf(&checks, "if %s < %s {\n\terrs = append(errs, fmt.Errorf(\"%s: value %%v is less than min %s\", %s))\n}\n",
+				value, literal(*min), code, literal(*min), value)
+		}
+		if max != nil {
+			fmt.Fprintf(&checks, "if %s > %s {\n\terrs = append(errs, fmt.Errorf(\"%s: value %%v is greater than max %s\", %s))\n}\n",
+				value, literal(*max), code, literal(*max), value)
+		}
+
+	case TypeDecimal:
+		spec, _ := resolveDecimalSpec(field)
+		text := value
+		if goType == "json.Number" {
+			text = "x." + name + ".String()"
+		}
+		g.patternCheck(&checks, prefix, decimalPattern(spec), text, code)
+
+		if spec.Min != nil || spec.Max != nil {
+			g.imports["math/big"] = true
+			fmt.Fprintf(&checks, "if r, ok := new(big.Rat).SetString(%s); ok {\n", text)
+			if spec.Min != nil {
+				g.ratVar(prefix+"Min", spec.Min.Text)
+				fmt.Fprintf(&checks, "if r.Cmp(%sMin) < 0 {\n\terrs = append(errs, fmt.Errorf(\"%s: value %%s is less than min %s\", %s))\n}\n",
+					lowerFirst(prefix), code, spec.Min.Text, text)
+			}
+			if spec.Max != nil {
+				g.ratVar(prefix+"Max", spec.Max.Text)
+				fmt.Fprintf(&checks, "if r.Cmp(%sMax) > 0 {\n\terrs = append(errs, fmt.Errorf(\"%s: value %%s is greater than max %s\", %s))\n}\n",
+					lowerFirst(prefix), code, spec.Max.Text, text)
+			}
+			checks.WriteString("}\n")
+		}
+	}
+
+	if checks.Len() > 0 {
+		g.imports["fmt"] = true
+	}
+
+	switch {
+	case required && checks.Len() > 0:
+		fmt.Fprintf(&g.validate, "if x.%s == nil {\n\terrs = append(errs, errors.New(\"%s: required\"))\n} else {\n%s}\n", name, code, checks.String())
+	case required:
+		fmt.Fprintf(&g.validate, "if x.%s == nil {\n\terrs = append(errs, errors.New(\"%s: required\"))\n}\n", name, code)
+	case checks.Len() > 0:
+		fmt.Fprintf(&g.validate, "if x.%s != nil {\n%s}\n", name, checks.String())
+	}
+
+	return nil
+}
+
+// goType - Go type of field value, declares enum type when needed
+func (g *goGenerator) goType(prefix string, field map[string]any) (string, error) {
+	_, typeStr := getFieldCodeAndType(field)
+
+	switch FieldKind(typeStr) {
+	case TypeNumber:
+		return "float64", nil
+	case TypeInteger:
+		return "int64", nil
+	case TypeBoolean:
+		return "bool", nil
+	case TypeDecimal:
+		if encoding, _ := field["encoding"].(string); encoding == DecimalAsNumber {
+			g.imports["encoding/json"] = true
+			return "json.Number", nil
+		}
+		return "string", nil
+	}
+
+	if typeStr != string(TypeEnum) {
+		return "string", nil
+	}
+
+	// enum: named string type with constants and Valid()
+	values, _ := field["values"].([]any)
+	fmt.Fprintf(&g.decls, "// %s - allowed values of %s.\ntype %s string\n\nconst (\n", prefix, field["code"], prefix)
+
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(values))
+	for i, v := range values {
+		s, _ := v.(string)
+		name := prefix + goIdent(s)
+		if goIdent(s) == "F" || seen[name] {
+			name = fmt.Sprintf("%s%d", prefix, i+1)
+		}
+		if seen[name] {
+			return "", fmt.Errorf("cannot name enum value %q", s)
+		}
+		seen[name] = true
+		names = append(names, name)
+		fmt.Fprintf(&g.decls, "\t%s %s = %s\n", name, prefix, strconv.Quote(s))
+	}
+	g.decls.WriteString(")\n\n")
+
+	fmt.Fprintf(&g.decls, "// Valid reports whether v is one of allowed values.\nfunc (v %s) Valid() bool {\n\tswitch v {\n\tcase %s:\n\t\treturn true\n\t}\n\treturn false\n}\n\n",
+		prefix, strings.Join(names, ", "))
+
+	return prefix, nil
+}
+
+func (g *goGenerator) patternCheck(checks *strings.Builder, prefix, pattern, value, code string) {
+	g.imports["regexp"] = true
+	varName := lowerFirst(prefix) + "Pattern"
+	fmt.Fprintf(&g.decls, "var %s = regexp.MustCompile(%s)\n\n", varName, strconv.Quote(pattern))
+	fmt.Fprintf(checks, "if !%s.MatchString(%s) {\n\terrs = append(errs, fmt.Errorf(\"%s: value does not match pattern %%s\", %s))\n}\n",
+		varName, value, code, varName)
+}
+
+func (g *goGenerator) ratVar(name, text string) {
+	fmt.Fprintf(&g.decls, "var %s, _ = new(big.Rat).SetString(%s)\n\n", lowerFirst(name), strconv.Quote(text))
+}
+
+// goComment - doc comment lines; name is the identifier the comment starts with
+func goComment(ident, title, description, key string) string {
+	var lines []string
+
+	head := title
+	if ident != "" {
+		head = ident
+		if title != "" {
+			head += " - " + title
+		}
+	}
+	if key != "" {
+		head += " (" + key + ")"
+	}
+	if head != "" {
+		lines = append(lines, "// "+head+".")
+	}
+	if description != "" {
+		lines = append(lines, "//")
+		for _, line := range strings.Split(description, "\n") {
+			lines = append(lines, strings.TrimRight("// "+line, " "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func indent(s string) string {
+	return "\t" + strings.ReplaceAll(s, "\n", "\n\t")
+}
@@ -0,0 +1,41 @@
+package preset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateGoCollisions(t *testing.T) {
+	field := map[string]any{"code": "id", "type": "string"}
+	tests := []struct {
+		name     string
+		entities []ProcessedEntity
+		errText  string
+	}{
+		{"package", []ProcessedEntity{
+			testEntity("crm_x", "client", "requisite", "a", field),
+			testEntity("crmx", "client", "requisite", "b", field),
+		}, "package crmx already generated for module 'crm_x'"},
+		{"case-only file", []ProcessedEntity{
+			testEntity("crm", "client", "requisite", "OOO", field),
+			testEntity("crm", "client", "requisite", "ooo", field),
+		}, "file crm/client_requisite_ooo.gen.go of crm.client.requisite.ooo already generated for crm.client.requisite.OOO"},
+		{"distinct", []ProcessedEntity{
+			testEntity("crm", "client", "requisite", "a", field),
+			testEntity("hr", "client", "requisite", "a", field),
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := GenerateGo(&Package{Name: "test", Version: "1.0.0"}, tt.entities)
+			switch {
+			case tt.errText == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.errText == "" && len(files) != 4:
+				t.Errorf("generated %d files, want 4", len(files))
+			case tt.errText != "" && (err == nil || !strings.Contains(err.Error(), tt.errText)):
+				t.Errorf("error = %v, want %q", err, tt.errText)
+			}
+		})
+	}
+}
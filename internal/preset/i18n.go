@@ -0,0 +1,197 @@
+package preset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// I18n schema modes
+const (
+	I18nAnnotations = "annotations" // title in default locale + x-i18n with all translations
+	I18nSplit       = "split"       // separate schema per package locale
+)
+
+// PackageLocale - default locale of the package (default_locale or first of locales)
+func PackageLocale(pkg *Package) string {
+	if pkg == nil {
+		return ""
+	}
+	if pkg.DefaultLocale != "" {
+		return pkg.DefaultLocale
+	}
+	if len(pkg.Locales) > 0 {
+		return pkg.Locales[0]
+	}
+	return ""
+}
+
+// LocalizedText - text of name/description in locale; plain strings are returned as is,
+// locale maps fall back to the first locale in sorted order
+func LocalizedText(v any, locale string) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case map[string]any:
+		if s, ok := t[locale].(string); ok && s != "" {
+			return s
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := t[k].(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// localizedMap - all translations of locale map, nil for plain strings
+func localizedMap(v any) map[string]string {
+	t, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	result := make(map[string]string, len(t))
+	for locale, text := range t {
+		if s, ok := text.(string); ok {
+			result[locale] = s
+		}
+	}
+	return result
+}
+
+// validateLocalized - check shape of name/description value and translations for locales
+func validateLocalized(v any, locales []string) []string {
+	switch t := v.(type) {
+	case string:
+		if len(locales) > 1 {
+			return []string{fmt.Sprintf("not translated, expected locales: %s", strings.Join(locales, ", "))}
+		}
+		return nil
+
+	case map[string]any:
+		var errors []string
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := t[k].(string); !ok || s == "" {
+				errors = append(errors, fmt.Sprintf("locale '%s' must be non-empty string", k))
+			}
+		}
+
+		var missing []string
+		for _, locale := range locales {
+			if _, ok := t[locale]; !ok {
+				missing = append(missing, locale)
+			}
+		}
+		if len(missing) > 0 {
+			errors = append(errors, fmt.Sprintf("missing translations: %s", strings.Join(missing, ", ")))
+		}
+		if len(locales) > 0 {
+			known := make(map[string]bool, len(locales))
+			for _, locale := range locales {
+				known[locale] = true
+			}
+			for _, k := range keys {
+				if !known[k] {
+					errors = append(errors, fmt.Sprintf("locale '%s' is not declared in package locales", k))
+				}
+			}
+		}
+		return errors
+	}
+
+	return []string{"must be string or locale map"}
+}
+
+// validateTranslations - names and descriptions of entity and fields
+func validateTranslations(data map[string]any, locales []string) []string {
+	var errors []string
+
+	for _, key := range []string{"name", "description"} {
+		if v, exists := data[key]; exists {
+			for _, err := range validateLocalized(v, locales) {
+				errors = append(errors, fmt.Sprintf("%s: %s", key, err))
+			}
+		}
+	}
+
+	fields, _ := data["fields"].([]any)
+	for _, fieldAny := range fields {
+		field, ok := fieldAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		code, _ := field["code"].(string)
+		for _, key := range []string{"name", "description"} {
+			if v, exists := field[key]; exists {
+				for _, err := range validateLocalized(v, locales) {
+					errors = append(errors, fmt.Sprintf("field %s: %s: %s", code, key, err))
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// i18nAnnotation - x-i18n block for localized name/description, nil if nothing is localized
+func i18nAnnotation(name, description any) map[string]any {
+	annotation := make(map[string]any)
+	if m := localizedMap(name); m != nil {
+		annotation["title"] = m
+	}
+	if m := localizedMap(description); m != nil {
+		annotation["description"] = m
+	}
+	if len(annotation) == 0 {
+		return nil
+	}
+	return annotation
+}
+
+// SplitSchemasByLocale - one JSON Schema per package locale for every valid entity,
+// the main schema is regenerated without x-i18n annotations
+func SplitSchemasByLocale(pkg *Package, processed []ProcessedEntity) error {
+	locales := pkg.Locales
+	if len(locales) == 0 && PackageLocale(pkg) != "" {
+		locales = []string{PackageLocale(pkg)}
+	}
+
+	for i := range processed {
+		pe := &processed[i]
+		if pe.Schema == nil {
+			continue
+		}
+
+		opts := SchemaOptions{Region: pkg.Region, Locale: PackageLocale(pkg)}
+		schema, err := GenerateJSONSchema(pe.ParsedData, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pe.File.Path, err)
+		}
+		pe.Schema = schema
+
+		pe.LocalizedSchemas = make(map[string]map[string]any, len(locales))
+		for _, locale := range locales {
+			opts.Locale = locale
+			schema, err := GenerateJSONSchema(pe.ParsedData, opts)
+			if err != nil {
+				return fmt.Errorf("%s: %w", pe.File.Path, err)
+			}
+			schema["$id"] = fmt.Sprintf("/%s/%s/%s/schema.%s.json",
+				pe.ParsedData["module"], pe.ParsedData["object"], pe.ParsedData["code"], locale)
+			pe.LocalizedSchemas[locale] = schema
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,362 @@
+package preset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// lint severities; on - default severity of the rule
+const (
+	LintOff     = "off"
+	LintOn      = "on"
+	LintWarning = "warning"
+	LintError   = "error"
+)
+
+// LintRule - check of style and likely mistakes that are not validation errors
+type LintRule struct {
+	Name        string
+	Description string
+	Severity    string // default severity: warning or error
+
+	// findings of one entity; Field and Key point to the node the finding is about
+	Check func(e *LintEntity) []LintFinding
+}
+
+// LintEntity - entity file under lint
+type LintEntity struct {
+	Path   string
+	Parsed map[string]any // as read by the processor
+}
+
+// LintFinding - finding of rule: Field - field code ("" - entity), Key - key of the field or entity
+type LintFinding struct {
+	Field   string
+	Key     string
+	Message string
+}
+
+// LintIssue - finding with rule, severity and position
+type LintIssue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"` // 1-based, 0 - unknown
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s:%d: %s %s: %s", i.Path, i.Line, i.Severity, i.Rule, i.Message)
+}
+
+// LintConfig - lint section of yieldaa.yml: rule -> off|on|warning|error
+type LintConfig struct {
+	Rules map[string]string `yaml:"rules" json:"rules,omitempty"`
+}
+
+// UnmarshalJSON - unquoted off/on are booleans in YAML 1.1; rules are set over the
+// rules already configured (yieldaa.yml over package.yml)
+func (c *LintConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Rules map[string]any `json:"rules"`
+	}
+	if err := decodeJSONStrict(data, &raw); err != nil {
+		return fmt.Errorf("lint: %w", err)
+	}
+	rules := make(map[string]string, len(c.Rules)+len(raw.Rules))
+	for name, severity := range c.Rules {
+		rules[name] = severity
+	}
+	c.Rules = rules
+	for name, v := range raw.Rules {
+		switch v := v.(type) {
+		case string:
+			c.Rules[name] = v
+		case bool:
+			c.Rules[name] = LintOff
+			if v {
+				c.Rules[name] = LintOn
+			}
+		default:
+			return fmt.Errorf("lint: rule %s: severity must be off, on, warning or error", name)
+		}
+	}
+	return nil
+}
+
+var (
+	lintMu    sync.RWMutex
+	lintRules = make(map[string]LintRule)
+)
+
+// RegisterLintRule - add lint rule, replaces existing one with same name
+func RegisterLintRule(r LintRule) {
+	if r.Name == "" || r.Check == nil {
+		panic("preset: lint rule must have name and check func")
+	}
+	if r.Severity == "" {
+		r.Severity = LintWarning
+	}
+	if r.Severity != LintWarning && r.Severity != LintError {
+		panic("preset: lint rule severity must be warning or error")
+	}
+
+	lintMu.Lock()
+	defer lintMu.Unlock()
+	lintRules[r.Name] = r
+}
+
+// LookupLintRule - find lint rule by name
+func LookupLintRule(name string) (LintRule, bool) {
+	lintMu.RLock()
+	defer lintMu.RUnlock()
+
+	r, ok := lintRules[name]
+	return r, ok
+}
+
+// LintRules - registered rules sorted by name
+func LintRules() []LintRule {
+	lintMu.RLock()
+	defer lintMu.RUnlock()
+
+	rules := make([]LintRule, 0, len(lintRules))
+	for _, r := range lintRules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// severities - effective severity of every rule, unknown rules and severities are errors
+func (c LintConfig) severities() (map[string]string, error) {
+	result := make(map[string]string)
+	for _, r := range LintRules() {
+		result[r.Name] = r.Severity
+	}
+	for name, severity := range c.Rules {
+		if _, ok := result[name]; !ok {
+			return nil, fmt.Errorf("lint: unknown rule '%s'", name)
+		}
+		switch severity {
+		case LintOn:
+		case LintOff, LintWarning, LintError:
+			result[name] = severity
+		default:
+			return nil, fmt.Errorf("lint: rule %s: severity must be off, on, warning or error, got '%s'", name, severity)
+		}
+	}
+	return result, nil
+}
+
+// LoadLintConfig - lint section of the build config of dir; no config - defaults
+func LoadLintConfig(dir string) (LintConfig, error) {
+	cfg, err := LoadBuildConfig(dir)
+	if err != nil {
+		return LintConfig{}, err
+	}
+	return cfg.Lint, nil
+}
+
+// LintPreset - lint entity files of dir with config of yieldaa.yml
+func LintPreset(dir string) ([]LintIssue, error) {
+	return lintPreset(dirFS(dir))
+}
+
+// LintPresetFS - LintPreset for preset at the root of fsys
+func LintPresetFS(fsys fs.FS) ([]LintIssue, error) {
+	return lintPreset(presetFS{fsys: fsys})
+}
+
+func lintPreset(p presetFS) ([]LintIssue, error) {
+	build, err := loadBuildConfig(p)
+	if err != nil {
+		return nil, err
+	}
+	cfg := build.Lint
+	files, err := scanEntities(p, "entities")
+	if err != nil {
+		return nil, fmt.Errorf("entities scan failed: %w", err)
+	}
+
+	var issues []LintIssue
+	for _, f := range files {
+		data, err := readEntityFile(f)
+		if err != nil {
+			return nil, err
+		}
+		found, err := LintEntityFile(f.Path, data, cfg)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// LintEntityFile - issues of one entity file sorted by line; files that do not parse
+// have no issues, the processor reports them
+func LintEntityFile(path string, data []byte, cfg LintConfig) ([]LintIssue, error) {
+	severities, err := cfg.severities()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, nil
+	}
+	var parsed map[string]any
+	if json.Unmarshal(jsonData, &parsed) != nil || parsed == nil {
+		return nil, nil
+	}
+	var doc yamlv3.Node
+	if yamlv3.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	ignores := parseLintIgnores(data, root)
+
+	entity := &LintEntity{Path: path, Parsed: parsed}
+	var issues []LintIssue
+	for _, rule := range LintRules() {
+		severity := severities[rule.Name]
+		if severity == LintOff {
+			continue
+		}
+		for _, f := range rule.Check(entity) {
+			line := findingLine(root, f)
+			if ignores.ignored(rule.Name, line, f.Field) {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Rule:     rule.Name,
+				Severity: severity,
+				Path:     path,
+				Line:     line,
+				Field:    f.Field,
+				Message:  f.Message,
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues, nil
+}
+
+// findingLine - line of the key of field or entity the finding is about
+func findingLine(root *yamlv3.Node, f LintFinding) int {
+	m := root
+	if f.Field != "" {
+		m = nil
+		if _, fields := mappingNode(root, "fields"); fields != nil {
+			for _, item := range fields.Content {
+				if _, code := mappingNode(item, "code"); code != nil && code.Value == f.Field {
+					m = item
+					break
+				}
+			}
+		}
+		if m == nil {
+			return 0
+		}
+	}
+	if f.Key != "" {
+		if k, _ := mappingNode(m, f.Key); k != nil {
+			return k.Line
+		}
+	}
+	return m.Line
+}
+
+var lintIgnoreRegex = regexp.MustCompile(`#\s*yieldaa:ignore\s+([A-Za-z0-9_,\s-]+)`)
+
+// lintIgnores - rules suppressed by "# yieldaa:ignore RULE[,RULE]" comments:
+// in the head of the file - whole file, on or above the first line of a field - whole field,
+// elsewhere - the line of the comment (trailing) or the next line (own line)
+type lintIgnores struct {
+	file   map[string]bool
+	fields map[string]map[string]bool // field code -> rules
+	lines  map[int]map[string]bool    // 1-based line -> rules
+}
+
+func parseLintIgnores(data []byte, root *yamlv3.Node) lintIgnores {
+	ig := lintIgnores{
+		file:   make(map[string]bool),
+		fields: make(map[string]map[string]bool),
+		lines:  make(map[int]map[string]bool),
+	}
+
+	firstKeyLine := 0
+	if len(root.Content) > 0 {
+		firstKeyLine = root.Content[0].Line
+	}
+	fieldStarts := make(map[int]string) // first line of field -> code
+	if _, fields := mappingNode(root, "fields"); fields != nil {
+		for _, item := range fields.Content {
+			if _, code := mappingNode(item, "code"); code != nil {
+				fieldStarts[item.Line] = code.Value
+			}
+		}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, text := range lines {
+		m := lintIgnoreRegex.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		rules := make(map[string]bool)
+		for _, name := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			rules[name] = true
+		}
+
+		line := i + 1
+		ownLine := strings.HasPrefix(strings.TrimSpace(text), "#")
+		target := line
+		if ownLine {
+			// the next line that is not a comment
+			for target = line + 1; target <= len(lines); target++ {
+				if t := strings.TrimSpace(lines[target-1]); t != "" && !strings.HasPrefix(t, "#") {
+					break
+				}
+			}
+		}
+
+		switch {
+		case ownLine && line < firstKeyLine:
+			addRules(ig.file, rules)
+		case fieldStarts[target] != "":
+			code := fieldStarts[target]
+			if ig.fields[code] == nil {
+				ig.fields[code] = make(map[string]bool)
+			}
+			addRules(ig.fields[code], rules)
+		default:
+			if ig.lines[target] == nil {
+				ig.lines[target] = make(map[string]bool)
+			}
+			addRules(ig.lines[target], rules)
+		}
+	}
+	return ig
+}
+
+func addRules(dst, rules map[string]bool) {
+	for name := range rules {
+		dst[name] = true
+	}
+}
+
+func (ig lintIgnores) ignored(rule string, line int, field string) bool {
+	return ig.file[rule] || ig.fields[field][rule] || ig.lines[line][rule]
+}
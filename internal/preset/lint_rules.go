@@ -0,0 +1,142 @@
+package preset
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// built-in lint rules
+func init() {
+	RegisterLintRule(LintRule{
+		Name:        "field-examples",
+		Description: "field has no examples",
+		Check: eachField(func(code string, field map[string]any) []LintFinding {
+			if examples, _ := field["examples"].([]any); len(examples) > 0 {
+				return nil
+			}
+			if _, ok := field["values"]; ok {
+				// values of enum are examples already
+				return nil
+			}
+			if _, typeStr := getFieldCodeAndType(field); typeStr == string(TypeBoolean) || typeStr == string(TypeReference) {
+				return nil
+			}
+			return []LintFinding{{Field: code, Message: "field has no examples"}}
+		}),
+	})
+	RegisterLintRule(LintRule{
+		Name:        "required-default",
+		Description: "required field has default, the default is never used",
+		Check: eachField(func(code string, field map[string]any) []LintFinding {
+			if required, _ := field["required"].(bool); !required {
+				return nil
+			}
+			if _, ok := field["default"]; !ok {
+				return nil
+			}
+			return []LintFinding{{Field: code, Key: "default", Message: "required field has default, the default is never used"}}
+		}),
+	})
+	RegisterLintRule(LintRule{
+		Name:        "string-max",
+		Description: "string field has no max length",
+		Check: eachField(func(code string, field map[string]any) []LintFinding {
+			if _, typeStr := getFieldCodeAndType(field); typeStr != string(TypeString) {
+				return nil
+			}
+			if getNumberValue(field, "max") != nil {
+				return nil
+			}
+			return []LintFinding{{Field: code, Key: "type", Message: "string field has no max length"}}
+		}),
+	})
+	RegisterLintRule(LintRule{
+		Name:        "pattern-anchors",
+		Description: "pattern is not anchored with ^...$ and matches substrings",
+		Check: eachField(func(code string, field map[string]any) []LintFinding {
+			pattern, _ := field["pattern"].(string)
+			if pattern == "" {
+				return nil
+			}
+			pattern = normalizePattern(pattern)
+			if strings.HasPrefix(pattern, "^") && strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+				return nil
+			}
+			return []LintFinding{{Field: code, Key: "pattern",
+				Message: fmt.Sprintf("pattern %s is not anchored with ^...$, it matches substrings", pattern)}}
+		}),
+	})
+	RegisterLintRule(LintRule{
+		Name:        "enum-case",
+		Description: "enum values differ only in case",
+		Check: eachField(func(code string, field map[string]any) []LintFinding {
+			values, _ := field["values"].([]any)
+			seen := make(map[string]string)
+			var findings []LintFinding
+			for _, v := range values {
+				s, ok := v.(string)
+				if !ok {
+					continue
+				}
+				lower := strings.ToLower(s)
+				if other, dup := seen[lower]; dup && other != s {
+					findings = append(findings, LintFinding{Field: code, Key: "values",
+						Message: fmt.Sprintf("enum values '%s' and '%s' differ only in case", other, s)})
+					continue
+				}
+				seen[lower] = s
+			}
+			return findings
+		}),
+	})
+	RegisterLintRule(LintRule{
+		Name:        "code-filename",
+		Description: "entity code does not match file name",
+		Check: func(e *LintEntity) []LintFinding {
+			code := GetFieldString(e.Parsed, "code")
+			name := strings.TrimSuffix(filepath.Base(e.Path), filepath.Ext(e.Path))
+			if code == "" || code == name {
+				return nil
+			}
+			return []LintFinding{{Key: "code",
+				Message: fmt.Sprintf("entity code '%s' does not match file name '%s'", code, filepath.Base(e.Path))}}
+		},
+	})
+	RegisterLintRule(LintRule{
+		Name:        "snake-case",
+		Description: "entity key parts and field codes are not snake_case",
+		Check: func(e *LintEntity) []LintFinding {
+			var findings []LintFinding
+			for _, key := range []string{"module", "object", "property", "code"} {
+				if v := GetFieldString(e.Parsed, key); v != "" && !snakeCaseRegex.MatchString(v) {
+					findings = append(findings, LintFinding{Key: key,
+						Message: fmt.Sprintf("%s '%s' is not snake_case", key, v)})
+				}
+			}
+			for _, field := range entityFields(e.Parsed) {
+				if code, _ := getFieldCodeAndType(field); code != "" && !snakeCaseRegex.MatchString(code) {
+					findings = append(findings, LintFinding{Field: code, Key: "code",
+						Message: fmt.Sprintf("field code '%s' is not snake_case", code)})
+				}
+			}
+			return findings
+		},
+	})
+}
+
+var snakeCaseRegex = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// eachField - rule that checks every field of the entity
+func eachField(check func(code string, field map[string]any) []LintFinding) func(e *LintEntity) []LintFinding {
+	return func(e *LintEntity) []LintFinding {
+		var findings []LintFinding
+		for _, field := range entityFields(e.Parsed) {
+			if code, _ := getFieldCodeAndType(field); code != "" {
+				findings = append(findings, check(code, field)...)
+			}
+		}
+		return findings
+	}
+}
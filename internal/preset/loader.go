@@ -1,40 +1,52 @@
 package preset
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 )
 
 // preset loader
 func LoadPreset(dir string) (*Package, error) {
+	return loadPreset(dirFS(dir))
+}
+
+// LoadPresetFS - preset at the root of fsys: embed.FS, archive, overlay, in-memory files;
+// paths of entity files are slash paths of fsys
+func LoadPresetFS(fsys fs.FS) (*Package, error) {
+	return loadPreset(presetFS{fsys: fsys})
+}
+
+func loadPreset(p presetFS) (*Package, error) {
 	// scan package.yml
-	packageData, err := loadConfig(dir)
+	packageData, err := loadConfig(p)
 	if err != nil {
 		return nil, fmt.Errorf("package load failed: %w", err)
 	}
 
 	// scan entities
-	entitiesDir := filepath.Join(dir, "entities")
-	if _, err := os.Stat(entitiesDir); os.IsNotExist(err) {
+	if _, err := fs.Stat(p.fsys, "entities"); errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("package does not have entities in the /entities directory")
 	}
-	entityFiles, err := ScanEntities(entitiesDir)
+	entityFiles, err := scanEntities(p, "entities")
 	if err != nil {
 		return nil, fmt.Errorf("entities scan failed: %w", err)
 	}
 
-	// entities meta to pkg struct
-	packageData.EntitiesFiles = entityFiles
-	packageData.EntitiesCount = len(entityFiles)
+	setEntityFiles(packageData, entityFiles)
+
+	return packageData, nil
+}
+
+// setEntityFiles - entities meta and control sum to pkg struct
+func setEntityFiles(pkg *Package, files []EntityFile) {
+	pkg.EntitiesFiles = files
+	pkg.EntitiesCount = len(files)
 
-	// control sum
 	totalSize := int64(0)
-	for _, f := range entityFiles {
+	for _, f := range files {
 		totalSize += f.Size
 	}
-	packageData.EntitiesTotalSize = totalSize
-	packageData.EntitiesStructureHash = calculateStructureHash(entityFiles)
-
-	return packageData, nil
+	pkg.EntitiesTotalSize = totalSize
+	pkg.EntitiesStructureHash = calculateStructureHash(files)
 }
@@ -0,0 +1,173 @@
+package preset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const OpenAPIVersion = "3.1.0"
+
+// GenerateOpenAPI - OpenAPI 3.1 document with one component schema per valid entity
+func GenerateOpenAPI(pkg *Package, processed []ProcessedEntity) (map[string]any, error) {
+	info := map[string]any{
+		"title":   pkg.Name,
+		"version": pkg.Version,
+	}
+	if pkg.Description != "" {
+		info["description"] = pkg.Description
+	}
+
+	names := make(map[string]string)  // entity key -> component name
+	owners := make(map[string]string) // component name -> entity key
+	for _, pe := range processed {
+		if pe.Schema == nil || pe.ParsedData == nil {
+			continue
+		}
+		key := EntityKey(pe.ParsedData)
+		name := componentName(key)
+		if other, exists := owners[name]; exists {
+			if other == key {
+				return nil, fmt.Errorf("%s: duplicate component '%s'", pe.File.Path, key)
+			}
+			return nil, fmt.Errorf("%s: component %s of '%s' already generated for '%s'", pe.File.Path, name, key, other)
+		}
+		owners[name] = key
+		names[key] = name
+	}
+
+	schemas := make(map[string]any)
+	objectTags := make(map[string]map[string]bool) // module -> module.object
+
+	for _, pe := range processed {
+		if pe.Schema == nil || pe.ParsedData == nil {
+			continue
+		}
+
+		module := GetFieldString(pe.ParsedData, "module")
+		object := GetFieldString(pe.ParsedData, "object")
+		tag := module + "." + object
+
+		schemas[names[EntityKey(pe.ParsedData)]] = componentSchema(pe.Schema, module, object, names)
+
+		if objectTags[module] == nil {
+			objectTags[module] = make(map[string]bool)
+		}
+		objectTags[module][tag] = true
+	}
+
+	// package tags first, then module.object tags for grouping
+	tags := make([]any, 0, len(pkg.Tags))
+	for _, tag := range pkg.Tags {
+		tags = append(tags, map[string]any{"name": tag})
+	}
+
+	modules := make([]string, 0, len(objectTags))
+	for module := range objectTags {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	tagGroups := make([]any, 0, len(modules))
+	for _, module := range modules {
+		names := make([]string, 0, len(objectTags[module]))
+		for tag := range objectTags[module] {
+			names = append(names, tag)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			tags = append(tags, map[string]any{"name": name})
+		}
+		tagGroups = append(tagGroups, map[string]any{"name": module, "tags": names})
+	}
+
+	doc := map[string]any{
+		"openapi":           OpenAPIVersion,
+		"info":              info,
+		"jsonSchemaDialect": JSONSchemaDraft,
+		"tags":              tags,
+		"paths":             map[string]any{},
+		"components":        map[string]any{"schemas": schemas},
+		"x-tagGroups":       tagGroups,
+		"x-package": map[string]any{
+			"name":    pkg.Name,
+			"version": pkg.Version,
+			"region":  pkg.Region,
+			"tags":    pkg.Tags,
+		},
+	}
+
+	return doc, nil
+}
+
+// componentName - name of entity component: characters of entity key outside of
+// [a-zA-Z0-9._-] (allowed by OpenAPI in component keys) are written as _uXXXX
+func componentName(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "_u%04X", r)
+		}
+	}
+	return b.String()
+}
+
+// componentSchema - entity schema without document-level keywords; reference fields
+// are $ref to the component of referenced entity
+func componentSchema(schema map[string]any, module, object string, names map[string]string) map[string]any {
+	component := make(map[string]any, len(schema)+2)
+	for k, v := range schema {
+		if k == "$schema" || k == "$id" {
+			continue
+		}
+		component[k] = v
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		component["properties"] = componentProperties(properties, names)
+	}
+	component["x-module"] = module
+	component["x-object"] = object
+	component["x-tags"] = []string{module + "." + object}
+	return component
+}
+
+// componentProperties - copy of properties with reference fields as $ref; a reference
+// to entity without component stays an id
+func componentProperties(properties map[string]any, names map[string]string) map[string]any {
+	result := make(map[string]any, len(properties))
+	for code, prop := range properties {
+		result[code] = prop
+		field, ok := prop.(map[string]any)
+		if !ok {
+			continue
+		}
+		ref, ok := field["x-ref"].(string)
+		if !ok {
+			continue
+		}
+
+		name, exists := names[ref]
+		if !exists {
+			id := make(map[string]any, len(field))
+			for k, v := range field {
+				if k != "x-ref" {
+					id[k] = v
+				}
+			}
+			result[code] = id
+			continue
+		}
+		refSchema := map[string]any{"$ref": "#/components/schemas/" + name}
+		for _, k := range []string{"title", "description", "x-i18n"} {
+			if v, exists := field[k]; exists {
+				refSchema[k] = v
+			}
+		}
+		result[code] = refSchema
+	}
+	return result
+}
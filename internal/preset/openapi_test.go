@@ -0,0 +1,82 @@
+package preset
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func openAPIEntity(module, object, property, code string, fields ...map[string]any) ProcessedEntity {
+	pe := testEntity(module, object, property, code, fields...)
+	schema, err := GenerateJSONSchema(pe.ParsedData, SchemaOptions{})
+	if err != nil {
+		panic(err)
+	}
+	pe.Schema = schema
+	return pe
+}
+
+func TestOpenAPIComponentNames(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"crm.client.requisite.individual", "crm.client.requisite.individual"},
+		{"crm.client-x.req_1.A", "crm.client-x.req_1.A"},
+		{"crm.клиент.requisite.ип", "crm._u043A_u043B_u0438_u0435_u043D_u0442.requisite._u0438_u043F"},
+		{"crm.client/x.requisite.a b", "crm.client_u002Fx.requisite.a_u0020b"},
+	}
+	valid := regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+	for _, tt := range tests {
+		got := componentName(tt.key)
+		if got != tt.want || !valid.MatchString(got) {
+			t.Errorf("componentName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateOpenAPIReferences(t *testing.T) {
+	id := map[string]any{"code": "inn", "type": "string"}
+	processed := []ProcessedEntity{
+		openAPIEntity("crm", "клиент", "requisite", "company", id),
+		openAPIEntity("crm", "contract", "requisite", "main",
+			map[string]any{"code": "company_id", "name": "Company", "type": "reference", "ref": "crm.клиент.requisite.company"},
+			map[string]any{"code": "bank_id", "name": "Bank", "type": "reference", "ref": "crm.bank.requisite.main"}),
+	}
+	doc, err := GenerateOpenAPI(&Package{Name: "test", Version: "1.0.0"}, processed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	company := "crm._u043A_u043B_u0438_u0435_u043D_u0442.requisite.company"
+	if _, exists := schemas[company]; !exists {
+		t.Fatalf("no component %s in %v", company, reflect.ValueOf(schemas).MapKeys())
+	}
+	properties := schemas["crm.contract.requisite.main"].(map[string]any)["properties"].(map[string]any)
+	want := map[string]any{"$ref": "#/components/schemas/" + company, "title": "Company"}
+	if got := properties["company_id"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("company_id = %v, want %v", got, want)
+	}
+	want = map[string]any{"type": "integer", "minimum": 1, "title": "Bank"}
+	if got := properties["bank_id"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("bank_id = %v, want %v", got, want)
+	}
+
+	// entity schema is not changed by document
+	if _, ok := processed[1].Schema["properties"].(map[string]any)["company_id"].(map[string]any)["x-ref"]; !ok {
+		t.Error("x-ref removed from entity schema")
+	}
+}
+
+func TestGenerateOpenAPICollisions(t *testing.T) {
+	id := map[string]any{"code": "id", "type": "string"}
+	_, err := GenerateOpenAPI(&Package{Name: "test", Version: "1.0.0"}, []ProcessedEntity{
+		openAPIEntity("crm", "a b", "requisite", "x", id),
+		openAPIEntity("crm", "a_u0020b", "requisite", "x", id),
+	})
+	want := "component crm.a_u0020b.requisite.x of 'crm.a_u0020b.requisite.x' already generated for 'crm.a b.requisite.x'"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/ghodss/yaml"
 )
 
 func SaveEntitiesToJSON(processed []ProcessedEntity, outputPath string) error {
@@ -17,25 +20,79 @@ func SaveEntitiesToJSON(processed []ProcessedEntity, outputPath string) error {
 		output = append(output, entityOutput)
 	}
 
-	dir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("create directory: %w", err)
+	if err := writeJSONFile(output, outputPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %d entities to %s\n", len(output), outputPath)
+	return nil
+}
+
+// SaveDocument - write generated document (OpenAPI etc) as JSON, or YAML for .yml/.yaml paths
+func SaveDocument(doc any, outputPath string) error {
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	if ext != ".yml" && ext != ".yaml" {
+		return writeJSONFile(doc, outputPath)
 	}
 
-	file, err := os.Create(outputPath)
+	jsonData, err := json.Marshal(doc)
 	if err != nil {
-		return fmt.Errorf("create file: %w", err)
+		return fmt.Errorf("encode JSON: %w", err)
 	}
-	defer file.Close()
+	yamlData, err := yaml.JSONToYAML(jsonData)
+	if err != nil {
+		return fmt.Errorf("JSON→YAML: %w", err)
+	}
+	return writeFile(yamlData, outputPath)
+}
+
+// SaveFiles - write generated files (relative path -> content) under dir
+func SaveFiles(files map[string][]byte, dir string) error {
+	for name, data := range files {
+		if err := writeFile(data, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
 
-	encoder := json.NewEncoder(file)
+func writeJSONFile(v any, outputPath string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
 	encoder.SetIndent("", "  ")
 
-	if err := encoder.Encode(output); err != nil {
+	if err := encoder.Encode(v); err != nil {
 		return fmt.Errorf("write JSON: %w", err)
 	}
+	return writeFile(buf.Bytes(), outputPath)
+}
 
-	fmt.Printf("Saved %d entities to %s\n", len(output), outputPath)
+// writeFile - temp file in the same directory and rename, readers never see partial data
+func writeFile(data []byte, outputPath string) error {
+	dir := filepath.Dir(outputPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("chmod file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), outputPath); err != nil {
+		return fmt.Errorf("replace file: %w", err)
+	}
 	return nil
 }
 
@@ -62,9 +119,7 @@ func convertToEntityOutput(pe ProcessedEntity) EntityOutput {
 		if code, ok := pe.ParsedData["code"].(string); ok {
 			metadata.Code = code
 		}
-		if name, ok := pe.ParsedData["name"].(string); ok {
-			metadata.Name = name
-		}
+		metadata.Name = LocalizedText(pe.ParsedData["name"], pe.Locale)
 	}
 
 	var jsonDataStr string
@@ -95,5 +150,7 @@ func convertToEntityOutput(pe ProcessedEntity) EntityOutput {
 		JSONData:   jsonDataStr,
 		Schema:     pe.Schema,
 		Validation: validation,
+
+		LocalizedSchemas: pe.LocalizedSchemas,
 	}
 }
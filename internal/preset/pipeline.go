@@ -1,8 +1,23 @@
 package preset
 
+import "io/fs"
+
 // centralized entrypoint
 func LoadAndProcessPreset(dir string, workers int) (*Package, []ProcessedEntity, []error) {
-	pkg, err := LoadPreset(dir)
+	return LoadAndProcessPresetWith(dir, ProcessOptions{Workers: workers})
+}
+
+func LoadAndProcessPresetWith(dir string, opts ProcessOptions) (*Package, []ProcessedEntity, []error) {
+	return loadAndProcess(dirFS(dir), opts)
+}
+
+// LoadAndProcessPresetFS - LoadAndProcessPresetWith for preset at the root of fsys
+func LoadAndProcessPresetFS(fsys fs.FS, opts ProcessOptions) (*Package, []ProcessedEntity, []error) {
+	return loadAndProcess(presetFS{fsys: fsys}, opts)
+}
+
+func loadAndProcess(p presetFS, opts ProcessOptions) (*Package, []ProcessedEntity, []error) {
+	pkg, err := loadPreset(p)
 	if err != nil {
 		return nil, nil, []error{err}
 	}
@@ -11,6 +26,6 @@ func LoadAndProcessPreset(dir string, workers int) (*Package, []ProcessedEntity,
 		return pkg, []ProcessedEntity{}, nil
 	}
 
-	processed, fatalErrors := ProcessEntities(pkg.EntitiesFiles, workers)
+	processed, fatalErrors := ProcessEntitiesWith(pkg.EntitiesFiles, pkg, opts)
 	return pkg, processed, fatalErrors
 }
@@ -2,19 +2,26 @@ package preset
 
 import (
 	"fmt"
-	"os"
 	"sync"
 	"time"
 )
 
-func ProcessEntities(files []EntityFile, maxWorkers int) ([]ProcessedEntity, []error) {
+// ProcessOptions - options of entities processing
+type ProcessOptions struct {
+	Workers int
+	Cache   *BuildCache // nil - every file is processed
+}
+
+func ProcessEntities(files []EntityFile, pkg *Package, maxWorkers int) ([]ProcessedEntity, []error) {
+	return ProcessEntitiesWith(files, pkg, ProcessOptions{Workers: maxWorkers})
+}
+
+func ProcessEntitiesWith(files []EntityFile, pkg *Package, opts ProcessOptions) ([]ProcessedEntity, []error) {
 	if len(files) == 0 {
 		return []ProcessedEntity{}, nil
 	}
 
-	if maxWorkers <= 0 {
-		maxWorkers = DefaultWorkers
-	}
+	maxWorkers := workersOf(opts, pkg)
 	if maxWorkers > len(files) {
 		maxWorkers = len(files)
 	}
@@ -31,6 +38,8 @@ func ProcessEntities(files []EntityFile, maxWorkers int) ([]ProcessedEntity, []e
 
 	var conflictsMu sync.Mutex // мьютекс для keyConflicts
 
+	duplicates := buildConfig(pkg).Duplicates
+
 	// Workers
 	for i := 0; i < maxWorkers; i++ {
 		wg.Add(1)
@@ -39,7 +48,7 @@ func ProcessEntities(files []EntityFile, maxWorkers int) ([]ProcessedEntity, []e
 			for file := range jobs {
 				progress.StartJob()
 
-				content, err := os.ReadFile(file.Path)
+				content, err := readEntityFile(file)
 				if err != nil {
 					errors <- fmt.Errorf("%s: read: %w", file.Path, err)
 					progress.CompleteJob()
@@ -50,12 +59,15 @@ func ProcessEntities(files []EntityFile, maxWorkers int) ([]ProcessedEntity, []e
 				contentHash := calculateContentHash(content)
 
 				// Atomic check and store
-				if _, alreadyProcessed := seenHashes.LoadOrStore(contentHash, true); alreadyProcessed {
+				if existingFile, alreadyProcessed := seenHashes.LoadOrStore(contentHash, file.Path); alreadyProcessed {
+					if duplicates == DuplicatesError {
+						results <- duplicateContent(file, contentHash, existingFile.(string))
+					}
 					progress.CompleteJob()
 					continue
 				}
 
-				result := ProcessEntity(file, content)
+				result := processContent(file, content, contentHash, pkg, opts.Cache)
 
 				// check entity key
 				if result.ParsedData != nil && result.FatalError == nil {
@@ -130,5 +142,31 @@ func ProcessEntities(files []EntityFile, maxWorkers int) ([]ProcessedEntity, []e
 		fatalErrors = append(fatalErrors, err)
 	}
 
+	checkReferences(processed)
+
 	return processed, fatalErrors
 }
+
+// duplicateContent - result of file with same content as already processed one
+func duplicateContent(file EntityFile, contentHash, existing string) ProcessedEntity {
+	return ProcessedEntity{
+		File:        file,
+		ContentHash: contentHash,
+		Errors:      []string{fmt.Sprintf("duplicate content: same as '%s'", existing)},
+	}
+}
+
+// processContent - cached result or ProcessEntity, result is stored in cache
+func processContent(file EntityFile, content []byte, contentHash string, pkg *Package, cache *BuildCache) ProcessedEntity {
+	if cache != nil {
+		if result, ok := cache.Load(file, contentHash, pkg); ok {
+			result.Cached = true
+			return result
+		}
+	}
+	result := ProcessEntity(file, content, pkg)
+	if cache != nil {
+		cache.Store(result, pkg)
+	}
+	return result
+}
@@ -0,0 +1,488 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProtoNumbers - sidecar mapping of proto field and enum value numbers,
+// kept next to package.yml so numbers never change across builds
+type ProtoNumbers struct {
+	Entities map[string]*ProtoEntityNumbers `json:"entities"`
+}
+
+// ProtoEntityNumbers - numbers of one entity message
+type ProtoEntityNumbers struct {
+	Fields map[string]ProtoFieldNumber `json:"fields"`
+	// numbers of removed or retyped fields, never reused
+	Reserved []int `json:"reserved,omitempty"`
+	// enum field code -> value -> number, values are never removed
+	Enums map[string]map[string]int `json:"enums,omitempty"`
+}
+
+// ProtoFieldNumber - number and proto type of field
+type ProtoFieldNumber struct {
+	Number int    `json:"number"`
+	Type   string `json:"type"`
+}
+
+// 19000-19999 are reserved by protobuf implementation
+const (
+	protoReservedFrom = 19000
+	protoReservedTo   = 19999
+)
+
+// LoadProtoNumbers - read sidecar mapping, missing file gives empty mapping
+func LoadProtoNumbers(path string) (*ProtoNumbers, error) {
+	numbers := &ProtoNumbers{Entities: make(map[string]*ProtoEntityNumbers)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return numbers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read proto numbers: %w", err)
+	}
+	if err := json.Unmarshal(data, numbers); err != nil {
+		return nil, fmt.Errorf("parse proto numbers %s: %w", path, err)
+	}
+	if numbers.Entities == nil {
+		numbers.Entities = make(map[string]*ProtoEntityNumbers)
+	}
+	return numbers, nil
+}
+
+// SaveProtoNumbers - write sidecar mapping
+func SaveProtoNumbers(numbers *ProtoNumbers, path string) error {
+	return writeJSONFile(numbers, path)
+}
+
+// AssignProtoNumbers - keep known numbers, give new fields and enum values next free ones;
+// removed or retyped fields go to reserved
+func AssignProtoNumbers(numbers *ProtoNumbers, processed []ProcessedEntity) {
+	for _, pe := range validEntities(processed) {
+		key := EntityKey(pe.ParsedData)
+		en := numbers.Entities[key]
+		if en == nil {
+			en = &ProtoEntityNumbers{}
+			numbers.Entities[key] = en
+		}
+		if en.Fields == nil {
+			en.Fields = make(map[string]ProtoFieldNumber)
+		}
+
+		fields := entityFields(pe.ParsedData)
+		present := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			code, _ := getFieldCodeAndType(field)
+			present[code] = true
+		}
+		for code, fn := range en.Fields {
+			if !present[code] {
+				en.Reserved = append(en.Reserved, fn.Number)
+				delete(en.Fields, code)
+			}
+		}
+
+		for _, field := range fields {
+			code, typeStr := getFieldCodeAndType(field)
+			wire := protoWireType(typeStr)
+
+			fn, exists := en.Fields[code]
+			if exists && fn.Type != wire {
+				en.Reserved = append(en.Reserved, fn.Number)
+				exists = false
+			}
+			if !exists {
+				en.Fields[code] = ProtoFieldNumber{Number: en.nextFieldNumber(), Type: wire}
+			}
+
+			if typeStr == string(TypeEnum) {
+				en.assignEnumValues(code, field)
+			}
+		}
+		sort.Ints(en.Reserved)
+	}
+}
+
+func (en *ProtoEntityNumbers) nextFieldNumber() int {
+	next := 1
+	for _, fn := range en.Fields {
+		if fn.Number >= next {
+			next = fn.Number + 1
+		}
+	}
+	for _, n := range en.Reserved {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	if next >= protoReservedFrom && next <= protoReservedTo {
+		next = protoReservedTo + 1
+	}
+	return next
+}
+
+func (en *ProtoEntityNumbers) assignEnumValues(code string, field map[string]any) {
+	if en.Enums == nil {
+		en.Enums = make(map[string]map[string]int)
+	}
+	values := en.Enums[code]
+	if values == nil {
+		values = make(map[string]int)
+		en.Enums[code] = values
+	}
+
+	next := 1 // 0 is <ENUM>_UNSPECIFIED
+	for _, n := range values {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	list, _ := field["values"].([]any)
+	for _, v := range list {
+		s, _ := v.(string)
+		if _, exists := values[s]; !exists {
+			values[s] = next
+			next++
+		}
+	}
+}
+
+// CheckProtoCompat - field numbers of previous version must keep their meaning in next one
+func CheckProtoCompat(prev, next *ProtoNumbers) []string {
+	var errs []string
+
+	for _, key := range sortedKeys(prev.Entities) {
+		old, cur := prev.Entities[key], next.Entities[key]
+		if cur == nil {
+			continue // message removed, its numbers are not reused
+		}
+
+		reserved := make(map[int]bool, len(cur.Reserved))
+		for _, n := range cur.Reserved {
+			reserved[n] = true
+		}
+		owners := make(map[int]string, len(cur.Fields))
+		for code, fn := range cur.Fields {
+			owners[fn.Number] = code
+		}
+
+		for _, code := range sortedKeys(old.Fields) {
+			fn := old.Fields[code]
+			switch owner, used := owners[fn.Number]; {
+			case used && owner != code:
+				errs = append(errs, fmt.Sprintf("%s: field number %d of '%s' is reused by '%s'", key, fn.Number, code, owner))
+			case used && cur.Fields[code].Type != fn.Type:
+				errs = append(errs, fmt.Sprintf("%s: field '%s' (%d) changed type %s -> %s",
+					key, code, fn.Number, fn.Type, cur.Fields[code].Type))
+			case !used && cur.Fields[code].Number != 0 && !reserved[fn.Number]:
+				errs = append(errs, fmt.Sprintf("%s: field '%s' changed number %d -> %d without reserving old one",
+					key, code, fn.Number, cur.Fields[code].Number))
+			case !used && !reserved[fn.Number]:
+				errs = append(errs, fmt.Sprintf("%s: number %d of removed field '%s' is not reserved", key, fn.Number, code))
+			}
+		}
+
+		for _, n := range old.Reserved {
+			if owner, used := owners[n]; used {
+				errs = append(errs, fmt.Sprintf("%s: reserved number %d is used by '%s'", key, n, owner))
+			} else if !reserved[n] {
+				errs = append(errs, fmt.Sprintf("%s: reserved number %d is no longer reserved", key, n))
+			}
+		}
+
+		for _, code := range sortedKeys(old.Enums) {
+			for value, n := range old.Enums[code] {
+				if m, exists := cur.Enums[code][value]; !exists || m != n {
+					errs = append(errs, fmt.Sprintf("%s: enum value '%s' of '%s' changed number %d -> %d", key, value, code, n, m))
+				}
+			}
+		}
+	}
+
+	sort.Strings(errs)
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// protoWireType - proto scalar type of field, enums are named per field
+func protoWireType(typeStr string) string {
+	if typeStr == string(TypeEnum) {
+		return "enum"
+	}
+	switch FieldKind(typeStr) {
+	case TypeNumber:
+		return "double"
+	case TypeInteger:
+		return "int64"
+	case TypeBoolean:
+		return "bool"
+	}
+	// decimals are exact decimal strings
+	return "string"
+}
+
+// GenerateProto - <module>.proto with message per entity and validation.proto with
+// generic ValidateRecord service; numbers must be assigned by AssignProtoNumbers
+func GenerateProto(pkg *Package, processed []ProcessedEntity, numbers *ProtoNumbers) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	locale := PackageLocale(pkg)
+	root := protoFieldName(pkg.Name)
+
+	// lowercase file name and proto package -> owner, names must not collide
+	fileOwners := map[string]string{"validation.proto": "ValidateRecord service"}
+	packageOwners := make(map[string]string)
+
+	modules := groupByModule(validEntities(processed))
+	for _, module := range sortedKeys(modules) {
+		fileName := pathSegment(module) + ".proto"
+		if other, exists := fileOwners[strings.ToLower(fileName)]; exists {
+			return nil, fmt.Errorf("module '%s': file %s collides with %s", module, fileName, other)
+		}
+		fileOwners[strings.ToLower(fileName)] = "module '" + module + "'"
+
+		protoPackage := root + "." + protoFieldName(module)
+		if other, exists := packageOwners[protoPackage]; exists {
+			return nil, fmt.Errorf("module '%s': proto package %s already used by module '%s'", module, protoPackage, other)
+		}
+		packageOwners[protoPackage] = module
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "// %s\n// module '%s' of preset %s v%s\n\nsyntax = \"proto3\";\n\npackage %s;\n",
+			generatedHeader, module, pkg.Name, pkg.Version, protoPackage)
+
+		seen := make(map[string]string)
+		for _, pe := range modules[module] {
+			key := EntityKey(pe.ParsedData)
+			en := numbers.Entities[key]
+			if en == nil {
+				return nil, fmt.Errorf("%s: no proto numbers for '%s'", pe.File.Path, key)
+			}
+
+			name := protoIdent(strings.Join([]string{
+				GetFieldString(pe.ParsedData, "object"),
+				GetFieldString(pe.ParsedData, "property"),
+				GetFieldString(pe.ParsedData, "code"),
+			}, "_"))
+			if other, exists := seen[name]; exists {
+				return nil, fmt.Errorf("%s: message %s already generated for %s", pe.File.Path, name, other)
+			}
+			seen[name] = key
+
+			msg, err := protoMessage(name, pe.ParsedData, en, locale)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pe.File.Path, err)
+			}
+			b.WriteString("\n")
+			b.WriteString(msg)
+		}
+
+		files[fileName] = []byte(b.String())
+	}
+
+	files["validation.proto"] = []byte(fmt.Sprintf(protoValidationService, generatedHeader, root))
+	return files, nil
+}
+
+const protoValidationService = `// %s
+
+syntax = "proto3";
+
+package %s;
+
+import "google/protobuf/struct.proto";
+
+// RecordValidator - validation of records against entity definitions
+service RecordValidator {
+  rpc ValidateRecord(ValidateRecordRequest) returns (ValidateRecordResponse);
+}
+
+message ValidateRecordRequest {
+  // entity key: module.object.property.code
+  string entity = 1;
+  // record as JSON object, keys are field codes
+  google.protobuf.Struct record = 2;
+  // region of validators, empty - package region
+  string region = 3;
+}
+
+message ValidateRecordResponse {
+  bool valid = 1;
+  repeated string errors = 2;
+}
+`
+
+func protoMessage(name string, parsed map[string]any, en *ProtoEntityNumbers, locale string) (string, error) {
+	var decls, body strings.Builder
+	names := make(map[string]string)
+
+	for _, field := range entityFields(parsed) {
+		code, typeStr := getFieldCodeAndType(field)
+		fn, ok := en.Fields[code]
+		if !ok {
+			return "", fmt.Errorf("field %s: no proto number", code)
+		}
+
+		fieldName := protoFieldName(code)
+		if other, exists := names[fieldName]; exists {
+			return "", fmt.Errorf("field %s: proto name %s already used by %s", code, fieldName, other)
+		}
+		names[fieldName] = code
+
+		protoType := fn.Type
+		if typeStr == string(TypeEnum) {
+			protoType = protoIdent(code)
+			decls.WriteString(protoEnum(protoType, code, field, en.Enums[code]))
+		}
+
+		if title := LocalizedText(field["name"], locale); title != "" {
+			fmt.Fprintf(&body, "  // %s\n", strings.ReplaceAll(title, "\n", " "))
+		}
+		label := ""
+		if required, _ := field["required"].(bool); !required {
+			label = "optional "
+		}
+		option := ""
+		if fieldName != code {
+			option = fmt.Sprintf(" [json_name = %s]", strconv.Quote(code))
+		}
+		fmt.Fprintf(&body, "  %s%s %s = %d%s;\n", label, protoType, fieldName, fn.Number, option)
+	}
+
+	var b strings.Builder
+	if title := LocalizedText(parsed["name"], locale); title != "" {
+		fmt.Fprintf(&b, "// %s\n", strings.ReplaceAll(title, "\n", " "))
+	}
+	fmt.Fprintf(&b, "// entity %s\nmessage %s {\n", EntityKey(parsed), name)
+	if len(en.Reserved) > 0 {
+		reserved := make([]string, len(en.Reserved))
+		for i, n := range en.Reserved {
+			reserved[i] = strconv.Itoa(n)
+		}
+		fmt.Fprintf(&b, "  reserved %s;\n\n", strings.Join(reserved, ", "))
+	}
+	b.WriteString(decls.String())
+	b.WriteString(body.String())
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// protoEnum - nested enum, values are prefixed with field name as proto requires
+func protoEnum(name, code string, field map[string]any, numbers map[string]int) string {
+	prefix := strings.ToUpper(protoFieldName(code))
+	values, _ := field["values"].([]any)
+	current := make(map[string]bool, len(values))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  enum %s {\n    %s_UNSPECIFIED = 0;\n", name, prefix)
+
+	used := map[string]bool{"UNSPECIFIED": true}
+	for _, v := range values {
+		s, _ := v.(string)
+		current[s] = true
+		n := numbers[s]
+
+		ident := strings.ToUpper(protoFieldName(s))
+		if !protoASCII(s) || used[ident] {
+			ident = "VALUE_" + strconv.Itoa(n)
+		}
+		used[ident] = true
+		fmt.Fprintf(&b, "    %s_%s = %d; // %s\n", prefix, ident, n, strconv.Quote(s))
+	}
+
+	var removed []int
+	for s, n := range numbers {
+		if !current[s] {
+			removed = append(removed, n)
+		}
+	}
+	if len(removed) > 0 {
+		sort.Ints(removed)
+		reserved := make([]string, len(removed))
+		for i, n := range removed {
+			reserved[i] = strconv.Itoa(n)
+		}
+		fmt.Fprintf(&b, "    reserved %s;\n", strings.Join(reserved, ", "))
+	}
+	b.WriteString("  }\n\n")
+	return b.String()
+}
+
+// protoIdent - CamelCase message and enum name: ASCII letters and digits, other letters
+// and digits as U<code point>; proto identifiers are ASCII only
+func protoIdent(code string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range code {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			upper = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			fmt.Fprintf(&b, "U%04X", r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	ident := b.String()
+	if ident == "" || (ident[0] >= '0' && ident[0] <= '9') {
+		ident = "F" + ident
+	}
+	return ident
+}
+
+// protoFieldName - lower snake identifier, other letters and digits as u<code point>;
+// json_name keeps original code
+func protoFieldName(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(code) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_':
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			fmt.Fprintf(&b, "u%04x", r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "f_" + name
+	}
+	return name
+}
+
+// protoASCII - value has ascii letters or digits to build readable enum value name
+func protoASCII(s string) bool {
+	hasAlnum := false
+	for _, r := range s {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			hasAlnum = true
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+		default:
+			return false
+		}
+	}
+	return hasAlnum
+}
@@ -0,0 +1,108 @@
+package preset
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testEntity - valid processed entity for generators
+func testEntity(module, object, property, code string, fields ...map[string]any) ProcessedEntity {
+	list := make([]any, len(fields))
+	for i, f := range fields {
+		list[i] = f
+	}
+	parsed := map[string]any{"module": module, "object": object, "property": property, "code": code, "fields": list}
+	return ProcessedEntity{
+		File:       EntityFile{Path: module + "/" + code + ".yml"},
+		ParsedData: parsed,
+		Schema:     map[string]any{},
+	}
+}
+
+func generateTestProto(processed ...ProcessedEntity) (map[string][]byte, error) {
+	numbers := &ProtoNumbers{Entities: make(map[string]*ProtoEntityNumbers)}
+	AssignProtoNumbers(numbers, processed)
+	return GenerateProto(&Package{Name: "test", Version: "1.0.0"}, processed, numbers)
+}
+
+func TestProtoIdentifiersASCII(t *testing.T) {
+	files, err := generateTestProto(testEntity("клиенты", "клиент", "реквизит", "ип",
+		map[string]any{"code": "инн", "type": "string"},
+		map[string]any{"code": "кпп", "type": "string"},
+		map[string]any{"code": "вид", "type": "enum", "values": []any{"ОСН", "УСН"}},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ident := regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	decl := regexp.MustCompile(`(?m)^\s*(?:package|message|enum)\s+([^\s;{]+)|^\s*(?:optional\s+)?\S+\s+(\S+)\s+=\s+\d+`)
+	for name, data := range files {
+		for _, m := range decl.FindAllStringSubmatch(string(data), -1) {
+			for _, part := range strings.Split(m[1]+m[2], ".") {
+				if !ident.MatchString(part) {
+					t.Errorf("%s: identifier %q is not ASCII proto identifier", name, part)
+				}
+			}
+		}
+	}
+}
+
+func TestProtoFileCollisions(t *testing.T) {
+	field := map[string]any{"code": "id", "type": "string"}
+	tests := []struct {
+		name    string
+		modules []string
+		errText string
+	}{
+		{"validation module", []string{"validation"}, "validation.proto collides with ValidateRecord service"},
+		{"case-only", []string{"crm", "CRM"}, "crm.proto collides with module 'CRM'"},
+		{"same package", []string{"crm-x", "crm_x"}, "proto package test.crm_x already used by module 'crm-x'"},
+		{"distinct", []string{"crm", "hr"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processed []ProcessedEntity
+			for _, module := range tt.modules {
+				processed = append(processed, testEntity(module, "client", "requisite", "a", field))
+			}
+			_, err := generateTestProto(processed...)
+			switch {
+			case tt.errText == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.errText != "" && (err == nil || !strings.Contains(err.Error(), tt.errText)):
+				t.Errorf("error = %v, want %q", err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestProtoNumbersStable(t *testing.T) {
+	numbers := &ProtoNumbers{Entities: make(map[string]*ProtoEntityNumbers)}
+	v1 := testEntity("crm", "client", "requisite", "a",
+		map[string]any{"code": "inn", "type": "string"},
+		map[string]any{"code": "kpp", "type": "string"})
+	AssignProtoNumbers(numbers, []ProcessedEntity{v1})
+	prev := &ProtoNumbers{Entities: map[string]*ProtoEntityNumbers{}}
+	for k, en := range numbers.Entities {
+		fields := make(map[string]ProtoFieldNumber)
+		for c, fn := range en.Fields {
+			fields[c] = fn
+		}
+		prev.Entities[k] = &ProtoEntityNumbers{Fields: fields}
+	}
+
+	// kpp removed, staff added: kpp's number is reserved, not reused
+	v2 := testEntity("crm", "client", "requisite", "a",
+		map[string]any{"code": "inn", "type": "string"},
+		map[string]any{"code": "staff", "type": "integer"})
+	AssignProtoNumbers(numbers, []ProcessedEntity{v2})
+	en := numbers.Entities["crm.client.requisite.a"]
+	if en.Fields["inn"].Number != 1 || en.Fields["staff"].Number != 3 || len(en.Reserved) != 1 || en.Reserved[0] != 2 {
+		t.Errorf("numbers = %+v", en)
+	}
+	if errs := CheckProtoCompat(prev, numbers); len(errs) != 0 {
+		t.Errorf("CheckProtoCompat = %v", errs)
+	}
+}
@@ -0,0 +1,112 @@
+package preset
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+var patternCache sync.Map // pattern -> *regexp.Regexp
+
+func compiledPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(normalizePattern(pattern))
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// ValidateRecord - runtime validation of a record (decoded JSON object) against entity definition;
+// decode records with json.Decoder.UseNumber to keep decimal values exact
+func ValidateRecord(entity map[string]any, record map[string]any, region string) []string {
+	var errors []string
+
+	fields, ok := entity["fields"].([]any)
+	if !ok {
+		return []string{"entity has no fields"}
+	}
+	byCode := fieldsByCode(fields)
+
+	// unknown keys, sorted for stable output
+	unknown := make([]string, 0)
+	for key := range record {
+		if _, exists := byCode[key]; !exists {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errors = append(errors, fmt.Sprintf("field %s: not defined in entity", key))
+	}
+
+	for _, fieldAny := range fields {
+		field, ok := fieldAny.(map[string]any)
+		if !ok {
+			continue
+		}
+		code, _ := field["code"].(string)
+
+		value, exists := record[code]
+		if !exists || value == nil {
+			if required, _ := field["required"].(bool); required {
+				errors = append(errors, fmt.Sprintf("field %s: required", code))
+			}
+			continue
+		}
+
+		for _, err := range validateValue(field, value, record, region) {
+			errors = append(errors, fmt.Sprintf("field %s: %s", code, err))
+		}
+	}
+
+	return errors
+}
+
+// validateValue - check single value by field definition
+func validateValue(field map[string]any, value any, record map[string]any, region string) []string {
+	_, typeStr := getFieldCodeAndType(field)
+
+	def, ok := LookupType(typeStr)
+	if !ok {
+		return []string{fmt.Sprintf("unsupported type '%s'", typeStr)}
+	}
+
+	decoded, err := def.Decode(field, value)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var errors []string
+	if def.Check != nil {
+		errors = append(errors, def.Check(field, decoded)...)
+	}
+	if def.CheckRecord != nil {
+		errors = append(errors, def.CheckRecord(field, decoded, record)...)
+	}
+
+	if name, ok := field["format"].(string); ok && name != "" {
+		s, _ := decoded.(string)
+		if f, found := LookupFormat(name); !found {
+			errors = append(errors, fmt.Sprintf("unknown format '%s'", name))
+		} else if err := f.Check(s, fieldFormatRegion(field, region)); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if name, ok := field["validator"].(string); ok && name != "" {
+		s, _ := decoded.(string)
+		if v, found := LookupValidator(region, name); !found {
+			errors = append(errors, fmt.Sprintf("unknown validator '%s' for region '%s'", name, region))
+		} else if err := runValidator(v, s, field, record); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,42 @@
+package preset
+
+import "fmt"
+
+// entityReferences - codes and targets of reference fields
+func entityReferences(parsed map[string]any) map[string]string {
+	refs := make(map[string]string)
+	for _, field := range entityFields(parsed) {
+		if code, typeStr := getFieldCodeAndType(field); typeStr == string(TypeReference) {
+			if ref, ok := field["ref"].(string); ok && ref != "" {
+				refs[code] = ref
+			}
+		}
+	}
+	return refs
+}
+
+// checkReferences - cross-file check: every ref points to an entity of the package
+func checkReferences(processed []ProcessedEntity) {
+	keys := make(map[string]bool, len(processed))
+	for _, pe := range processed {
+		if pe.ParsedData != nil {
+			keys[EntityKey(pe.ParsedData)] = true
+		}
+	}
+
+	for i := range processed {
+		pe := &processed[i]
+		if pe.ParsedData == nil {
+			continue
+		}
+		refs := entityReferences(pe.ParsedData)
+		for _, field := range entityFields(pe.ParsedData) {
+			code, _ := field["code"].(string)
+			if ref, ok := refs[code]; ok && !keys[ref] {
+				pe.Errors = append(pe.Errors,
+					fmt.Sprintf("field %s: ref '%s' not found in package", code, ref))
+				pe.Schema = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// registry layout: index.json and packages/<id>/<id>-<version>.tar.gz
+const (
+	RegistryIndexFile   = "index.json"
+	registryPackagesDir = "packages"
+	registryLockFile    = ".lock"
+)
+
+// limit of bundle and index downloaded from registry
+const maxRegistryFile = 256 << 20
+
+// RegistryIndex - published packages of registry by package id
+type RegistryIndex struct {
+	Packages map[string]*RegistryPackage `json:"packages"`
+}
+
+// RegistryPackage - releases of package, sorted by version
+type RegistryPackage struct {
+	Name     string            `json:"name"`
+	Releases []RegistryRelease `json:"releases"`
+}
+
+// RegistryRelease - published bundle of package version
+type RegistryRelease struct {
+	Version      string            `json:"version"`
+	File         string            `json:"file"` // slash path under registry root
+	SHA256       string            `json:"sha256"`
+	Size         int64             `json:"size"`
+	Description  string            `json:"description,omitempty"`
+	Region       string            `json:"region,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+	KeyID        string            `json:"key_id,omitempty"` // "" - unsigned
+	Published    time.Time         `json:"published"`
+}
+
+// Latest - release with the highest version
+func (p *RegistryPackage) Latest() RegistryRelease {
+	return p.Releases[len(p.Releases)-1]
+}
+
+// Search - packages whose id or name contains query, or with tag equal to query,
+// or whose latest description contains query; "" - all packages. Sorted by id
+func (idx *RegistryIndex) Search(query string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var ids []string
+	for _, id := range sortedKeys(idx.Packages) {
+		p := idx.Packages[id]
+		if query == "" || matchesPackage(id, p, query) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func matchesPackage(id string, p *RegistryPackage, query string) bool {
+	latest := p.Latest()
+	if strings.Contains(id, query) || strings.Contains(strings.ToLower(p.Name), query) ||
+		strings.Contains(strings.ToLower(latest.Description), query) {
+		return true
+	}
+	for _, tag := range latest.Tags {
+		if strings.ToLower(tag) == query {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve - highest release of package id matching all constraints
+func (idx *RegistryIndex) Resolve(id string, constraints ...Constraint) (*RegistryRelease, error) {
+	p, ok := idx.Packages[id]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not in registry", id)
+	}
+	for i := len(p.Releases) - 1; i >= 0; i-- {
+		v, err := ParseVersion(p.Releases[i].Version)
+		if err != nil {
+			continue
+		}
+		matched := true
+		for _, c := range constraints {
+			if !c.Match(v) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return &p.Releases[i], nil
+		}
+	}
+
+	required := make([]string, len(constraints))
+	for i, c := range constraints {
+		required[i] = c.String()
+	}
+	return nil, fmt.Errorf("package %s: no version matches %s", id, strings.Join(required, ", "))
+}
+
+func (idx *RegistryIndex) add(id, name string, release RegistryRelease) {
+	p, ok := idx.Packages[id]
+	if !ok {
+		p = &RegistryPackage{}
+		idx.Packages[id] = p
+	}
+	p.Name = name
+	p.Releases = append(p.Releases, release)
+	sort.SliceStable(p.Releases, func(i, j int) bool {
+		a, _ := ParseVersion(p.Releases[i].Version)
+		b, _ := ParseVersion(p.Releases[j].Version)
+		return a.Compare(b) < 0
+	})
+}
+
+func parseRegistryIndex(data []byte) (*RegistryIndex, error) {
+	idx := &RegistryIndex{}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("registry %s: %w", RegistryIndexFile, err)
+	}
+	if idx.Packages == nil {
+		idx.Packages = make(map[string]*RegistryPackage)
+	}
+	for id, p := range idx.Packages {
+		if p == nil || len(p.Releases) == 0 {
+			delete(idx.Packages, id)
+		}
+	}
+	return idx, nil
+}
+
+// Registry - source of published bundles
+type Registry interface {
+	Index() (*RegistryIndex, error)
+	// Fetch - file of release, as in RegistryRelease.File
+	Fetch(file string) ([]byte, error)
+	String() string
+}
+
+// IsRegistryURL - location of registry served over http(s)
+func IsRegistryURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// OpenRegistry - registry served over http(s) for URL, local registry directory otherwise
+func OpenRegistry(location string) Registry {
+	if IsRegistryURL(location) {
+		return &httpRegistry{base: strings.TrimRight(location, "/"), client: &http.Client{Timeout: time.Minute}}
+	}
+	return dirRegistry(location)
+}
+
+// dirRegistry - registry rooted in local directory
+type dirRegistry string
+
+func (r dirRegistry) String() string { return string(r) }
+
+// Index - empty index when the directory has no packages yet
+func (r dirRegistry) Index() (*RegistryIndex, error) {
+	data, err := os.ReadFile(filepath.Join(string(r), RegistryIndexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &RegistryIndex{Packages: make(map[string]*RegistryPackage)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRegistryIndex(data)
+}
+
+func (r dirRegistry) Fetch(file string) ([]byte, error) {
+	if !fs.ValidPath(file) {
+		return nil, fmt.Errorf("invalid registry file %q", file)
+	}
+	return os.ReadFile(filepath.Join(string(r), filepath.FromSlash(file)))
+}
+
+// httpRegistry - registry directory served by RegistryHandler
+type httpRegistry struct {
+	base   string
+	client *http.Client
+}
+
+func (r *httpRegistry) String() string { return r.base }
+
+func (r *httpRegistry) Index() (*RegistryIndex, error) {
+	data, err := r.Fetch(RegistryIndexFile)
+	if err != nil {
+		return nil, err
+	}
+	return parseRegistryIndex(data)
+}
+
+func (r *httpRegistry) Fetch(file string) ([]byte, error) {
+	if !fs.ValidPath(file) {
+		return nil, fmt.Errorf("invalid registry file %q", file)
+	}
+	resp, err := r.client.Get(r.base + "/" + file)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s/%s: %s", r.base, file, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryFile+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRegistryFile {
+		return nil, fmt.Errorf("%s/%s: larger than %d bytes", r.base, file, maxRegistryFile)
+	}
+	return data, nil
+}
+
+// RegistryHandler - read-only HTTP access to registry directory: index.json and bundles
+func RegistryHandler(root string) http.Handler {
+	files := http.FileServer(http.Dir(root))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		p := path.Clean(r.URL.Path)
+		if p != "/"+RegistryIndexFile && !(strings.HasPrefix(p, "/"+registryPackagesDir+"/") && strings.HasSuffix(p, BundleExt)) {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// PublishBundle - bundle data stored in registry directory root and added to its index.
+// The bundle is verified first (checksums, signature, trust store of opts). Published
+// versions are immutable: publishing the same bytes again is a no-op, other bytes an error
+func PublishBundle(root string, data []byte, opts BundleOptions, now time.Time) (*RegistryRelease, error) {
+	b, err := ReadBundleWith(bytes.NewReader(data), opts)
+	if err != nil {
+		return nil, err
+	}
+	m := b.Manifest
+	if _, err := ParseVersion(m.Version); err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	id := PackageID(m.Name)
+
+	unlock, err := lockRegistry(root)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reg := dirRegistry(root)
+	idx, err := reg.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256Hex(data)
+	if p, ok := idx.Packages[id]; ok {
+		for _, r := range p.Releases {
+			if r.Version != m.Version {
+				continue
+			}
+			if r.SHA256 == sum {
+				return &r, nil
+			}
+			return nil, fmt.Errorf("%s %s is already published with other content", id, m.Version)
+		}
+	}
+
+	release := RegistryRelease{
+		Version:      m.Version,
+		File:         path.Join(registryPackagesDir, id, id+"-"+m.Version+BundleExt),
+		SHA256:       sum,
+		Size:         int64(len(data)),
+		Description:  m.Description,
+		Region:       m.Region,
+		Tags:         m.Tags,
+		Dependencies: m.Dependencies,
+		Published:    now.UTC().Truncate(time.Second),
+	}
+	if b.Signature != nil {
+		release.KeyID = b.Signature.KeyID
+	}
+
+	if err := writeFile(data, filepath.Join(root, filepath.FromSlash(release.File))); err != nil {
+		return nil, err
+	}
+	idx.add(id, m.Name, release)
+	index, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(append(index, '\n'), filepath.Join(root, RegistryIndexFile)); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// lockRegistry - exclusive lock of registry directory between publishers
+func lockRegistry(root string) (func(), error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	lockPath := filepath.Join(root, registryLockFile)
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("registry %s is locked by another publish, remove %s if it is stale", root, lockPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return func() { os.Remove(lockPath) }, nil
+}
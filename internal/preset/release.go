@@ -0,0 +1,270 @@
+package preset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ReleasePlan - next release of the package computed against the last released bundle
+type ReleasePlan struct {
+	Name      string // bundle name of the package
+	Previous  string // version of the last release, "" - first release
+	Version   string
+	Required  string // bump level required by changes
+	Changes   []EntityChange
+	Changelog string // CHANGELOG.md entry
+}
+
+// PlanRelease - version and changes of the next release; bump "" - the level required by
+// changes, a lower level than required is an error. Version of package.yml is kept when it
+// is already bumped enough
+func PlanRelease(pkg *Package, processed []ProcessedEntity, releasesDir, bump string, date time.Time) (*ReleasePlan, error) {
+	if bump != "" && bump != BumpPatch && bump != BumpMinor && bump != BumpMajor {
+		return nil, fmt.Errorf("bump must be patch, minor or major, got %s", bump)
+	}
+	current, err := ParseVersion(pkg.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ReleasePlan{Name: releaseName(pkg)}
+	entities := releaseEntities(processed)
+
+	previous, prevEntities, err := lastRelease(releasesDir, plan.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous == nil {
+		// first release: everything is added, version of package.yml as is
+		plan.Changes = DiffEntities(nil, entities)
+		plan.Required = RequiredBump(plan.Changes)
+		plan.Version = current.String()
+	} else {
+		plan.Previous = previous.String()
+		plan.Changes = DiffEntities(prevEntities, entities)
+		plan.Required = RequiredBump(plan.Changes)
+
+		level := plan.Required
+		if bump != "" {
+			if bumpRank[bump] < bumpRank[level] {
+				return nil, fmt.Errorf("bump %s is lower than %s required by changes", bump, level)
+			}
+			level = bump
+		}
+		if level == BumpNone {
+			return nil, fmt.Errorf("no changes since %s, use bump to release anyway", plan.Previous)
+		}
+
+		next := previous.Bump(level)
+		if current.Compare(next) > 0 {
+			next = current
+		}
+		plan.Version = next.String()
+	}
+
+	if _, err := os.Stat(bundlePath(releasesDir, plan.Name, plan.Version)); err == nil {
+		return nil, fmt.Errorf("release %s %s already exists", plan.Name, plan.Version)
+	}
+
+	plan.Changelog = FormatChangelog(plan.Version, date.Format(time.DateOnly), plan.Changes)
+	return plan, nil
+}
+
+// WriteRelease - bundle of the plan in releasesDir, signed with key unless nil, CHANGELOG.md
+// entry and version of package.yml in dir. Returns path of the bundle
+func WriteRelease(dir string, pkg *Package, processed []ProcessedEntity, plan *ReleasePlan, releasesDir string, key *SigningKey) (string, error) {
+	if HasValidationErrors(processed) {
+		return "", fmt.Errorf("release refused: package has validation errors")
+	}
+
+	configPath := filepath.Join(dir, "package.yml")
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", err
+	}
+	config, err = setPackageVersion(config, plan.Version)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := writeBundle(releasesDir, plan, pkg, processed, key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := prependChangelog(filepath.Join(dir, "CHANGELOG.md"), plan.Changelog); err != nil {
+		return path, err
+	}
+	if err := writeFile(config, configPath); err != nil {
+		return path, err
+	}
+	pkg.Version = plan.Version
+	return path, nil
+}
+
+// writeBundle - bundle of the release and its sha256 file (sha256sum format) in releasesDir
+func writeBundle(releasesDir string, plan *ReleasePlan, pkg *Package, processed []ProcessedEntity, key *SigningKey) (string, error) {
+	released := *pkg
+	released.Version = plan.Version
+
+	var buf bytes.Buffer
+	if _, err := WriteBundle(&buf, &released, processed, key); err != nil {
+		return "", err
+	}
+
+	path := bundlePath(releasesDir, plan.Name, plan.Version)
+	sum := fmt.Sprintf("%s  %s\n", sha256Hex(buf.Bytes()), filepath.Base(path))
+	if err := writeFile([]byte(sum), path+".sha256"); err != nil {
+		return "", err
+	}
+	if err := writeFile(buf.Bytes(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func bundlePath(releasesDir, name, version string) string {
+	return filepath.Join(releasesDir, name+"-"+version+BundleExt)
+}
+
+// releaseName - package name usable in file names: lower case, words joined with -
+func releaseName(pkg *Package) string {
+	return PackageID(pkg.Name)
+}
+
+// PackageID - id of package name in releases and registries: lower case, words joined with -
+func PackageID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "package"
+	}
+	return b.String()
+}
+
+// lastRelease - highest version of bundles of name in releasesDir and its entities by key;
+// nil version - no releases
+func lastRelease(releasesDir, name string) (*Version, map[string]map[string]any, error) {
+	entries, err := os.ReadDir(releasesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var versions []Version
+	for _, e := range entries {
+		rest, ok := strings.CutPrefix(e.Name(), name+"-")
+		if !ok || e.IsDir() {
+			continue
+		}
+		rest, ok = strings.CutSuffix(rest, BundleExt)
+		if !ok {
+			continue
+		}
+		if v, err := ParseVersion(rest); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, nil, nil
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) > 0 })
+	last := versions[0]
+
+	b, err := OpenBundle(bundlePath(releasesDir, name, last.String()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("last release %s: %w", last, err)
+	}
+	return &last, b.Entities, nil
+}
+
+// releaseEntities - valid entities by key
+func releaseEntities(processed []ProcessedEntity) map[string]map[string]any {
+	entities := make(map[string]map[string]any, len(processed))
+	for _, pe := range validEntities(processed) {
+		entities[EntityKey(pe.ParsedData)] = pe.ParsedData
+	}
+	return entities
+}
+
+// setPackageVersion - package.yml with new version, the rest of the text is kept
+func setPackageVersion(data []byte, version string) ([]byte, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML in package.yml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("package.yml is empty")
+	}
+	_, v := mappingNode(doc.Content[0], "version")
+	if v == nil || v.Kind != yamlv3.ScalarNode {
+		return nil, fmt.Errorf("package.yml has no version")
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	line := lines[v.Line-1]
+	start := v.Column - 1
+	end := start + len(v.Value)
+	if v.Style == yamlv3.DoubleQuotedStyle || v.Style == yamlv3.SingleQuotedStyle {
+		end += 2
+	}
+	if end > len(line) || strings.Trim(line[start:end], `"'`) != v.Value {
+		return nil, fmt.Errorf("package.yml: cannot locate version value")
+	}
+	lines[v.Line-1] = line[:start] + version + line[end:]
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// prependChangelog - entry on top of CHANGELOG.md, below its title
+func prependChangelog(path, entry string) error {
+	const title = "# Changelog\n"
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	text := string(data)
+	if text == "" {
+		text = title
+	}
+
+	head, rest := "", text
+	if i := strings.Index(text, "\n## "); i >= 0 {
+		head, rest = text[:i+1], text[i+1:]
+	} else if strings.HasPrefix(text, "## ") {
+		rest = text
+	} else {
+		head, rest = text, ""
+	}
+	head = strings.TrimRight(head, "\n") + "\n\n"
+	if head == "\n\n" {
+		head = ""
+	}
+	if rest != "" {
+		entry += "\n"
+	}
+	return writeFile([]byte(head+entry+rest), path)
+}
@@ -14,8 +14,12 @@ func PrintResults(pkg *Package, processed []ProcessedEntity, fatalErrs []error)
 
 	// Processing stats
 	stats := GetStats(processed)
-	fmt.Printf("processed:%d failed:%d errors:%d\n\n",
+	fmt.Printf("processed:%d failed:%d errors:%d",
 		stats.Success, stats.Failed, stats.TotalErrors)
+	if stats.Cached > 0 {
+		fmt.Printf(" cached:%d", stats.Cached)
+	}
+	fmt.Printf("\n\n")
 
 	// Files table
 	if len(processed) > 0 {
@@ -0,0 +1,348 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// InitOptions - package created by InitPreset; empty values get defaults
+type InitOptions struct {
+	Name          string // default - name of the directory
+	Version       string // default - 0.1.0
+	Region        string
+	Description   string
+	Locales       []string
+	DefaultLocale string // default - first of locales
+}
+
+// buildConfigTemplate - yieldaa.yml of new package: defaults, commented out
+const buildConfigTemplate = `# build config of the package; flags of the CLI override it
+# workers: %d
+# strict: false              # lint warnings fail the build
+# duplicates: %s              # skip or error: files with same content
+# limits:
+#   required_keys: [%s]
+#   name_min: %d
+#   name_max: %d
+#   region_min: %d
+#   region_max: %d
+#   max_fields: 0            # 0 - no limit
+# outputs:
+#   entities: ./output/entities.json
+#   i18n: %s
+#   gen: {go: ./output/go, ts: ./output/ts}
+# registry: ./../registry    # directory or URL of publish and install
+# lint:
+#   rules:
+#     string-max: error
+`
+
+// InitPreset - package.yml, entities directory and yieldaa.yml in dir; existing package
+// is not overwritten. Returns created paths
+func InitPreset(dir string, opts InitOptions) ([]string, error) {
+	configPath := filepath.Join(dir, "package.yml")
+	if _, err := os.Stat(configPath); err == nil {
+		return nil, fmt.Errorf("%s already exists", configPath)
+	}
+
+	if opts.Name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		opts.Name = filepath.Base(abs)
+	}
+	if opts.Version == "" {
+		opts.Version = "0.1.0"
+	}
+	if opts.DefaultLocale == "" && len(opts.Locales) > 0 {
+		opts.DefaultLocale = opts.Locales[0]
+	}
+
+	// yieldaa.yml of dir, if any, sets the limits of the new package
+	build, err := LoadBuildConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := Package{
+		Name:          opts.Name,
+		Version:       opts.Version,
+		Region:        opts.Region,
+		Description:   opts.Description,
+		Locales:       opts.Locales,
+		DefaultLocale: opts.DefaultLocale,
+		Build:         build,
+	}
+	if err := validateConfig(pkg); err != nil {
+		return nil, fmt.Errorf("error validating package.yml: %w", err)
+	}
+
+	root := &yamlv3.Node{Kind: yamlv3.MappingNode}
+	pairs := []struct {
+		key   string
+		value any
+	}{
+		{"name", pkg.Name},
+		{"version", pkg.Version},
+		{"description", pkg.Description},
+		{"region", pkg.Region},
+		{"locales", pkg.Locales},
+		{"default_locale", pkg.DefaultLocale},
+	}
+	for _, p := range pairs {
+		if p.value == "" || p.value == nil || (p.key == "locales" && len(pkg.Locales) == 0) {
+			continue
+		}
+		if err := appendPair(root, p.key, p.value); err != nil {
+			return nil, err
+		}
+	}
+	config, err := encodeYAML(root)
+	if err != nil {
+		return nil, err
+	}
+	if config, err = FormatPackageYAML(config); err != nil {
+		return nil, err
+	}
+
+	var created []string
+	entitiesDir := filepath.Join(dir, "entities")
+	if err := os.MkdirAll(entitiesDir, 0755); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	created = append(created, entitiesDir)
+
+	buildPath := filepath.Join(dir, ConfigFileName)
+	if _, err := os.Stat(buildPath); errors.Is(err, fs.ErrNotExist) {
+		limits := DefaultBuildLimits()
+		template := fmt.Sprintf(buildConfigTemplate, DefaultWorkers, DuplicatesSkip,
+			strings.Join(limits.RequiredKeys, ", "), limits.NameMin, limits.NameMax,
+			limits.RegionMin, limits.RegionMax, I18nAnnotations)
+		if err := writeFile([]byte(template), buildPath); err != nil {
+			return nil, err
+		}
+		created = append(created, buildPath)
+	}
+
+	if err := writeFile(config, configPath); err != nil {
+		return nil, err
+	}
+	created = append(created, configPath)
+
+	if _, err := LoadPreset(dir); err != nil {
+		return created, fmt.Errorf("created package does not load: %w", err)
+	}
+	return created, nil
+}
+
+// NewEntityOptions - entity file created by NewEntity
+type NewEntityOptions struct {
+	From string // entity key of the package or path of entity file to clone fields from
+	Name string // name in every locale, default - words of the code
+	Path string // default - entities/<module>/<code>.yml
+}
+
+// NewEntity - entity file from template or with fields of another entity. The file
+// passes validation of the package; existing files and keys are not overwritten
+func NewEntity(dir, key string, opts NewEntityOptions) (string, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("entity key must be module.object.property.code, got %s", key)
+	}
+	for _, part := range parts {
+		if !snakeCaseRegex.MatchString(part) {
+			return "", fmt.Errorf("entity key part '%s' must be snake_case", part)
+		}
+	}
+	module, code := parts[0], parts[3]
+
+	pkg, err := LoadPreset(dir)
+	if err != nil {
+		return "", err
+	}
+	keys, err := entityPathsByKey(pkg)
+	if err != nil {
+		return "", err
+	}
+	if existing, ok := keys[key]; ok {
+		return "", fmt.Errorf("entity %s is already defined in %s", key, existing)
+	}
+
+	path := opts.Path
+	if path == "" {
+		path = filepath.Join(dir, "entities", module, code+".yml")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = humanize(code)
+	}
+
+	root := &yamlv3.Node{Kind: yamlv3.MappingNode}
+	for i, k := range []string{"module", "object", "property", "code"} {
+		if err := appendPair(root, k, parts[i]); err != nil {
+			return "", err
+		}
+	}
+	if err := appendPair(root, "name", localizedText(pkg, name)); err != nil {
+		return "", err
+	}
+	for _, required := range buildConfig(pkg).Limits.RequiredKeys {
+		if required == "description" {
+			if err := appendPair(root, "description", localizedText(pkg, name)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if opts.From != "" {
+		fields, err := cloneFields(keys, opts.From)
+		if err != nil {
+			return "", err
+		}
+		fieldsKey := &yamlv3.Node{}
+		fieldsKey.SetString("fields")
+		root.Content = append(root.Content, fieldsKey, fields)
+	} else {
+		template := []map[string]any{{
+			"code":     "title",
+			"name":     localizedText(pkg, "Title"),
+			"type":     string(TypeString),
+			"max":      255,
+			"examples": []string{"Example"},
+		}}
+		if err := appendPair(root, "fields", template); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := encodeYAML(root)
+	if err != nil {
+		return "", err
+	}
+	if data, err = FormatEntityYAML(data, FormatOptions{Locales: pkg.Locales}); err != nil {
+		return "", err
+	}
+
+	// the file must pass validation as is
+	result := ProcessEntity(EntityFile{Path: path, Size: int64(len(data))}, data, pkg)
+	if result.FatalError != nil {
+		return "", fmt.Errorf("generated entity does not parse: %w", result.FatalError)
+	}
+	errs := result.Errors
+	for _, field := range entityFields(result.ParsedData) {
+		fieldCode, typeStr := getFieldCodeAndType(field)
+		ref, _ := field["ref"].(string)
+		if typeStr != string(TypeReference) || ref == key {
+			continue
+		}
+		if _, ok := keys[ref]; !ok {
+			errs = append(errs, fmt.Sprintf("field %s: ref '%s' not found in package", fieldCode, ref))
+		}
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("generated entity does not pass validation:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	if err := writeFile(data, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// entityPathsByKey - entity key -> file of the package, files that do not parse are skipped
+func entityPathsByKey(pkg *Package) (map[string]string, error) {
+	files := append([]EntityFile(nil), pkg.EntitiesFiles...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	keys := make(map[string]string, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		var parsed map[string]any
+		if yaml.Unmarshal(data, &parsed) != nil {
+			continue
+		}
+		if key := EntityKey(parsed); key != "..." {
+			if _, ok := keys[key]; !ok {
+				keys[key] = f.Path
+			}
+		}
+	}
+	return keys, nil
+}
+
+// cloneFields - fields node of entity by key of the package or by file path, comments are kept
+func cloneFields(keys map[string]string, from string) (*yamlv3.Node, error) {
+	path, ok := keys[from]
+	if !ok {
+		if _, err := os.Stat(from); err != nil {
+			return nil, fmt.Errorf("entity %s not found: neither key of the package nor file", from)
+		}
+		path = from
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: empty document", path)
+	}
+	_, fields := mappingNode(doc.Content[0], "fields")
+	if fields == nil || fields.Kind != yamlv3.SequenceNode {
+		return nil, fmt.Errorf("%s: no fields to clone", path)
+	}
+	return fields, nil
+}
+
+// localizedText - text in every locale of the package, plain string without locales
+func localizedText(pkg *Package, text string) any {
+	if len(pkg.Locales) == 0 {
+		return text
+	}
+	m := make(map[string]string, len(pkg.Locales))
+	for _, locale := range pkg.Locales {
+		m[locale] = text
+	}
+	return m
+}
+
+// humanize - snake_case code as words: legal_entity -> Legal entity
+func humanize(code string) string {
+	s := strings.ReplaceAll(code, "_", " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// appendPair - key and encoded value to mapping node
+func appendPair(m *yamlv3.Node, key string, value any) error {
+	k, v := &yamlv3.Node{}, &yamlv3.Node{}
+	k.SetString(key)
+	if err := v.Encode(value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	m.Content = append(m.Content, k, v)
+	return nil
+}
+
+func encodeYAML(root *yamlv3.Node) ([]byte, error) {
+	doc := &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{root}}
+	return yamlv3.Marshal(doc)
+}
@@ -0,0 +1,82 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestScaffoldBuildsClean(t *testing.T) {
+	tests := []struct {
+		name string
+		opts InitOptions
+	}{
+		{"defaults", InitOptions{Name: "demo"}},
+		{"region", InitOptions{Name: "demo", Region: "ru", Description: "Demo preset"}},
+		{"locales", InitOptions{Name: "demo", Region: "ru", Locales: []string{"ru", "en"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := InitPreset(dir, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range []struct{ key, from string }{
+				{"crm.client.requisite.company", ""},
+				{"crm.client.requisite.person", "crm.client.requisite.company"},
+				{"hr.employee.profile.main", ""},
+			} {
+				if _, err := NewEntity(dir, e.key, NewEntityOptions{From: e.from}); err != nil {
+					t.Fatalf("NewEntity(%s): %v", e.key, err)
+				}
+			}
+
+			pkg, processed, fatalErrs := LoadAndProcessPreset(dir, 2)
+			if pkg == nil || len(fatalErrs) > 0 {
+				t.Fatalf("build: %v", fatalErrs)
+			}
+			if len(processed) != 3 {
+				t.Errorf("built %d entities, want 3", len(processed))
+			}
+			for _, pe := range processed {
+				if len(pe.Errors) > 0 || pe.Schema == nil {
+					t.Errorf("%s: %v", pe.File.Path, pe.Errors)
+				}
+			}
+
+			issues, err := LintPreset(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, issue := range issues {
+				t.Errorf("lint %s %s: %s", issue.Rule, issue.Path, issue.Message)
+			}
+		})
+	}
+}
+
+func TestScaffoldBuildConfigTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := InitPreset(dir, InitOptions{Name: "demo"}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every commented key of the template is a key of the config
+	uncommented := regexp.MustCompile(`(?m)^# ( *[a-z0-9_-]+:)`).ReplaceAll(data, []byte("$1"))
+	if err := os.WriteFile(path, uncommented, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadBuildConfig(dir)
+	if err != nil {
+		t.Fatalf("uncommented %s:\n%s\n%v", ConfigFileName, uncommented, err)
+	}
+	if cfg.Workers != DefaultWorkers || cfg.Outputs.I18n != I18nAnnotations || cfg.Lint.Rules["string-max"] != LintError {
+		t.Errorf("config = %+v", cfg)
+	}
+}
@@ -2,36 +2,54 @@ package preset
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"strings"
 )
 
 // pre-scan entities - collect meta
 func ScanEntities(dir string) ([]EntityFile, error) {
+	return scanEntities(dirFS(dir), ".")
+}
+
+// ScanEntitiesFS - entity files under root of fsys; paths of files are slash paths of fsys
+func ScanEntitiesFS(fsys fs.FS, root string) ([]EntityFile, error) {
+	return scanEntities(presetFS{fsys: fsys}, root)
+}
+
+func scanEntities(p presetFS, root string) ([]EntityFile, error) {
 	var files []EntityFile
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(p.fsys, root, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !strings.HasSuffix(strings.ToLower(info.Name()), ".yml") &&
-			!strings.HasSuffix(strings.ToLower(info.Name()), ".yaml") {
+		if d.IsDir() ||
+			!strings.HasSuffix(strings.ToLower(d.Name()), ".yml") &&
+				!strings.HasSuffix(strings.ToLower(d.Name()), ".yaml") {
 			return nil
 		}
 
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
 		files = append(files, EntityFile{
-			Path:    path,
+			Path:    p.path(name),
 			Size:    info.Size(),
 			ModTime: info.ModTime(),
+			fsys:    p,
+			name:    path.Clean(name),
+			root:    path.Clean(root),
 		})
 
 		return nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("scan failed: %w", err)
+		return nil, fmt.Errorf("scan failed: %w", p.pathError(err))
 	}
 
 	return files, nil
@@ -4,10 +4,17 @@ import (
 	"bytes"         // ← ДОБАВИТЬ
 	"encoding/json" // ← ДОБАВИТЬ
 	"fmt"
-	"strconv"
 )
 
-func GenerateJSONSchema(parsed map[string]any) (map[string]any, error) {
+type SchemaOptions struct {
+	Region string
+	// locale of titles and descriptions
+	Locale string
+	// add x-i18n with all translations of localized names
+	Annotate bool
+}
+
+func GenerateJSONSchema(parsed map[string]any, opts SchemaOptions) (map[string]any, error) {
 	// fields from parsed
 	fieldsAny, ok := parsed["fields"].([]any)
 	if !ok {
@@ -28,18 +35,27 @@ func GenerateJSONSchema(parsed map[string]any) (map[string]any, error) {
 		"$id": fmt.Sprintf("/%s/%s/%s/schema.json",
 			parsed["module"], parsed["object"], parsed["code"]),
 		"type":                 "object",
-		"title":                parsed["name"],
+		"title":                LocalizedText(parsed["name"], opts.Locale),
 		"additionalProperties": false,
 		"properties":           make(map[string]any),
 		"required":             []string{},
 	}
 
+	if description := LocalizedText(parsed["description"], opts.Locale); description != "" {
+		schema["description"] = description
+	}
+	if opts.Annotate {
+		if annotation := i18nAnnotation(parsed["name"], parsed["description"]); annotation != nil {
+			schema["x-i18n"] = annotation
+		}
+	}
+
 	properties := schema["properties"].(map[string]any)
 	required := make([]string, 0)
 
 	for _, field := range fields {
 		fieldCode, _ := field["code"].(string)
-		fieldName, _ := field["name"].(string)
+		fieldName := LocalizedText(field["name"], opts.Locale)
 		isRequired, _ := field["required"].(bool)
 
 		// schema for field
@@ -48,14 +64,30 @@ func GenerateJSONSchema(parsed map[string]any) (map[string]any, error) {
 		// + title
 		fieldSchema["title"] = fieldName
 
-		if description, ok := field["description"].(string); ok && description != "" {
+		if description := LocalizedText(field["description"], opts.Locale); description != "" {
 			fieldSchema["description"] = description
 		}
 
+		if opts.Annotate {
+			if annotation := i18nAnnotation(field["name"], field["description"]); annotation != nil {
+				fieldSchema["x-i18n"] = annotation
+			}
+		}
+
 		if examples, ok := field["examples"].([]any); ok && len(examples) > 0 {
 			fieldSchema["examples"] = examples
 		}
 
+		applyFormatSchema(field, fieldSchema, opts.Region)
+
+		// algorithmic check, not expressible in JSON Schema
+		if validator, ok := field["validator"].(string); ok && validator != "" {
+			fieldSchema["x-validator"] = validator
+			if v, ok := LookupValidator(opts.Region, validator); ok && v.Schema != nil {
+				v.Schema(field, fieldSchema)
+			}
+		}
+
 		properties[fieldCode] = fieldSchema
 
 		if isRequired {
@@ -74,125 +106,14 @@ func GenerateJSONSchema(parsed map[string]any) (map[string]any, error) {
 
 func generateFieldJSONSchema(field map[string]any) map[string]any {
 	fieldType, _ := field["type"].(string)
-	schema := make(map[string]any)
-
-	switch fieldType {
-	case "string":
-		schema["type"] = "string"
 
-		if pattern, ok := field["pattern"].(string); ok && pattern != "" {
-			normalized := normalizePatternForSchema(pattern)
-			if pattern == "YYYY-MM-DD" {
-				schema["format"] = "date"
-			}
-			schema["pattern"] = normalized
-		}
-
-		if min := getNumberValue(field, "min"); min != nil {
-			schema["minLength"] = int(*min)
-		}
-		if max := getNumberValue(field, "max"); max != nil {
-			schema["maxLength"] = int(*max)
-		}
-
-		// default value
-		if def, ok := field["default"].(string); ok && def != "" {
-			schema["default"] = def
-		}
-
-	case "number":
-		schema["type"] = "number"
-
-		// range
-		if min := getNumberValue(field, "min"); min != nil {
-			schema["minimum"] = *min
-		}
-		if max := getNumberValue(field, "max"); max != nil {
-			schema["maximum"] = *max
-		}
-
-		// multiplicity
-		if multiple := getNumberValue(field, "multiple_of"); multiple != nil {
-			schema["multipleOf"] = *multiple
-		}
-		if multiple := getNumberValue(field, "multipleOf"); multiple != nil {
-			schema["multipleOf"] = *multiple
-		}
-
-		// default
-		if def, ok := field["default"].(float64); ok {
-			schema["default"] = def
-		}
-		if def, ok := field["default"].(int); ok {
-			schema["default"] = float64(def)
-		}
-
-	case "integer":
-		schema["type"] = "integer"
-
-		// range
-		if min := getNumberValue(field, "min"); min != nil {
-			schema["minimum"] = int(*min)
-		}
-		if max := getNumberValue(field, "max"); max != nil {
-			schema["maximum"] = int(*max)
-		}
-
-		// multiplicity
-		if multiple := getNumberValue(field, "multiple_of"); multiple != nil {
-			schema["multipleOf"] = *multiple
-		}
-		if multiple := getNumberValue(field, "multipleOf"); multiple != nil {
-			schema["multipleOf"] = *multiple
-		}
-
-		// default
-		if def, ok := field["default"].(float64); ok {
-			schema["default"] = int(def)
-		}
-		if def, ok := field["default"].(int); ok {
-			schema["default"] = def
-		}
-		if def, ok := field["default"].(string); ok && def != "" {
-			if intVal, err := strconv.Atoi(def); err == nil {
-				schema["default"] = intVal
-			}
-		}
-
-	case "boolean":
-		schema["type"] = "boolean"
-
-		// default
-		if def, ok := field["default"].(bool); ok {
-			schema["default"] = def
-		}
-		if def, ok := field["default"].(string); ok && def != "" {
-			if def == "true" {
-				schema["default"] = true
-			} else if def == "false" {
-				schema["default"] = false
-			}
-		}
-
-	case "enum":
-		schema["type"] = "string"
-
-		// allowable
-		if values, ok := field["values"].([]any); ok && len(values) > 0 {
-			schema["enum"] = values
-		}
-
-		// default
-		if def, ok := field["default"].(string); ok && def != "" {
-			schema["default"] = def
-		}
-
-	default:
+	def, ok := LookupType(fieldType)
+	if !ok {
 		// undefined -> string
-		schema["type"] = "string"
+		return map[string]any{"type": "string"}
 	}
 
-	return schema
+	return def.Schema(field)
 }
 
 func formatJSONData(jsonData []byte) string {
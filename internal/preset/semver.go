@@ -0,0 +1,172 @@
+package preset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bump levels, ordered
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+var bumpRank = map[string]int{BumpNone: 0, BumpPatch: 1, BumpMinor: 2, BumpMajor: 3}
+
+// maxBump - higher of two bump levels
+func maxBump(a, b string) string {
+	if bumpRank[b] > bumpRank[a] {
+		return b
+	}
+	return a
+}
+
+// Version - X.Y.Z version of package.yml
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion - X.Y.Z, as required by package.yml
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("version must be X.Y.Z format, got %s", s)
+	}
+	var n [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || p == "" || strings.HasPrefix(p, "+") {
+			return Version{}, fmt.Errorf("version must be X.Y.Z format, got %s", s)
+		}
+		n[i] = v
+	}
+	return Version{n[0], n[1], n[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare - -1, 0 or 1 as v is lower, equal or higher than other
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Bump - next version for level; none - same version
+func (v Version) Bump(level string) Version {
+	switch level {
+	case BumpMajor:
+		return Version{v.Major + 1, 0, 0}
+	case BumpMinor:
+		return Version{v.Major, v.Minor + 1, 0}
+	case BumpPatch:
+		return Version{v.Major, v.Minor, v.Patch + 1}
+	}
+	return v
+}
+
+// Constraint - version requirement of dependency: all of its comparisons must hold.
+// Supported: 1.2.3 (exact), =, >, >=, <, <=, ^1.2.3, ~1.2.3, * (any);
+// comparisons are separated by spaces or commas, missing minor and patch are 0
+type Constraint struct {
+	source string
+	checks []versionCheck
+}
+
+type versionCheck struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint - constraint of package.yml dependencies
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{source: strings.TrimSpace(s)}
+	terms := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, term := range terms {
+		if term == "*" || term == "x" {
+			continue
+		}
+		op := ""
+		for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(term, prefix) {
+				op, term = prefix, strings.TrimPrefix(term, prefix)
+				break
+			}
+		}
+		v, err := parsePartialVersion(term)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("constraint %q: %w", s, err)
+		}
+
+		switch op {
+		case "", "=":
+			c.checks = append(c.checks, versionCheck{"=", v})
+		case "^":
+			upper := Version{v.Major + 1, 0, 0}
+			if v.Major == 0 && v.Minor > 0 {
+				upper = Version{0, v.Minor + 1, 0}
+			} else if v.Major == 0 {
+				upper = Version{0, 0, v.Patch + 1}
+			}
+			c.checks = append(c.checks, versionCheck{">=", v}, versionCheck{"<", upper})
+		case "~":
+			c.checks = append(c.checks, versionCheck{">=", v}, versionCheck{"<", Version{v.Major, v.Minor + 1, 0}})
+		default:
+			c.checks = append(c.checks, versionCheck{op, v})
+		}
+	}
+	return c, nil
+}
+
+// parsePartialVersion - X, X.Y or X.Y.Z
+func parsePartialVersion(s string) (Version, error) {
+	switch strings.Count(s, ".") {
+	case 0:
+		s += ".0.0"
+	case 1:
+		s += ".0"
+	}
+	return ParseVersion(s)
+}
+
+// Match - version satisfies the constraint
+func (c Constraint) Match(v Version) bool {
+	for _, check := range c.checks {
+		cmp := v.Compare(check.v)
+		ok := false
+		switch check.op {
+		case "=":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	if c.source == "" {
+		return "*"
+	}
+	return c.source
+}
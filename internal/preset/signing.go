@@ -0,0 +1,237 @@
+package preset
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SignatureAlgorithm - the only signature algorithm of bundles
+const SignatureAlgorithm = "ed25519"
+
+// key files: private key in PKCS #8 PEM, public key in PKIX PEM
+const (
+	PrivateKeyExt = ".key"
+	PublicKeyExt  = ".pub"
+)
+
+// SigningKey - private key of bundle signatures
+type SigningKey struct {
+	ID      string
+	Private ed25519.PrivateKey
+}
+
+// BundleSignature - signature.json of bundle: signature of sha256 of manifest.json;
+// the manifest has checksums of every other part, so the signature covers the whole bundle
+type BundleSignature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // base64, checked against trust store by the reader
+	Digest    string `json:"digest"`     // sha256 of manifest.json, hex
+	Signature string `json:"signature"`  // base64
+}
+
+// KeyID - short id of public key: first 8 bytes of its sha256, hex
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GenerateKeyPair - new key pair saved as <prefix>.key (mode 0600) and <prefix>.pub;
+// existing files are not overwritten
+func GenerateKeyPair(prefix string) (*SigningKey, error) {
+	keyPath, pubPath := prefix+PrivateKeyExt, prefix+PublicKeyExt
+	if _, err := os.Stat(pubPath); err == nil {
+		return nil, fmt.Errorf("%s already exists", pubPath)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	// private key is created exclusively and never readable by others
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(keyPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%s already exists", keyPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(keyPath)
+		return nil, err
+	}
+
+	if err := writeFile(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), pubPath); err != nil {
+		os.Remove(keyPath)
+		return nil, err
+	}
+	return &SigningKey{ID: KeyID(pub), Private: priv}, nil
+}
+
+// LoadSigningKey - ed25519 private key of PEM file
+func LoadSigningKey(path string) (*SigningKey, error) {
+	block, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an %s key", path, SignatureAlgorithm)
+	}
+	return &SigningKey{ID: KeyID(priv.Public().(ed25519.PublicKey)), Private: priv}, nil
+}
+
+// LoadPublicKey - ed25519 public key of PEM file
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an %s key", path, SignatureAlgorithm)
+	}
+	return pub, nil
+}
+
+func readPEM(path, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s: no %s PEM block", path, blockType)
+	}
+	return block, nil
+}
+
+// TrustStore - public keys whose bundle signatures are accepted, by key id
+type TrustStore struct {
+	keys map[string]ed25519.PublicKey
+}
+
+// LoadTrustStore - public key file, or directory of *.pub files
+func LoadTrustStore(path string) (*TrustStore, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("trust store: %w", err)
+	}
+	paths := []string{path}
+	if info.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(path, "*"+PublicKeyExt)); err != nil {
+			return nil, fmt.Errorf("trust store: %w", err)
+		}
+	}
+
+	store := &TrustStore{keys: make(map[string]ed25519.PublicKey)}
+	for _, p := range paths {
+		pub, err := LoadPublicKey(p)
+		if err != nil {
+			return nil, fmt.Errorf("trust store: %w", err)
+		}
+		store.keys[KeyID(pub)] = pub
+	}
+	if len(store.keys) == 0 {
+		return nil, fmt.Errorf("trust store: no %s files in %s", PublicKeyExt, path)
+	}
+	return store, nil
+}
+
+// Keys - ids of trusted keys, sorted
+func (t *TrustStore) Keys() []string {
+	ids := make([]string, 0, len(t.keys))
+	for id := range t.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// check - signature is made by a trusted key
+func (t *TrustStore) check(sig *BundleSignature) error {
+	if sig == nil {
+		return fmt.Errorf("not signed")
+	}
+	pub, ok := t.keys[sig.KeyID]
+	if !ok || base64.StdEncoding.EncodeToString(pub) != sig.PublicKey {
+		return fmt.Errorf("signed by untrusted key %s", sig.KeyID)
+	}
+	return nil
+}
+
+// signManifest - signature of manifest.json bytes
+func signManifest(manifest []byte, key *SigningKey) BundleSignature {
+	digest := sha256.Sum256(manifest)
+	pub := key.Private.Public().(ed25519.PublicKey)
+	return BundleSignature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     key.ID,
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+		Digest:    hex.EncodeToString(digest[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key.Private, digest[:])),
+	}
+}
+
+// verify - signature matches manifest.json bytes and the key of the signature
+func (s *BundleSignature) verify(manifest []byte) error {
+	if s.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("signature algorithm %s is not supported", s.Algorithm)
+	}
+	pub, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("signature has invalid public key")
+	}
+	if KeyID(pub) != s.KeyID {
+		return fmt.Errorf("signature key id %s does not match its public key", s.KeyID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil {
+		return errors.New("signature is not base64")
+	}
+
+	digest := sha256.Sum256(manifest)
+	if !strings.EqualFold(hex.EncodeToString(digest[:]), s.Digest) {
+		return errors.New("signature digest does not match manifest")
+	}
+	if !ed25519.Verify(pub, digest[:], sig) {
+		return fmt.Errorf("invalid signature of key %s", s.KeyID)
+	}
+	return nil
+}
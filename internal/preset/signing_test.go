@@ -0,0 +1,75 @@
+package preset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "keys", "release")
+
+	key, err := GenerateKeyPair(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(prefix + PrivateKeyExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("private key mode = %o, want 600", mode)
+	}
+
+	loaded, err := LoadSigningKey(prefix + PrivateKeyExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ID != key.ID {
+		t.Errorf("loaded key id = %s, want %s", loaded.ID, key.ID)
+	}
+	pub, err := LoadPublicKey(prefix + PublicKeyExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if KeyID(pub) != key.ID {
+		t.Errorf("public key id = %s, want %s", KeyID(pub), key.ID)
+	}
+
+	if _, err := GenerateKeyPair(prefix); err == nil {
+		t.Error("existing key pair overwritten")
+	}
+}
+
+func TestGenerateKeyPairKeepsExistingPrivateKey(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "release")
+	if err := os.WriteFile(prefix+PrivateKeyExt, []byte("existing"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GenerateKeyPair(prefix); err == nil {
+		t.Fatal("existing private key overwritten")
+	}
+	if data, _ := os.ReadFile(prefix + PrivateKeyExt); string(data) != "existing" {
+		t.Errorf("private key changed: %q", data)
+	}
+	if _, err := os.Stat(prefix + PublicKeyExt); err == nil {
+		t.Error("public key written without private key")
+	}
+}
+
+func TestSignManifest(t *testing.T) {
+	key, err := GenerateKeyPair(filepath.Join(t.TempDir(), "k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := []byte(`{"name":"test"}`)
+	sig := signManifest(manifest, key)
+	if err := sig.verify(manifest); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	if err := sig.verify([]byte(`{"name":"tampered"}`)); err == nil {
+		t.Error("tampered manifest verified")
+	}
+}
@@ -6,6 +6,7 @@ type ProcessStats struct {
 	Failed      int
 	WithErrors  int
 	TotalErrors int
+	Cached      int
 }
 
 func GetStats(processed []ProcessedEntity) ProcessStats {
@@ -18,6 +19,9 @@ func GetStats(processed []ProcessedEntity) ProcessStats {
 		} else {
 			stats.Success++
 		}
+		if p.Cached {
+			stats.Cached++
+		}
 		if len(p.Errors) > 0 {
 			stats.WithErrors++
 			stats.TotalErrors += len(p.Errors)
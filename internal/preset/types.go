@@ -10,6 +10,12 @@ type Package struct {
 	Tags         []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
 	Dependencies map[string]string `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
 
+	Locales       []string `yaml:"locales,omitempty" json:"locales,omitempty"`
+	DefaultLocale string   `yaml:"default_locale,omitempty" json:"default_locale,omitempty"`
+
+	// build section merged with yieldaa.yml
+	Build BuildConfig `yaml:"build,omitempty" json:"build,omitempty"`
+
 	EntitiesFiles         []EntityFile `yaml:"-" json:"entities_files"`
 	EntitiesCount         int          `yaml:"-" json:"entities_count"`
 	EntitiesTotalSize     int64        `yaml:"-" json:"entities_total_size"`
@@ -23,6 +29,12 @@ type EntityFile struct {
 	Size        int64     `json:"size"`
 	ModTime     time.Time `json:"mod_time"`
 	ContentHash string    `json:"content_hash,omitempty"`
+
+	// filesystem the file was scanned in, its name there and the scanned root;
+	// zero fsys - Path is an OS path (name is the source of bundle entities)
+	fsys presetFS
+	name string
+	root string
 }
 
 type ProcessedEntity struct {
@@ -33,6 +45,11 @@ type ProcessedEntity struct {
 	Schema      map[string]any `json:"schema"` // JSON Schema
 	Errors      []string       // Ошибки валидации
 	FatalError  error          // Фатальная ошибка чтения/конвертации
+
+	Locale           string                    // Локаль по умолчанию для name/description
+	LocalizedSchemas map[string]map[string]any // JSON Schema по локалям (режим split)
+
+	Cached bool // Результат взят из кэша сборки
 }
 
 type EntityOutput struct {
@@ -41,6 +58,8 @@ type EntityOutput struct {
 	JSONData   string           `json:"json_data,omitempty"`
 	Schema     map[string]any   `json:"schema"`
 	Validation ValidationResult `json:"validation"`
+
+	LocalizedSchemas map[string]map[string]any `json:"localized_schemas,omitempty"`
 }
 
 type ValidationResult struct {
@@ -101,9 +120,17 @@ const (
 	TypeInteger FieldType = "integer"
 	TypeBoolean FieldType = "boolean"
 	TypeEnum    FieldType = "enum"
+	TypeDecimal FieldType = "decimal"
+	TypeMoney   FieldType = "money"
+	TypePercent FieldType = "percent"
+
+	TypeReference FieldType = "reference"
 )
 
 const (
 	JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
 	DefaultWorkers  = 10
 )
+
+// ToolVersion - version of the runtime, part of build cache key and bundle manifests
+const ToolVersion = "0.4.0"
@@ -0,0 +1,312 @@
+package preset
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateTypeScript - TypeScript interfaces and Zod schemas (relative path -> content),
+// one directory per module with file per entity and index.ts
+func GenerateTypeScript(pkg *Package, processed []ProcessedEntity) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	locale := PackageLocale(pkg)
+
+	for module, entities := range groupByModule(validEntities(processed)) {
+		dir := pathSegment(module)
+		exports := make([]string, 0, len(entities))
+
+		for _, pe := range entities {
+			name := strings.TrimSuffix(goFileName(pe.ParsedData), ".gen.go")
+
+			src, err := generateTSEntity(pe.ParsedData, locale)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pe.File.Path, err)
+			}
+			files[path.Join(dir, name+".ts")] = []byte(src)
+			exports = append(exports, fmt.Sprintf("export * from \"./%s\";", name))
+		}
+
+		sort.Strings(exports)
+		index := fmt.Sprintf("// %s\n// module '%s' of preset %s v%s\n\n%s\n",
+			generatedHeader, module, pkg.Name, pkg.Version, strings.Join(exports, "\n"))
+		files[path.Join(dir, "index.ts")] = []byte(index)
+	}
+
+	return files, nil
+}
+
+// pathSegment - safe directory/file name from code
+func pathSegment(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "" || s == "." || s == ".." {
+		s = "_" + s
+	}
+	return s
+}
+
+func generateTSEntity(parsed map[string]any, locale string) (string, error) {
+	typeName := goIdent(strings.Join([]string{
+		GetFieldString(parsed, "object"),
+		GetFieldString(parsed, "property"),
+		GetFieldString(parsed, "code"),
+	}, "_"))
+
+	var decls, iface, schema, defaults strings.Builder
+
+	for _, field := range entityFields(parsed) {
+		code, typeStr := getFieldCodeAndType(field)
+		required, _ := field["required"].(bool)
+		key := tsPropertyKey(code)
+
+		tsType, zod, err := tsFieldType(typeName+goIdent(code), field, &decls)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", code, err)
+		}
+
+		if doc := tsDoc(LocalizedText(field["name"], locale), LocalizedText(field["description"], locale), "  "); doc != "" {
+			iface.WriteString(doc)
+		}
+		optional := "?"
+		if required {
+			optional = ""
+		} else {
+			zod += ".optional()"
+		}
+		fmt.Fprintf(&iface, "  %s%s: %s;\n", key, optional, tsType)
+		fmt.Fprintf(&schema, "  %s: %s,\n", key, zod)
+
+		if def, ok := tsDefault(typeStr, field); ok {
+			fmt.Fprintf(&defaults, "  %s: %s,\n", key, def)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n\nimport { z } from \"zod\";\n\n", generatedHeader)
+	b.WriteString(decls.String())
+
+	title := LocalizedText(parsed["name"], locale) + " (" + EntityKey(parsed) + ")"
+	b.WriteString(tsDoc(title, LocalizedText(parsed["description"], locale), ""))
+	fmt.Fprintf(&b, "export interface %s {\n%s}\n\n", typeName, iface.String())
+
+	fmt.Fprintf(&b, "export const %sSchema = z\n  .object({\n%s  })\n  .strict();\n\n",
+		typeName, indentLines(schema.String(), "  "))
+
+	fmt.Fprintf(&b, "export const %sDefaults: Partial<%s> = {\n%s};\n", typeName, typeName, defaults.String())
+
+	return b.String(), nil
+}
+
+// tsFieldType - TypeScript type and Zod expression of field, enum declarations go to decls
+func tsFieldType(prefix string, field map[string]any, decls *strings.Builder) (string, string, error) {
+	_, typeStr := getFieldCodeAndType(field)
+	min := getNumberValue(field, "min")
+	max := getNumberValue(field, "max")
+
+	if typeStr == string(TypeEnum) {
+		values, _ := field["values"].([]any)
+		literals := make([]string, 0, len(values))
+		for _, v := range values {
+			s, _ := v.(string)
+			literals = append(literals, strconv.Quote(s))
+		}
+		fmt.Fprintf(decls, "export const %sValues = [%s] as const;\n", prefix, strings.Join(literals, ", "))
+		fmt.Fprintf(decls, "export type %s = (typeof %sValues)[number];\n\n", prefix, prefix)
+		return prefix, fmt.Sprintf("z.enum(%sValues)", prefix), nil
+	}
+
+	switch FieldKind(typeStr) {
+	case TypeBoolean:
+		return "boolean", "z.boolean()", nil
+
+	case TypeNumber, TypeInteger:
+		zod := "z.number()"
+		if FieldKind(typeStr) == TypeInteger {
+			zod += ".int()"
+		}
+		if min != nil {
+			zod += fmt.Sprintf(".gte(%s)", strconv.FormatFloat(*min, 'g', -1, 64))
+		}
+		if max != nil {
+			zod += fmt.Sprintf(".lte(%s)", strconv.FormatFloat(*max, 'g', -1, 64))
+		}
+		if multiple := getMultipleOf(field); multiple != nil {
+			zod += fmt.Sprintf(".multipleOf(%s)", strconv.FormatFloat(*multiple, 'g', -1, 64))
+		}
+		return "number", zod, nil
+
+	case TypeDecimal:
+		spec, _ := resolveDecimalSpec(field)
+		if spec.Encoding == DecimalAsNumber {
+			zod := "z.number()"
+			if spec.Min != nil {
+				zod += ".gte(" + spec.Min.Text + ")"
+			}
+			if spec.Max != nil {
+				zod += ".lte(" + spec.Max.Text + ")"
+			}
+			if spec.Scale >= 0 {
+				zod += ".multipleOf(1e-" + strconv.Itoa(spec.Scale) + ")"
+			}
+			return "number", zod, nil
+		}
+		// exact string decimal; range is checked by the runtime validator
+		re, err := tsRegexLiteral(decimalPattern(spec))
+		if err != nil {
+			return "", "", err
+		}
+		return "string", "z.string().regex(" + re + ")", nil
+	}
+
+	zod := "z.string()"
+	if pattern, ok := field["pattern"].(string); ok && pattern != "" {
+		re, err := tsRegexLiteral(normalizePattern(pattern))
+		if err != nil {
+			return "", "", err
+		}
+		zod += ".regex(" + re + ")"
+	}
+	switch format, _ := field["format"].(string); format {
+	case "email":
+		zod += ".email()"
+	case "uri", "url":
+		zod += ".url()"
+	case "uuid":
+		zod += ".uuid()"
+	case "ipv4":
+		zod += ".ip({ version: \"v4\" })"
+	case "ipv6":
+		zod += ".ip({ version: \"v6\" })"
+	}
+	// length in code points as the runtime counts runes; .min/.max count UTF-16 units.
+	// refine last: the refined schema has no string methods
+	if min != nil {
+		zod += fmt.Sprintf(".refine((s) => [...s].length >= %d, { message: \"at least %d characters\" })", int(*min), int(*min))
+	}
+	if max != nil {
+		zod += fmt.Sprintf(".refine((s) => [...s].length <= %d, { message: \"at most %d characters\" })", int(*max), int(*max))
+	}
+	return "string", zod, nil
+}
+
+// tsDefault - default value literal of field
+func tsDefault(typeStr string, field map[string]any) (string, bool) {
+	def, exists := field["default"]
+	if !exists || def == nil {
+		return "", false
+	}
+
+	switch FieldKind(typeStr) {
+	case TypeDecimal:
+		d, err := toDecimal(def)
+		if err != nil {
+			return "", false
+		}
+		if encoding, _ := field["encoding"].(string); encoding == DecimalAsNumber {
+			return d.Text, true
+		}
+		return strconv.Quote(d.Text), true
+	case TypeNumber, TypeInteger:
+		if f := getNumberValue(field, "default"); f != nil {
+			return strconv.FormatFloat(*f, 'g', -1, 64), true
+		}
+	case TypeBoolean:
+		if b, ok := def.(bool); ok {
+			return strconv.FormatBool(b), true
+		}
+	default:
+		if s, ok := def.(string); ok {
+			return strconv.Quote(s), true
+		}
+	}
+	return "", false
+}
+
+var (
+	tsIdentRegex    = regexp.MustCompile(`^[\p{L}_$][\p{L}\p{N}_$]*$`)
+	goLeadingFlags  = regexp.MustCompile(`^\(\?([ims]+)\)`)
+	goNamedGroup    = regexp.MustCompile(`\(\?P<`)
+	unsupportedInJS = regexp.MustCompile(`\\[zACQE]|\(\?[a-zA-Z-]*[:)]`)
+)
+
+func tsPropertyKey(code string) string {
+	if tsIdentRegex.MatchString(code) {
+		return code
+	}
+	return strconv.Quote(code)
+}
+
+// tsRegexLiteral - JS regex literal from RE2 pattern
+func tsRegexLiteral(pattern string) (string, error) {
+	flags := ""
+	if m := goLeadingFlags.FindStringSubmatch(pattern); m != nil {
+		flags = m[1]
+		pattern = pattern[len(m[0]):]
+	}
+	pattern = goNamedGroup.ReplaceAllString(pattern, "(?<")
+
+	// non-capturing groups (?:...) are fine, other inline flags and \z etc are not
+	if loc := unsupportedInJS.FindStringIndex(strings.ReplaceAll(pattern, "(?:", "")); loc != nil {
+		return "", fmt.Errorf("pattern %s is not supported in JavaScript", pattern)
+	}
+
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		if r == '/' && !escaped {
+			b.WriteString(`\/`)
+			continue
+		}
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+
+	return "/" + b.String() + "/" + flags, nil
+}
+
+// tsDoc - JSDoc comment
+func tsDoc(title, description, indent string) string {
+	lines := make([]string, 0)
+	if title != "" {
+		lines = append(lines, title)
+	}
+	if description != "" {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(lines) == 1 {
+		fmt.Fprintf(&b, "%s/** %s */\n", indent, strings.ReplaceAll(lines[0], "*/", "*\\/"))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "%s%s\n", indent, strings.TrimRight(" * "+strings.ReplaceAll(line, "*/", "*\\/"), " "))
+	}
+	fmt.Fprintf(&b, "%s */\n", indent)
+	return b.String()
+}
+
+func indentLines(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
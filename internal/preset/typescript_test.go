@@ -0,0 +1,20 @@
+package preset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTSStringLengthInCodePoints(t *testing.T) {
+	var decls strings.Builder
+	field := map[string]any{"code": "name", "type": "string", "min": 2.0, "max": 5.0, "format": "email"}
+	typ, zod, err := tsFieldType("Name", field, &decls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `z.string().email().refine((s) => [...s].length >= 2, { message: "at least 2 characters" })` +
+		`.refine((s) => [...s].length <= 5, { message: "at most 5 characters" })`
+	if typ != "string" || zod != want {
+		t.Errorf("tsFieldType = %s %s, want string %s", typ, zod, want)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/cespare/xxhash/v2"
 )
@@ -53,6 +55,51 @@ func EntityKey(data map[string]any) string {
 	}, ".")
 }
 
+// validEntities - entities with generated schema, sorted by entity key
+func validEntities(processed []ProcessedEntity) []ProcessedEntity {
+	valid := make([]ProcessedEntity, 0, len(processed))
+	for _, pe := range processed {
+		if pe.Schema != nil && pe.ParsedData != nil {
+			valid = append(valid, pe)
+		}
+	}
+	sort.Slice(valid, func(i, j int) bool {
+		return EntityKey(valid[i].ParsedData) < EntityKey(valid[j].ParsedData)
+	})
+	return valid
+}
+
+// entityFields - field definitions of parsed entity
+func entityFields(parsed map[string]any) []map[string]any {
+	fieldsAny, _ := parsed["fields"].([]any)
+	fields := make([]map[string]any, 0, len(fieldsAny))
+	for _, f := range fieldsAny {
+		if field, ok := f.(map[string]any); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// camelCase - snake/kebab/dot separated code -> CamelCase identifier
+func camelCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
 func ShortPath(path string, maxLen int) string {
 	if len(path) <= maxLen {
 		return path
@@ -118,6 +165,14 @@ func getNumberValue(field map[string]any, key string) *float64 {
 	return nil
 }
 
+// getMultipleOf - multipleOf or multiple_of key
+func getMultipleOf(field map[string]any) *float64 {
+	if multiple := getNumberValue(field, "multipleOf"); multiple != nil {
+		return multiple
+	}
+	return getNumberValue(field, "multiple_of")
+}
+
 func validatePattern(pattern string) (bool, string) {
 	if pattern == "" {
 		return true, ""
@@ -156,12 +211,21 @@ func normalizePatternForSchema(pattern string) string {
 }
 
 func isValidType(t string) bool {
-	switch t {
-	case "string", "number", "integer", "boolean", "enum":
-		return true
-	default:
-		return false
+	_, ok := LookupType(t)
+	return ok
+}
+
+// fieldsByCode - index of field definitions by code
+func fieldsByCode(fields []any) map[string]map[string]any {
+	index := make(map[string]map[string]any, len(fields))
+	for _, fieldAny := range fields {
+		if field, ok := fieldAny.(map[string]any); ok {
+			if code, ok := field["code"].(string); ok && code != "" {
+				index[code] = field
+			}
+		}
 	}
+	return index
 }
 
 func getFieldCodeAndType(field map[string]any) (code, fieldType string) {
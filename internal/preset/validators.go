@@ -0,0 +1,134 @@
+package preset
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValueValidator - named algorithmic check of a string value (checksums, control keys)
+type ValueValidator struct {
+	Name        string
+	Description string
+
+	// optional build-time check of the field definition (extra keys like bik_field);
+	// fields - all fields of the entity by code
+	CheckDefinition func(field map[string]any, fields map[string]map[string]any) error
+
+	// optional JSON Schema annotation of the field schema (x-validator is set anyway)
+	Schema func(field map[string]any, schema map[string]any)
+
+	// optional normalization of the value before check (e.g. strip separators)
+	Decode func(value string) (string, error)
+
+	// runtime check; field is the entity field definition, record - whole record
+	Check func(value string, field, record map[string]any) error
+
+	// optional generator of valid values for sample data
+	Example func(r *rand.Rand, field, record map[string]any) string
+}
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = make(map[string]map[string]ValueValidator) // region -> name -> validator
+)
+
+// GlobalRegion - scope of validators available for every package region
+const GlobalRegion = ""
+
+// RegisterValidator - add validator to region scope, replaces existing one with same name
+func RegisterValidator(region string, v ValueValidator) {
+	if v.Name == "" || v.Check == nil {
+		panic("preset: validator must have name and check func")
+	}
+
+	region = strings.ToLower(region)
+
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+
+	if validators[region] == nil {
+		validators[region] = make(map[string]ValueValidator)
+	}
+	validators[region][v.Name] = v
+}
+
+// LookupValidator - find validator by name for region, falls back to global scope
+func LookupValidator(region, name string) (ValueValidator, bool) {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	if v, ok := validators[strings.ToLower(region)][name]; ok {
+		return v, true
+	}
+	v, ok := validators[GlobalRegion][name]
+	return v, ok
+}
+
+// RegionValidators - sorted validator names available for region (including global)
+func RegionValidators(region string) []string {
+	validatorsMu.RLock()
+	defer validatorsMu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, scope := range []string{strings.ToLower(region), GlobalRegion} {
+		for name := range validators[scope] {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runValidator - decode and check value with named validator
+func runValidator(v ValueValidator, value string, field, record map[string]any) error {
+	if v.Decode != nil {
+		decoded, err := v.Decode(value)
+		if err != nil {
+			return err
+		}
+		value = decoded
+	}
+	return v.Check(value, field, record)
+}
+
+// validateFieldValidator - build-time check of 'validator' key
+func validateFieldValidator(field map[string]any, fields map[string]map[string]any, region string) []string {
+	raw, exists := field["validator"]
+	if !exists {
+		return nil
+	}
+
+	name, ok := raw.(string)
+	if !ok || name == "" {
+		return []string{"validator must be non-empty string"}
+	}
+
+	if typeStr, _ := field["type"].(string); FieldKind(typeStr) != TypeString {
+		return []string{fmt.Sprintf("validator '%s' requires string type, got '%s'", name, typeStr)}
+	}
+
+	v, ok := LookupValidator(region, name)
+	if !ok {
+		if region == "" {
+			return []string{fmt.Sprintf("unknown validator '%s': package has no region", name)}
+		}
+		return []string{fmt.Sprintf("unknown validator '%s' for region '%s' (available: %s)",
+			name, region, strings.Join(RegionValidators(region), ", "))}
+	}
+
+	if v.CheckDefinition != nil {
+		if err := v.CheckDefinition(field, fields); err != nil {
+			return []string{fmt.Sprintf("validator '%s': %v", name, err)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,360 @@
+package preset
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// russian requisites: ИНН, ОГРН, ОГРНИП, КПП, СНИЛС, БИК, счета
+func init() {
+	RegisterValidator("ru", ValueValidator{
+		Name:        "inn",
+		Description: "ИНН юрлица (10 цифр) или физлица/ИП (12 цифр)",
+		Check: func(value string, _, _ map[string]any) error {
+			switch len(value) {
+			case 10:
+				return checkINN10(value)
+			case 12:
+				return checkINN12(value)
+			}
+			return fmt.Errorf("ИНН must be 10 or 12 digits")
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleINN10(r)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "inn10",
+		Description: "ИНН юрлица (10 цифр)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkINN10(value)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleINN10(r)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "inn12",
+		Description: "ИНН физлица/ИП (12 цифр)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkINN12(value)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleINN12(r)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "ogrn",
+		Description: "ОГРН (13 цифр, контроль mod 11)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkOGRN(value, 13, 11)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleOGRN(r, "1", 13, 11)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "ogrnip",
+		Description: "ОГРНИП (15 цифр, контроль mod 13)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkOGRN(value, 15, 13)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleOGRN(r, "3", 15, 13)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "kpp",
+		Description: "КПП (9 символов: NNNNPPNNN)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkKPP(value)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return randomDigits(r, 4) + "01" + randomDigits(r, 3)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "snils",
+		Description: "СНИЛС (11 цифр, допускаются '-' и пробелы)",
+		Decode: func(value string) (string, error) {
+			return strings.NewReplacer("-", "", " ", "").Replace(value), nil
+		},
+		Check: func(value string, _, _ map[string]any) error {
+			return checkSNILS(value)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return exampleSNILS(r)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:        "bik",
+		Description: "БИК (9 цифр)",
+		Check: func(value string, _, _ map[string]any) error {
+			return checkBIK(value)
+		},
+		Example: func(r *rand.Rand, _, _ map[string]any) string {
+			return "04" + randomDigits(r, 7)
+		},
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:            "account",
+		Description:     "расчетный счет, ключ по БИК из поля bik_field",
+		Check:           accountCheck(settlementKeyPrefix),
+		CheckDefinition: checkBIKFieldDefinition,
+		Example:         accountExample(settlementKeyPrefix),
+	})
+	RegisterValidator("ru", ValueValidator{
+		Name:            "corr_account",
+		Description:     "корреспондентский счет, ключ по БИК из поля bik_field",
+		Check:           accountCheck(corrKeyPrefix),
+		CheckDefinition: checkBIKFieldDefinition,
+		Example:         accountExample(corrKeyPrefix),
+	})
+}
+
+func digitsOnly(value string) ([]int, bool) {
+	digits := make([]int, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return nil, false
+		}
+		digits[i] = int(value[i] - '0')
+	}
+	return digits, true
+}
+
+func weightedSum(digits, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += digits[i] * w
+	}
+	return sum
+}
+
+func checkINN10(value string) error {
+	d, ok := digitsOnly(value)
+	if !ok || len(d) != 10 {
+		return fmt.Errorf("ИНН must be 10 digits")
+	}
+
+	control := weightedSum(d, []int{2, 4, 10, 3, 5, 9, 4, 6, 8}) % 11 % 10
+	if control != d[9] {
+		return fmt.Errorf("invalid ИНН check digit")
+	}
+	return nil
+}
+
+func checkINN12(value string) error {
+	d, ok := digitsOnly(value)
+	if !ok || len(d) != 12 {
+		return fmt.Errorf("ИНН must be 12 digits")
+	}
+
+	n11 := weightedSum(d, []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) % 11 % 10
+	n12 := weightedSum(d, []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) % 11 % 10
+	if n11 != d[10] || n12 != d[11] {
+		return fmt.Errorf("invalid ИНН check digits")
+	}
+	return nil
+}
+
+// checkOGRN - ОГРН/ОГРНИП: remainder of number without last digit by mod, last digit of remainder
+func checkOGRN(value string, length, mod int) error {
+	d, ok := digitsOnly(value)
+	if !ok || len(d) != length {
+		return fmt.Errorf("must be %d digits", length)
+	}
+
+	// long division, number does not fit into int64 safely for 14 digits * mod
+	rem := 0
+	for _, digit := range d[:length-1] {
+		rem = (rem*10 + digit) % mod
+	}
+	if rem%10 != d[length-1] {
+		return fmt.Errorf("invalid check digit")
+	}
+	return nil
+}
+
+func checkKPP(value string) error {
+	if len(value) != 9 {
+		return fmt.Errorf("КПП must be 9 characters")
+	}
+	for i := 0; i < 9; i++ {
+		c := value[i]
+		isDigit := c >= '0' && c <= '9'
+		if i == 4 || i == 5 {
+			if !isDigit && (c < 'A' || c > 'Z') {
+				return fmt.Errorf("КПП position %d must be digit or A-Z", i+1)
+			}
+			continue
+		}
+		if !isDigit {
+			return fmt.Errorf("КПП position %d must be digit", i+1)
+		}
+	}
+	return nil
+}
+
+func checkSNILS(value string) error {
+	d, ok := digitsOnly(value)
+	if !ok || len(d) != 11 {
+		return fmt.Errorf("СНИЛС must be 11 digits")
+	}
+
+	// numbers up to 001-001-998 are issued without control
+	number := 0
+	for _, digit := range d[:9] {
+		number = number*10 + digit
+	}
+	if number <= 1001998 {
+		return nil
+	}
+
+	sum := weightedSum(d, []int{9, 8, 7, 6, 5, 4, 3, 2, 1})
+	control := sum
+	if sum >= 100 {
+		control = sum % 101
+		if control == 100 {
+			control = 0
+		}
+	}
+
+	if control != d[9]*10+d[10] {
+		return fmt.Errorf("invalid СНИЛС check number")
+	}
+	return nil
+}
+
+func checkBIK(value string) error {
+	if _, ok := digitsOnly(value); !ok || len(value) != 9 {
+		return fmt.Errorf("БИК must be 9 digits")
+	}
+	return nil
+}
+
+// settlement account key uses last 3 digits of БИК; accounts of РКЦ (БИК ending in
+// 000-002) have no corr account, their key uses "0" + 5-6 digits of БИК
+func settlementKeyPrefix(bik string) string {
+	if last := bik[6:9]; last > "002" {
+		return last
+	}
+	return corrKeyPrefix(bik)
+}
+
+// correspondent account key uses "0" + 5-6 digits of БИК
+func corrKeyPrefix(bik string) string {
+	return "0" + bik[4:6]
+}
+
+func accountCheck(prefix func(bik string) string) func(string, map[string]any, map[string]any) error {
+	return func(value string, field, record map[string]any) error {
+		if _, ok := digitsOnly(value); !ok || len(value) != 20 {
+			return fmt.Errorf("account must be 20 digits")
+		}
+
+		bikField, _ := field["bik_field"].(string)
+		bik, _ := record[bikField].(string)
+		if bik == "" {
+			// no БИК - nothing to check the key against, 'required' covers absence
+			return nil
+		}
+		if err := checkBIK(bik); err != nil {
+			return fmt.Errorf("cannot check account key: %v", err)
+		}
+
+		d, _ := digitsOnly(prefix(bik) + value)
+		weights := []int{7, 1, 3}
+		sum := 0
+		for i, digit := range d {
+			sum += digit * weights[i%3] % 10
+		}
+		if sum%10 != 0 {
+			return fmt.Errorf("invalid account control key for БИК %s", bik)
+		}
+		return nil
+	}
+}
+
+func checkBIKFieldDefinition(field map[string]any, fields map[string]map[string]any) error {
+	bikField, ok := field["bik_field"].(string)
+	if !ok || bikField == "" {
+		return fmt.Errorf("requires 'bik_field' with code of БИК field")
+	}
+	if _, exists := fields[bikField]; !exists {
+		return fmt.Errorf("bik_field '%s' not found in entity fields", bikField)
+	}
+	return nil
+}
+
+// --- sample values
+
+func randomDigits(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + r.Intn(10))
+	}
+	return string(b)
+}
+
+func exampleINN10(r *rand.Rand) string {
+	value := randomDigits(r, 9)
+	d, _ := digitsOnly(value)
+	return value + strconv.Itoa(weightedSum(d, []int{2, 4, 10, 3, 5, 9, 4, 6, 8})%11%10)
+}
+
+func exampleINN12(r *rand.Rand) string {
+	value := randomDigits(r, 10)
+	d, _ := digitsOnly(value)
+	value += strconv.Itoa(weightedSum(d, []int{7, 2, 4, 10, 3, 5, 9, 4, 6, 8}) % 11 % 10)
+	d, _ = digitsOnly(value)
+	return value + strconv.Itoa(weightedSum(d, []int{3, 7, 2, 4, 10, 3, 5, 9, 4, 6, 8})%11%10)
+}
+
+func exampleOGRN(r *rand.Rand, first string, length, mod int) string {
+	value := first + randomDigits(r, length-2)
+	rem := 0
+	for i := 0; i < len(value); i++ {
+		rem = (rem*10 + int(value[i]-'0')) % mod
+	}
+	return value + strconv.Itoa(rem%10)
+}
+
+func exampleSNILS(r *rand.Rand) string {
+	// above 001-001-998, so the control number is checked
+	value := strconv.Itoa(100000000 + r.Intn(900000000))
+	d, _ := digitsOnly(value)
+	control := weightedSum(d, []int{9, 8, 7, 6, 5, 4, 3, 2, 1})
+	if control >= 100 {
+		control %= 101
+		if control == 100 {
+			control = 0
+		}
+	}
+	return fmt.Sprintf("%s%02d", value, control)
+}
+
+// accountExample - account with control key (9th digit) for БИК from record
+func accountExample(prefix func(bik string) string) func(*rand.Rand, map[string]any, map[string]any) string {
+	return func(r *rand.Rand, field, record map[string]any) string {
+		value := []byte(randomDigits(r, 20))
+		bikField, _ := field["bik_field"].(string)
+		bik, _ := record[bikField].(string)
+		if checkBIK(bik) != nil {
+			return string(value)
+		}
+
+		value[8] = '0'
+		d, _ := digitsOnly(prefix(bik) + string(value))
+		weights := []int{7, 1, 3}
+		sum := 0
+		for i, digit := range d {
+			sum += digit * weights[i%3] % 10
+		}
+		// key digit has weight 3; 7 is inverse of 3 mod 10
+		value[8] = byte('0' + (10-sum%10)%10*7%10)
+		return string(value)
+	}
+}
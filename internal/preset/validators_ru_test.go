@@ -0,0 +1,68 @@
+package preset
+
+import "testing"
+
+func TestRussianRequisites(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(string) error
+		value string
+		valid bool
+	}{
+		{"inn10", checkINN10, "7707083893", true},
+		{"inn10 check digit", checkINN10, "7707083894", false},
+		{"inn10 length", checkINN10, "770708389", false},
+		{"inn10 letters", checkINN10, "77070838a3", false},
+		{"inn12", checkINN12, "500100732259", true},
+		{"inn12 11th digit", checkINN12, "500100732269", false},
+		{"inn12 12th digit", checkINN12, "500100732258", false},
+		{"ogrn", func(v string) error { return checkOGRN(v, 13, 11) }, "1027700132195", true},
+		{"ogrn check digit", func(v string) error { return checkOGRN(v, 13, 11) }, "1027700132196", false},
+		{"ogrn length", func(v string) error { return checkOGRN(v, 13, 11) }, "102770013219", false},
+		{"ogrnip", func(v string) error { return checkOGRN(v, 15, 13) }, "304500116000157", true},
+		{"ogrnip check digit", func(v string) error { return checkOGRN(v, 15, 13) }, "304500116000158", false},
+		{"snils", checkSNILS, "11223344595", true},
+		{"snils check number", checkSNILS, "11223344596", false},
+		{"snils without control", checkSNILS, "00100199800", true},
+		{"snils length", checkSNILS, "1122334459", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.check(tt.value); (err == nil) != tt.valid {
+				t.Errorf("%s(%q) error = %v, want valid %v", tt.name, tt.value, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestAccountKey(t *testing.T) {
+	field := map[string]any{"bik_field": "bik"}
+	tests := []struct {
+		name    string
+		prefix  func(string) string
+		bik     string
+		account string
+		valid   bool
+	}{
+		{"settlement", settlementKeyPrefix, "044525225", "40702810938000000001", true},
+		{"settlement key", settlementKeyPrefix, "044525225", "40702810838000000001", false},
+		// РКЦ: БИК ending in 000-002, key by "0" + 5-6 digits of БИК
+		{"settlement of РКЦ", settlementKeyPrefix, "044525000", "40101810545250000001", true},
+		{"settlement of РКЦ by last digits", settlementKeyPrefix, "044525000", "40101810445250000001", false},
+		{"settlement of РКЦ 002", settlementKeyPrefix, "044525002", "40101810545250000001", true},
+		{"settlement of БИК 003", settlementKeyPrefix, "044525003", "40101810545250000001", false},
+		{"corr", corrKeyPrefix, "044525225", "30101810400000000225", true},
+		{"corr key", corrKeyPrefix, "044525225", "30101810500000000225", false},
+		{"length", settlementKeyPrefix, "044525225", "4070281093800000000", false},
+		{"no БИК", settlementKeyPrefix, "", "40702810838000000001", true},
+		{"invalid БИК", settlementKeyPrefix, "04452522", "40702810938000000001", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := accountCheck(tt.prefix)(tt.account, field, map[string]any{"bik": tt.bik})
+			if (err == nil) != tt.valid {
+				t.Errorf("account %s for БИК %s error = %v, want valid %v", tt.account, tt.bik, err, tt.valid)
+			}
+		})
+	}
+}
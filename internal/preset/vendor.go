@@ -0,0 +1,225 @@
+package preset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// vendor directory of preset: <id>-<version>.tar.gz of every dependency and vendor.json
+const (
+	VendorDir      = "vendor"
+	VendorLockFile = "vendor.json"
+)
+
+// VendoredPackage - dependency installed into vendor directory
+type VendoredPackage struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	File     string   `json:"file"` // name in vendor directory
+	SHA256   string   `json:"sha256"`
+	KeyID    string   `json:"key_id,omitempty"`
+	Required []string `json:"required_by"` // ids of packages depending on it, sorted
+}
+
+// VendorLock - vendor.json: resolved dependencies, sorted by id
+type VendorLock struct {
+	Registry string            `json:"registry"`
+	Packages []VendoredPackage `json:"packages"`
+}
+
+// InstallOptions - vendor directory and checks of installed bundles
+type InstallOptions struct {
+	Vendor string      // "" - vendor in preset directory
+	Trust  *TrustStore // not nil - unsigned bundles and signatures of other keys are refused
+}
+
+// maximum rounds of dependency resolution before giving up
+const maxResolveRounds = 100
+
+// ResolveDependencies - release of every direct and transitive dependency of the package:
+// the highest version matching constraints of all packages depending on it
+func ResolveDependencies(pkg *Package, idx *RegistryIndex) (map[string]*RegistryRelease, map[string][]string, error) {
+	self := PackageID(pkg.Name)
+	chosen := make(map[string]*RegistryRelease)
+
+	for round := 0; round < maxResolveRounds; round++ {
+		constraints := make(map[string][]Constraint)
+		requiredBy := make(map[string][]string)
+		add := func(from string, deps map[string]string) error {
+			for _, name := range sortedKeys(deps) {
+				id := PackageID(name)
+				if id == self {
+					return fmt.Errorf("%s: dependency on the package itself", from)
+				}
+				c, err := ParseConstraint(deps[name])
+				if err != nil {
+					return fmt.Errorf("%s: dependency %s: %w", from, name, err)
+				}
+				constraints[id] = append(constraints[id], c)
+				requiredBy[id] = append(requiredBy[id], from)
+			}
+			return nil
+		}
+		if err := add(self, pkg.Dependencies); err != nil {
+			return nil, nil, err
+		}
+		// dependencies of chosen releases still reachable from the package
+		visited := make(map[string]bool)
+		for pending := true; pending; {
+			pending = false
+			for _, id := range sortedKeys(constraints) {
+				release, ok := chosen[id]
+				if visited[id] || !ok {
+					continue
+				}
+				visited[id], pending = true, true
+				if err := add(id, release.Dependencies); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+
+		next := make(map[string]*RegistryRelease, len(constraints))
+		changed := false
+		for _, id := range sortedKeys(constraints) {
+			release, err := idx.Resolve(id, constraints[id]...)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w (required by %s)", err, strings.Join(requiredBy[id], ", "))
+			}
+			next[id] = release
+			if prev, ok := chosen[id]; !ok || prev.Version != release.Version {
+				changed = true
+			}
+		}
+		if len(next) != len(chosen) {
+			changed = true
+		}
+		chosen = next
+		if !changed {
+			return chosen, requiredBy, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("dependencies do not converge after %d rounds", maxResolveRounds)
+}
+
+// InstallDependencies - dependencies of the package in dir resolved from registry into
+// vendor directory. Every bundle is checked against sha256 of the index and its own
+// checksums; bundles already vendored with the right sha256 are kept, bundles no longer
+// required are removed
+func InstallDependencies(dir string, pkg *Package, reg Registry, opts InstallOptions) (*VendorLock, error) {
+	vendor := opts.Vendor
+	if vendor == "" {
+		vendor = filepath.Join(dir, VendorDir)
+	}
+
+	idx, err := reg.Index()
+	if err != nil {
+		return nil, fmt.Errorf("registry %s: %w", reg, err)
+	}
+	chosen, requiredBy, err := ResolveDependencies(pkg, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &VendorLock{Registry: reg.String(), Packages: []VendoredPackage{}}
+	keep := map[string]bool{VendorLockFile: true}
+	for _, id := range sortedKeys(chosen) {
+		release := chosen[id]
+		file := id + "-" + release.Version + BundleExt
+		keep[file] = true
+
+		data, err := vendoredBundle(filepath.Join(vendor, file), release.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			if data, err = reg.Fetch(release.File); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", id, release.Version, err)
+			}
+			if sum := sha256Hex(data); sum != release.SHA256 {
+				return nil, fmt.Errorf("%s %s: sha256 %s does not match registry index %s", id, release.Version, sum, release.SHA256)
+			}
+		}
+
+		b, err := ReadBundleWith(bytes.NewReader(data), BundleOptions{Trust: opts.Trust})
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", id, release.Version, err)
+		}
+		if PackageID(b.Manifest.Name) != id || b.Manifest.Version != release.Version {
+			return nil, fmt.Errorf("%s %s: bundle is %s %s", id, release.Version, b.Manifest.Name, b.Manifest.Version)
+		}
+		if err := writeFile(data, filepath.Join(vendor, file)); err != nil {
+			return nil, err
+		}
+
+		required := append([]string(nil), requiredBy[id]...)
+		sort.Strings(required)
+		vp := VendoredPackage{
+			ID:       id,
+			Name:     b.Manifest.Name,
+			Version:  release.Version,
+			File:     file,
+			SHA256:   release.SHA256,
+			Required: required,
+		}
+		if b.Signature != nil {
+			vp.KeyID = b.Signature.KeyID
+		}
+		lock.Packages = append(lock.Packages, vp)
+	}
+
+	if err := removeStaleVendored(vendor, keep); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(append(data, '\n'), filepath.Join(vendor, VendorLockFile)); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// vendoredBundle - bytes of vendored bundle when it exists with sha256, nil otherwise
+func vendoredBundle(path, sum string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sha256Hex(data) != sum {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// removeStaleVendored - bundles of vendor directory not in keep
+func removeStaleVendored(vendor string, keep map[string]bool) error {
+	entries, err := os.ReadDir(vendor)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || keep[e.Name()] || !strings.HasSuffix(e.Name(), BundleExt) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(vendor, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
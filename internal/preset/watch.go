@@ -0,0 +1,312 @@
+package preset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Watcher - incremental rebuild of preset directory; changes are found by polling
+// size and mtime, so it works on any file system
+type Watcher struct {
+	dir  string
+	opts ProcessOptions
+
+	pkg    *Package
+	config fileStamp
+	build  fileStamp // yieldaa.yml, zero - no file
+	files  map[string]fileStamp
+	// ProcessEntity results before cross-file checks, by path
+	results map[string]ProcessedEntity
+	fatal   map[string]error
+	// errors of the last rebuild
+	errors map[string]bool
+	// error of the last poll, returned once while it repeats
+	pollErr string
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// WatchResult - state of the preset after rebuild
+type WatchResult struct {
+	Package   *Package
+	Processed []ProcessedEntity // with cross-file checks, sorted by path
+	Fatal     []error
+	Reloaded  bool     // package.yml or yieldaa.yml changed, every file is reprocessed
+	Changed   []string // reprocessed files
+	Removed   []string
+	NewErrors []string // "path: error"
+	Resolved  []string
+}
+
+func NewWatcher(dir string, opts ProcessOptions) *Watcher {
+	return &Watcher{
+		dir:     dir,
+		opts:    opts,
+		files:   make(map[string]fileStamp),
+		results: make(map[string]ProcessedEntity),
+		fatal:   make(map[string]error),
+		errors:  make(map[string]bool),
+	}
+}
+
+// Poll - rebuild if package.yml, yieldaa.yml or entity files changed since the last poll;
+// nil result - nothing changed. An error is returned once, polls repeating it give nil
+func (w *Watcher) Poll() (*WatchResult, error) {
+	result, err := w.poll()
+	if err == nil {
+		w.pollErr = ""
+		return result, nil
+	}
+	if err.Error() == w.pollErr {
+		return nil, nil
+	}
+	w.pollErr = err.Error()
+	return nil, err
+}
+
+func (w *Watcher) poll() (*WatchResult, error) {
+	configPath := filepath.Join(w.dir, "package.yml")
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("package load failed: %w", err)
+	}
+
+	var build fileStamp
+	if info, err := os.Stat(filepath.Join(w.dir, ConfigFileName)); err == nil {
+		build = stampOf(info)
+	}
+
+	reload := false
+	if stamp := stampOf(info); w.pkg == nil || stamp != w.config || build != w.build {
+		if w.pkg == nil && stamp == w.config && build == w.build {
+			// broken config is already reported
+			return nil, nil
+		}
+		w.config, w.build = stamp, build
+
+		pkg, err := loadConfig(dirFS(w.dir))
+		if err != nil {
+			w.pkg = nil
+			return nil, fmt.Errorf("package load failed: %w", err)
+		}
+		w.pkg = pkg
+		reload = true
+	}
+
+	files, err := ScanEntities(filepath.Join(w.dir, "entities"))
+	if err != nil {
+		return nil, fmt.Errorf("entities scan failed: %w", err)
+	}
+
+	var changed []EntityFile
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f.Path] = true
+		if stamp, ok := w.files[f.Path]; reload || !ok || stamp != (fileStamp{f.Size, f.ModTime}) {
+			changed = append(changed, f)
+		}
+	}
+
+	var removed []string
+	for path := range w.files {
+		if !present[path] {
+			removed = append(removed, path)
+			delete(w.files, path)
+			delete(w.results, path)
+			delete(w.fatal, path)
+		}
+	}
+	sort.Strings(removed)
+
+	if !reload && len(changed) == 0 && len(removed) == 0 {
+		return nil, nil
+	}
+
+	result := &WatchResult{Reloaded: reload, Removed: removed}
+	for _, f := range files {
+		w.files[f.Path] = fileStamp{f.Size, f.ModTime}
+	}
+	result.Changed = w.process(changed, reload)
+	if !reload && len(result.Changed) == 0 && len(removed) == 0 {
+		// only mtime changed
+		return nil, nil
+	}
+
+	setEntityFiles(w.pkg, files)
+	result.Package = w.pkg
+	result.Processed, result.Fatal = w.assemble()
+	result.NewErrors, result.Resolved = w.diffErrors(result.Processed, result.Fatal)
+
+	return result, nil
+}
+
+// process - reprocess changed files in parallel; files with unchanged content keep
+// their result. Returns paths of reprocessed files
+func (w *Watcher) process(files []EntityFile, reload bool) []string {
+	workers := workersOf(w.opts, w.pkg)
+
+	type outcome struct {
+		path      string
+		result    ProcessedEntity
+		err       error
+		unchanged bool
+	}
+
+	// previous results are read by workers while outcomes are collected
+	previous := make(map[string]ProcessedEntity, len(files))
+	if !reload {
+		for _, f := range files {
+			if prev, ok := w.results[f.Path]; ok {
+				previous[f.Path] = prev
+			}
+		}
+	}
+
+	jobs := make(chan EntityFile)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(files); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				content, err := readEntityFile(file)
+				if err != nil {
+					outcomes <- outcome{path: file.Path, err: fmt.Errorf("%s: read: %w", file.Path, err)}
+					continue
+				}
+				contentHash := calculateContentHash(content)
+
+				// touched, but not edited
+				if prev, ok := previous[file.Path]; ok && prev.ContentHash == contentHash {
+					prev.File = file
+					outcomes <- outcome{path: file.Path, result: prev, unchanged: true}
+					continue
+				}
+
+				result := processContent(file, content, contentHash, w.pkg, w.opts.Cache)
+				if result.FatalError != nil {
+					outcomes <- outcome{path: file.Path, err: fmt.Errorf("%s: %w", file.Path, result.FatalError)}
+					continue
+				}
+				outcomes <- outcome{path: file.Path, result: result}
+			}
+		}()
+	}
+
+	go func() {
+		for _, file := range files {
+			jobs <- file
+		}
+		close(jobs)
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var reprocessed []string
+	for o := range outcomes {
+		delete(w.results, o.path)
+		delete(w.fatal, o.path)
+		if o.err != nil {
+			w.fatal[o.path] = o.err
+		} else {
+			w.results[o.path] = o.result
+		}
+		if !o.unchanged {
+			reprocessed = append(reprocessed, o.path)
+		}
+	}
+	sort.Strings(reprocessed)
+	return reprocessed
+}
+
+// assemble - results sorted by path with cross-file checks applied to copies
+func (w *Watcher) assemble() ([]ProcessedEntity, []error) {
+	paths := make([]string, 0, len(w.results))
+	for path := range w.results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	processed := make([]ProcessedEntity, 0, len(paths))
+	duplicates := buildConfig(w.pkg).Duplicates
+	seenHashes := make(map[string]string)
+	seenKeys := make(map[string]string)
+	for _, path := range paths {
+		pe := w.results[path]
+		// same content is processed once, as in ProcessEntities
+		if existing, ok := seenHashes[pe.ContentHash]; ok {
+			if duplicates == DuplicatesError {
+				processed = append(processed, duplicateContent(pe.File, pe.ContentHash, existing))
+			}
+			continue
+		}
+		seenHashes[pe.ContentHash] = path
+
+		pe.Errors = append([]string(nil), pe.Errors...)
+		if pe.ParsedData != nil {
+			if key := EntityKey(pe.ParsedData); key != "" {
+				if existing, ok := seenKeys[key]; ok {
+					pe.Errors = append(pe.Errors,
+						fmt.Sprintf("entity key conflict: '%s' already defined in '%s'", key, existing))
+				} else {
+					seenKeys[key] = path
+				}
+			}
+		}
+		processed = append(processed, pe)
+	}
+	checkReferences(processed)
+
+	fatalPaths := make([]string, 0, len(w.fatal))
+	for path := range w.fatal {
+		fatalPaths = append(fatalPaths, path)
+	}
+	sort.Strings(fatalPaths)
+	fatal := make([]error, 0, len(fatalPaths))
+	for _, path := range fatalPaths {
+		fatal = append(fatal, w.fatal[path])
+	}
+
+	return processed, fatal
+}
+
+// diffErrors - errors that appeared and errors that were resolved since the last rebuild
+func (w *Watcher) diffErrors(processed []ProcessedEntity, fatal []error) (added, resolved []string) {
+	current := make(map[string]bool)
+	for _, pe := range processed {
+		for _, e := range pe.Errors {
+			current[pe.File.Path+": "+e] = true
+		}
+	}
+	for _, err := range fatal {
+		current[err.Error()] = true
+	}
+
+	for e := range current {
+		if !w.errors[e] {
+			added = append(added, e)
+		}
+	}
+	for e := range w.errors {
+		if !current[e] {
+			resolved = append(resolved, e)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(resolved)
+
+	w.errors = current
+	return added, resolved
+}
+
+func stampOf(info os.FileInfo) fileStamp {
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}
+}
@@ -0,0 +1,161 @@
+package preset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watchTestEntity = `module: crm
+object: client
+property: requisite
+code: %s
+name: Sample
+fields:
+  - code: inn
+    name: INN
+    type: %s
+`
+
+// watchTestDir - preset directory and writer of its files; every write moves mtime
+// forward, so polling sees it even within one clock tick
+func watchTestDir(t *testing.T) (string, func(name, content string)) {
+	dir := t.TempDir()
+	mtime := time.Now().Add(-time.Hour)
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime = mtime.Add(time.Second)
+		os.Chtimes(path, mtime, mtime)
+	}
+	write("package.yml", "name: test\nversion: 1.0.0\n")
+	write("entities/a.yml", fmt.Sprintf(watchTestEntity, "a", "string"))
+	write("entities/b.yml", fmt.Sprintf(watchTestEntity, "b", "string"))
+	return dir, write
+}
+
+func pollResult(t *testing.T, w *Watcher) *WatchResult {
+	t.Helper()
+	result, err := w.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func hasPrefix(list []string, prefix string) bool {
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWatcherIncremental(t *testing.T) {
+	dir, write := watchTestDir(t)
+	a, b := filepath.Join(dir, "entities", "a.yml"), filepath.Join(dir, "entities", "b.yml")
+	w := NewWatcher(dir, ProcessOptions{Workers: 2})
+
+	result := pollResult(t, w)
+	if result == nil || !result.Reloaded || len(result.Changed) != 2 || len(result.NewErrors) != 0 {
+		t.Fatalf("first poll = %+v", result)
+	}
+	if result := pollResult(t, w); result != nil {
+		t.Fatalf("poll without changes = %+v", result)
+	}
+
+	// touched, not edited: nothing is reprocessed
+	now := time.Now()
+	os.Chtimes(a, now, now)
+	if result := pollResult(t, w); result != nil {
+		t.Fatalf("poll after touch = %+v", result)
+	}
+
+	// only the edited file is reprocessed, its error is new
+	write("entities/a.yml", fmt.Sprintf(watchTestEntity, "a", "no_such_type"))
+	result = pollResult(t, w)
+	if result == nil || result.Reloaded || len(result.Changed) != 1 || result.Changed[0] != a {
+		t.Fatalf("poll after edit = %+v", result)
+	}
+	if len(result.NewErrors) == 0 || !hasPrefix(result.NewErrors, a+": ") || len(result.Resolved) != 0 {
+		t.Fatalf("errors after edit: +%v -%v", result.NewErrors, result.Resolved)
+	}
+	broken := result.NewErrors
+
+	write("entities/a.yml", fmt.Sprintf(watchTestEntity, "a", "string"))
+	result = pollResult(t, w)
+	if result == nil || len(result.NewErrors) != 0 || strings.Join(result.Resolved, "\n") != strings.Join(broken, "\n") {
+		t.Fatalf("errors after fix: +%v -%v, want -%v", result.NewErrors, result.Resolved, broken)
+	}
+
+	// key conflict is recomputed over unchanged files
+	write("entities/b.yml", fmt.Sprintf(watchTestEntity, "a", "integer"))
+	result = pollResult(t, w)
+	if result == nil || len(result.Changed) != 1 || !hasPrefix(result.NewErrors, b+": entity key conflict") {
+		t.Fatalf("poll after key conflict = %+v", result)
+	}
+	os.Remove(b)
+	result = pollResult(t, w)
+	if result == nil || len(result.Removed) != 1 || result.Removed[0] != b ||
+		len(result.NewErrors) != 0 || !hasPrefix(result.Resolved, b+": entity key conflict") {
+		t.Fatalf("poll after remove = %+v", result)
+	}
+
+	// package config change reprocesses every file
+	write("package.yml", "name: test\nversion: 1.0.1\n")
+	result = pollResult(t, w)
+	if result == nil || !result.Reloaded || len(result.Changed) != 1 || result.Package.Version != "1.0.1" {
+		t.Fatalf("poll after config change = %+v", result)
+	}
+}
+
+func TestWatcherErrorsOnce(t *testing.T) {
+	dir, write := watchTestDir(t)
+	w := NewWatcher(dir, ProcessOptions{Workers: 1})
+	pollResult(t, w)
+
+	tests := []struct {
+		name   string
+		damage func()
+		fix    func()
+	}{
+		{"broken package.yml",
+			func() { write("package.yml", "name: [\n") },
+			func() { write("package.yml", "name: test\nversion: 1.0.0\n") }},
+		{"missing package.yml",
+			func() { os.Rename(filepath.Join(dir, "package.yml"), filepath.Join(dir, "package.bak")) },
+			func() { os.Rename(filepath.Join(dir, "package.bak"), filepath.Join(dir, "package.yml")) }},
+		{"missing entities",
+			func() { os.Rename(filepath.Join(dir, "entities"), filepath.Join(dir, "entities.bak")) },
+			func() { os.Rename(filepath.Join(dir, "entities.bak"), filepath.Join(dir, "entities")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.damage()
+			if _, err := w.Poll(); err == nil {
+				t.Fatal("error not reported")
+			}
+			for i := 0; i < 3; i++ {
+				if result, err := w.Poll(); err != nil || result != nil {
+					t.Fatalf("repeated poll = %+v, %v; want nothing", result, err)
+				}
+			}
+			tt.fix()
+			if _, err := w.Poll(); err != nil {
+				t.Fatalf("poll after fix: %v", err)
+			}
+			if _, err := w.Poll(); err != nil {
+				t.Fatalf("poll after fix: %v", err)
+			}
+		})
+	}
+}